@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"golang.org/x/sync/errgroup"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/pkg/di"
+	"image-resizing-service/pkg/metrics"
+	"image-resizing-service/pkg/utils"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+type importResult struct {
+	Path   string `json:"path"`
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type importReport struct {
+	Imported int            `json:"imported"`
+	Failed   int            `json:"failed"`
+	Skipped  int            `json:"skipped"`
+	Files    []importResult `json:"files"`
+}
+
+func runImport(ctx context.Context, deps *di.Dependencies, args []string) (any, error) {
+	flags := newFlagSet("import", "import [flags] <dir>")
+	concurrency := flags.Int("concurrency", 2, "number of images imported at the same time")
+	recursive := flags.Bool("recursive", true, "descend into subdirectories")
+	tenant := flags.String("tenant", domain.DefaultTenantName, "tenant the images are imported into")
+	visibilityFlag := flags.String("visibility", "", "private or public (default: ACCESS_DEFAULT_VISIBILITY)")
+
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) != 1 {
+		return nil, errors.New("usage: irsctl import [flags] <dir>")
+	}
+
+	if *tenant == "" {
+		return nil, errors.New("-tenant is required")
+	}
+	visibility, err := domain.ParseVisibility(*visibilityFlag, "")
+	if err != nil {
+		return nil, err
+	}
+	ctx, err = withTenant(ctx, deps, *tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := collectFiles(positional[0], *recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]importResult, len(paths))
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(max(*concurrency, 1))
+
+	for i, path := range paths {
+		group.Go(func() error {
+			results[i] = importFile(groupCtx, deps, path, visibility)
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	report := importReport{Files: results}
+	for _, result := range results {
+		switch result.Status {
+		case "skipped":
+			report.Skipped++
+		case "failed":
+			report.Failed++
+		default:
+			report.Imported++
+		}
+	}
+
+	return report, nil
+}
+
+func importFile(ctx context.Context, deps *di.Dependencies, path string, visibility domain.Visibility) importResult {
+	result := importResult{Path: path}
+
+	if err := ctx.Err(); err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+
+	contentType, err := detectFileContentType(path)
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+	if !utils.IsSupportedContentType(contentType) {
+		result.Status = "skipped"
+		result.Error = "unsupported content type " + contentType
+		return result
+	}
+
+	image, err := deps.ImageUsecase.ImportFile(metrics.WithTransport(ctx, metrics.TransportCLI), path, contentType, visibility)
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+
+	result.ID = image.ID
+	result.Status = string(image.Status)
+	return result
+}
+
+func collectFiles(root string, recursive bool) ([]string, error) {
+	var paths []string
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Type().IsRegular() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return paths, nil
+}
+
+func detectFileContentType(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	header := make([]byte, 512)
+	n, err := io.ReadFull(file, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	return utils.DetectContentType(header[:n]), nil
+}
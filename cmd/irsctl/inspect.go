@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"image-resizing-service/pkg/di"
+)
+
+func runInspect(ctx context.Context, deps *di.Dependencies, args []string) (any, error) {
+	flags := newFlagSet("inspect", "inspect <id>")
+
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) != 1 {
+		return nil, errors.New("usage: irsctl inspect <id>")
+	}
+	if uuid.Validate(positional[0]) != nil {
+		return nil, errors.New("invalid id")
+	}
+
+	image, err := deps.ImageUsecase.FindByID(ctx, positional[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return newImageView(image), nil
+}
@@ -0,0 +1,110 @@
+// Command irsctl is the operator CLI of the image resizing service. Every subcommand runs through
+// the same di.Dependencies and use cases as the server and prints a single JSON document to stdout.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image-resizing-service/pkg/config"
+	"image-resizing-service/pkg/di"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+const usage = `Usage: irsctl <command> [flags]
+
+Commands:
+  import <dir>                   ingest every supported image in a folder into a tenant
+  reprocess                      reprocess one image (-id) or every image matching a filter
+  inspect <id>                   show an image with its renditions
+  verify                         check that every rendition is stored (-repair to regenerate)
+  gc                             remove thumbnails of disabled presets and old reprocess jobs
+  stats                          show image, thumbnail and job counts
+  presets list|add|disable       manage thumbnail presets
+  tenants list|add|key|revoke    manage tenants and their API keys
+  tenants quota <tenant>         override the storage quota of a tenant
+  usage [recalculate]            show storage used per tenant, or rebuild it from storage
+  tokens issue                   sign an HS256 bearer token with APP_SECRET_KEY
+
+Run "irsctl <command> -h" for the flags of a command.
+`
+
+type command func(ctx context.Context, deps *di.Dependencies, args []string) (any, error)
+
+var commands = map[string]command{
+	"import":    runImport,
+	"reprocess": runReprocess,
+	"inspect":   runInspect,
+	"verify":    runVerify,
+	"gc":        runGC,
+	"stats":     runStats,
+	"presets":   runPresets,
+	"tenants":   runTenants,
+	"tokens":    runTokens,
+	"usage":     runUsage,
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "irsctl: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "irsctl: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Keep stdout for the JSON result.
+	for i, output := range cfg.Log.Output {
+		if output == "stdout" {
+			cfg.Log.Output[i] = "stderr"
+		}
+	}
+
+	dependencies := di.InitDependencies(cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	result, err := run(ctx, dependencies, os.Args[2:])
+	if err := dependencies.ShutdownTracing(context.Background()); err != nil {
+		fmt.Fprintf(os.Stderr, "irsctl: failed to flush traces: %v\n", err)
+	}
+	if err != nil {
+		printJSON(map[string]string{"error": err.Error()})
+		os.Exit(1)
+	}
+
+	printJSON(result)
+}
+
+func printJSON(value any) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		fmt.Fprintf(os.Stderr, "irsctl: failed to encode output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// splitList parses a comma-separated flag value, ignoring empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
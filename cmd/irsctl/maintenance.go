@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/di"
+	"time"
+)
+
+func runVerify(ctx context.Context, deps *di.Dependencies, args []string) (any, error) {
+	flags := newFlagSet("verify", "verify [-repair]")
+	repair := flags.Bool("repair", false, "process broken images again to regenerate missing renditions")
+
+	if _, err := parseFlags(flags, args); err != nil {
+		return nil, err
+	}
+
+	return deps.MaintenanceUsecase.Verify(ctx, *repair)
+}
+
+func runGC(ctx context.Context, deps *di.Dependencies, args []string) (any, error) {
+	flags := newFlagSet("gc", "gc [-dry-run] [-job-retention 720h] [-orphans=false] [-grace 24h]")
+	dryRun := flags.Bool("dry-run", false, "report what would be removed without removing it")
+	jobRetention := flags.Duration("job-retention", 30*24*time.Hour, "how long finished reprocess jobs are kept")
+	orphans := flags.Bool("orphans", true, "delete stored objects no image or thumbnail refers to")
+	grace := flags.Duration("grace", deps.Config.OrphanGC.GracePeriod, "minimum age of an orphaned object before it is deleted")
+
+	if _, err := parseFlags(flags, args); err != nil {
+		return nil, err
+	}
+
+	return deps.MaintenanceUsecase.CollectGarbage(ctx, ports.GCOptions{
+		DryRun:       *dryRun,
+		JobRetention: *jobRetention,
+		Orphans:      *orphans,
+		GracePeriod:  *grace,
+	})
+}
+
+func runStats(ctx context.Context, deps *di.Dependencies, args []string) (any, error) {
+	flags := newFlagSet("stats", "stats")
+
+	if _, err := parseFlags(flags, args); err != nil {
+		return nil, err
+	}
+
+	return deps.MaintenanceUsecase.Stats(ctx)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/pkg/di"
+)
+
+const presetsUsage = "usage: irsctl presets list | add -label <label> -width <px> -height <px> [flags] | disable <label>"
+
+func runPresets(ctx context.Context, deps *di.Dependencies, args []string) (any, error) {
+	if len(args) == 0 {
+		return nil, errors.New(presetsUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		return listPresets(ctx, deps)
+	case "add":
+		return addPreset(ctx, deps, args[1:])
+	case "disable":
+		if len(args) != 2 {
+			return nil, errors.New("usage: irsctl presets disable <label>")
+		}
+		if err := deps.PresetUsecase.Disable(ctx, args[1]); err != nil {
+			return nil, err
+		}
+		return listPresets(ctx, deps)
+	default:
+		return nil, errors.New(presetsUsage)
+	}
+}
+
+func listPresets(ctx context.Context, deps *di.Dependencies) (any, error) {
+	presets, err := deps.PresetUsecase.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]presetView, 0, len(presets))
+	for _, preset := range presets {
+		views = append(views, newPresetView(preset))
+	}
+
+	return views, nil
+}
+
+func addPreset(ctx context.Context, deps *di.Dependencies, args []string) (any, error) {
+	defaults := domain.DefaultEncoding
+
+	flags := newFlagSet("presets add", "presets add -label <label> -width <px> -height <px> [flags]")
+	label := flags.String("label", "", "preset label, used in thumbnail keys")
+	width := flags.Int("width", 0, "thumbnail width in pixels")
+	height := flags.Int("height", 0, "thumbnail height in pixels")
+	thumbnailType := flags.String("type", "", "thumbnail type reported to clients (default: the label)")
+	mode := flags.String("mode", string(defaults.Mode), "encoding mode: auto, lossy, lossless or near_lossless")
+	quality := flags.Float64("quality", float64(defaults.Quality), "lossy quality, 0-100")
+	nearLossless := flags.Int("near-lossless", defaults.NearLosslessLevel, "near-lossless level, 0-100")
+	exactAlpha := flags.Bool("exact-alpha", defaults.ExactAlpha, "preserve RGB under transparent pixels")
+	tryLossless := flags.Bool("try-lossless", defaults.TryLossless, "in auto mode, also encode photos losslessly and keep the smaller output")
+	keepWideGamut := flags.Bool("keep-wide-gamut", defaults.KeepWideGamut, "embed the source ICC profile instead of converting to sRGB")
+
+	if _, err := parseFlags(flags, args); err != nil {
+		return nil, err
+	}
+
+	if *thumbnailType == "" {
+		*thumbnailType = *label
+	}
+
+	preset := &domain.ThumbnailPreset{
+		Label:  *label,
+		Width:  *width,
+		Height: *height,
+		Type:   domain.ThumbnailType(*thumbnailType),
+		Encoding: domain.EncodingSettings{
+			Mode:              domain.EncodingMode(*mode),
+			Quality:           float32(*quality),
+			NearLosslessLevel: *nearLossless,
+			ExactAlpha:        *exactAlpha,
+			TryLossless:       *tryLossless,
+			KeepWideGamut:     *keepWideGamut,
+		},
+	}
+
+	if err := deps.PresetUsecase.Add(ctx, preset); err != nil {
+		return nil, err
+	}
+
+	return newPresetView(*preset), nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/di"
+	"time"
+)
+
+func runReprocess(ctx context.Context, deps *di.Dependencies, args []string) (any, error) {
+	flags := newFlagSet("reprocess", "reprocess [-id <id> | -status <status> -from <time> -to <time>] [-presets a,b] [-tenant <name>]")
+	id := flags.String("id", "", "reprocess a single image synchronously")
+	presets := flags.String("presets", "", "comma-separated presets to regenerate (default: every rendition)")
+	imageStatus := flags.String("status", "", "bulk: only images with this status: pending, processing, ready or error")
+	from := flags.String("from", "", "bulk: only images created at or after this RFC 3339 time")
+	to := flags.String("to", "", "bulk: only images created before this RFC 3339 time")
+	poll := flags.Duration("poll", 2*time.Second, "bulk: how often job progress is checked")
+	tenant := flags.String("tenant", "", "only images of this tenant (default: every tenant)")
+
+	if _, err := parseFlags(flags, args); err != nil {
+		return nil, err
+	}
+
+	ctx, err := withTenant(ctx, deps, *tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	if *id != "" {
+		if uuid.Validate(*id) != nil {
+			return nil, errors.New("invalid id")
+		}
+
+		err := deps.ImageUsecase.ProcessImage(ctx, *id, ports.ProcessOptions{Presets: splitList(*presets), Force: true})
+		if err != nil {
+			return nil, err
+		}
+
+		image, err := deps.ImageUsecase.FindByID(ctx, *id)
+		if err != nil {
+			return nil, err
+		}
+		return newImageView(image), nil
+	}
+
+	filter := domain.ReprocessFilter{Presets: splitList(*presets)}
+
+	switch status := domain.ImageStatus(*imageStatus); status {
+	case "", domain.StatusPending, domain.StatusProcessing, domain.StatusReady, domain.StatusError:
+		filter.Status = status
+	default:
+		return nil, fmt.Errorf("invalid -status %q, expected pending, processing, ready or error", *imageStatus)
+	}
+
+	if filter.CreatedFrom, err = parseTime(*from); err != nil {
+		return nil, fmt.Errorf("invalid -from: %w", err)
+	}
+	if filter.CreatedTo, err = parseTime(*to); err != nil {
+		return nil, fmt.Errorf("invalid -to: %w", err)
+	}
+
+	job, err := deps.ReprocessUsecase.StartBulk(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// The job runs in this process, so wait for it before exiting.
+	ticker := time.NewTicker(*poll)
+	defer ticker.Stop()
+
+	for job.Status != domain.JobCompleted && job.Status != domain.JobFailed {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		if job, err = deps.ReprocessUsecase.FindJob(ctx, job.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return deps.RestImageAssembler.BuildReprocessJob(job), nil
+}
+
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsed, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/pkg/di"
+)
+
+const tenantsUsage = "usage: irsctl tenants list | add <name> | key <tenant> | revoke <prefix> | quota <tenant> [flags]"
+
+func runTenants(ctx context.Context, deps *di.Dependencies, args []string) (any, error) {
+	if len(args) == 0 {
+		return nil, errors.New(tenantsUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		return listTenants(ctx, deps)
+	case "add":
+		if len(args) != 2 {
+			return nil, errors.New("usage: irsctl tenants add <name>")
+		}
+		tenant, err := deps.TenantUsecase.Create(ctx, args[1])
+		if err != nil {
+			return nil, err
+		}
+		return newTenantView(*tenant), nil
+	case "key":
+		if len(args) != 2 {
+			return nil, errors.New("usage: irsctl tenants key <tenant>")
+		}
+		// The key is only ever shown here; it cannot be recovered later.
+		return deps.TenantUsecase.IssueAPIKey(ctx, args[1])
+	case "revoke":
+		if len(args) != 2 {
+			return nil, errors.New("usage: irsctl tenants revoke <prefix>")
+		}
+		if err := deps.TenantUsecase.RevokeAPIKey(ctx, args[1]); err != nil {
+			return nil, err
+		}
+		return listTenants(ctx, deps)
+	case "quota":
+		return setTenantQuota(ctx, deps, args[1:])
+	default:
+		return nil, errors.New(tenantsUsage)
+	}
+}
+
+func setTenantQuota(ctx context.Context, deps *di.Dependencies, args []string) (any, error) {
+	flags := newFlagSet("tenants quota", "tenants quota <tenant> [-soft-bytes n] [-hard-bytes n] [-soft-images n] [-hard-images n]")
+	softBytes := flags.Int64("soft-bytes", 0, "bytes stored before uploads are warned about, 0 for no limit")
+	hardBytes := flags.Int64("hard-bytes", 0, "bytes stored before uploads are rejected, 0 for no limit")
+	softImages := flags.Int64("soft-images", 0, "images stored before uploads are warned about, 0 for no limit")
+	hardImages := flags.Int64("hard-images", 0, "images stored before uploads are rejected, 0 for no limit")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: irsctl tenants quota <tenant> [-soft-bytes n] [-hard-bytes n] [-soft-images n] [-hard-images n]")
+		fmt.Fprintln(flags.Output(), "Only the given limits change; -1 returns a limit to the configured quota.")
+		flags.PrintDefaults()
+	}
+
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) != 1 {
+		return nil, errors.New("usage: irsctl tenants quota <tenant> [-soft-bytes n] [-hard-bytes n] [-soft-images n] [-hard-images n]")
+	}
+
+	tenant, err := deps.TenantUsecase.Resolve(ctx, positional[0])
+	if err != nil {
+		return nil, err
+	}
+
+	quota := tenant.Quota
+	limits := map[string]struct {
+		value  *int64
+		target **int64
+	}{
+		"soft-bytes":  {softBytes, &quota.SoftBytes},
+		"hard-bytes":  {hardBytes, &quota.HardBytes},
+		"soft-images": {softImages, &quota.SoftImages},
+		"hard-images": {hardImages, &quota.HardImages},
+	}
+	flags.Visit(func(f *flag.Flag) {
+		limit := limits[f.Name]
+		if *limit.value == -1 {
+			*limit.target = nil
+			return
+		}
+		value := *limit.value
+		*limit.target = &value
+	})
+
+	tenant, err = deps.TenantUsecase.SetQuota(ctx, tenant.Name, quota)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTenantView(*tenant), nil
+}
+
+func listTenants(ctx context.Context, deps *di.Dependencies) (any, error) {
+	tenants, err := deps.TenantUsecase.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]tenantView, 0, len(tenants))
+	for _, tenant := range tenants {
+		views = append(views, newTenantView(tenant))
+	}
+
+	return views, nil
+}
+
+// withTenant scopes ctx to the tenant called name. An empty name leaves ctx unscoped, so the
+// command sees the data of every tenant.
+func withTenant(ctx context.Context, deps *di.Dependencies, name string) (context.Context, error) {
+	if name == "" {
+		return ctx, nil
+	}
+
+	tenant, err := deps.TenantUsecase.Resolve(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.WithTenant(ctx, tenant.ID), nil
+}
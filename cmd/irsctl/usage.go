@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/di"
+)
+
+func runUsage(ctx context.Context, deps *di.Dependencies, args []string) (any, error) {
+	flags := newFlagSet("usage", "usage [-tenant name] | usage recalculate")
+	tenantName := flags.String("tenant", "", "report only this tenant")
+
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(positional) == 1 && positional[0] == "recalculate":
+		if *tenantName != "" {
+			return nil, errors.New("usage recalculate rebuilds every tenant and takes no -tenant")
+		}
+		return deps.UsageUsecase.Recalculate(ctx)
+	case len(positional) != 0:
+		return nil, errors.New("usage: irsctl usage [-tenant name] | usage recalculate")
+	case *tenantName != "":
+		tenant, err := deps.TenantUsecase.Resolve(ctx, *tenantName)
+		if err != nil {
+			return nil, err
+		}
+		return deps.UsageUsecase.Usage(ctx, tenant.ID)
+	}
+
+	tenants, err := deps.TenantUsecase.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]*ports.Usage, 0, len(tenants))
+	for _, tenant := range tenants {
+		usage, err := deps.UsageUsecase.Usage(ctx, tenant.ID)
+		if err != nil {
+			return nil, err
+		}
+		usages = append(usages, usage)
+	}
+
+	return usages, nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"image-resizing-service/internal/domain"
+	"time"
+)
+
+type imageView struct {
+	ID            string          `json:"id"`
+	TenantID      string          `json:"tenant_id"`
+	Status        string          `json:"status"`
+	Visibility    string          `json:"visibility"`
+	ErrorMessage  *string         `json:"error_message,omitempty"`
+	OriginalKey   string          `json:"original_key"`
+	CompressedKey string          `json:"compressed_key,omitempty"`
+	ProcessingMs  int64           `json:"processing_ms"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	Thumbnails    []thumbnailView `json:"thumbnails"`
+}
+
+type thumbnailView struct {
+	Size         string  `json:"size"`
+	Type         string  `json:"type"`
+	Key          string  `json:"key"`
+	Status       string  `json:"status"`
+	ErrorMessage *string `json:"error_message,omitempty"`
+	ProcessingMs int64   `json:"processing_ms"`
+}
+
+type presetView struct {
+	Label    string       `json:"label"`
+	Width    int          `json:"width"`
+	Height   int          `json:"height"`
+	Type     string       `json:"type"`
+	Enabled  bool         `json:"enabled"`
+	Encoding encodingView `json:"encoding"`
+}
+
+type tenantView struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	CreatedAt time.Time    `json:"created_at"`
+	APIKeys   []apiKeyView `json:"api_keys"`
+	// Quota holds the limits the tenant overrides; the others are configured.
+	Quota quotaView `json:"quota"`
+}
+
+type quotaView struct {
+	SoftBytes  *int64 `json:"soft_bytes,omitempty"`
+	HardBytes  *int64 `json:"hard_bytes,omitempty"`
+	SoftImages *int64 `json:"soft_images,omitempty"`
+	HardImages *int64 `json:"hard_images,omitempty"`
+}
+
+type apiKeyView struct {
+	Prefix    string    `json:"prefix"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type encodingView struct {
+	Mode              string  `json:"mode"`
+	Quality           float32 `json:"quality"`
+	NearLosslessLevel int     `json:"near_lossless_level"`
+	ExactAlpha        bool    `json:"exact_alpha"`
+	TryLossless       bool    `json:"try_lossless"`
+	KeepWideGamut     bool    `json:"keep_wide_gamut"`
+}
+
+func newImageView(image *domain.Image) imageView {
+	view := imageView{
+		ID:            image.ID,
+		TenantID:      image.TenantID,
+		Status:        string(image.Status),
+		Visibility:    string(image.Visibility),
+		ErrorMessage:  image.ErrorMessage,
+		OriginalKey:   image.OriginalKey,
+		CompressedKey: image.CompressedKey,
+		ProcessingMs:  image.ProcessingMs,
+		CreatedAt:     image.CreatedAt,
+		UpdatedAt:     image.UpdatedAt,
+		Thumbnails:    make([]thumbnailView, 0, len(image.Thumbnails)),
+	}
+
+	for _, thumb := range image.Thumbnails {
+		view.Thumbnails = append(view.Thumbnails, thumbnailView{
+			Size:         thumb.Size,
+			Type:         thumb.Type,
+			Key:          thumb.Key,
+			Status:       string(thumb.Status),
+			ErrorMessage: thumb.ErrorMessage,
+			ProcessingMs: thumb.ProcessingMs,
+		})
+	}
+
+	return view
+}
+
+func newPresetView(preset domain.ThumbnailPreset) presetView {
+	return presetView{
+		Label:   preset.Label,
+		Width:   preset.Width,
+		Height:  preset.Height,
+		Type:    string(preset.Type),
+		Enabled: preset.Enabled,
+		Encoding: encodingView{
+			Mode:              string(preset.Encoding.Mode),
+			Quality:           preset.Encoding.Quality,
+			NearLosslessLevel: preset.Encoding.NearLosslessLevel,
+			ExactAlpha:        preset.Encoding.ExactAlpha,
+			TryLossless:       preset.Encoding.TryLossless,
+			KeepWideGamut:     preset.Encoding.KeepWideGamut,
+		},
+	}
+}
+
+func newTenantView(tenant domain.Tenant) tenantView {
+	view := tenantView{
+		ID:        tenant.ID,
+		Name:      tenant.Name,
+		CreatedAt: tenant.CreatedAt,
+		APIKeys:   make([]apiKeyView, 0, len(tenant.APIKeys)),
+		Quota: quotaView{
+			SoftBytes:  tenant.Quota.SoftBytes,
+			HardBytes:  tenant.Quota.HardBytes,
+			SoftImages: tenant.Quota.SoftImages,
+			HardImages: tenant.Quota.HardImages,
+		},
+	}
+
+	for _, key := range tenant.APIKeys {
+		view.APIKeys = append(view.APIKeys, apiKeyView{Prefix: key.Prefix, CreatedAt: key.CreatedAt})
+	}
+
+	return view
+}
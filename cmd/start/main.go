@@ -2,85 +2,344 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"image-resizing-service/internal/delivery/grpc/handlers"
 	images "image-resizing-service/internal/delivery/grpc/pb"
 	"image-resizing-service/internal/delivery/rest"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/config"
 	"image-resizing-service/pkg/di"
+	"image-resizing-service/pkg/logging"
 	"image-resizing-service/pkg/utils"
-	"log"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
 var dependencies *di.Dependencies
 
 func init() {
-	dependencies = di.InitDependencies()
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	dependencies = di.InitDependencies(cfg)
 }
 
 func main() {
 	utils.InitMigrations(dependencies.DB)
 
-	go func() {
-		fmt.Println("Server started on port 8000")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-		r := gin.Default()
-		rest.InitRoutes(r, dependencies.ImageUsecase, dependencies.RestImageAssembler)
+	cfg := dependencies.Config
+	logger := dependencies.Logger
 
-		r.Run(":8000")
+	r := gin.New()
+	rest.InitRoutes(r, cfg, logger, dependencies.ImageUsecase, dependencies.ReprocessUsecase, dependencies.HealthUsecase, dependencies.AuthUsecase, dependencies.RateLimitUsecase, dependencies.UsageUsecase, dependencies.FileUsecase, dependencies.RestImageAssembler)
+
+	httpServer := &http.Server{Addr: fmt.Sprintf(":%d", cfg.HTTP.Port), Handler: r}
+
+	go func() {
+		logger.Info("http server started", zap.Int("port", cfg.HTTP.Port))
+
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal("failed to serve http", zap.Error(err))
+		}
 	}()
 
+	maxUploadBytes := cfg.GRPC.MaxUploadBytes
+
+	interceptors := []grpc.UnaryServerInterceptor{requestLoggingInterceptor(logger), authInterceptor(dependencies.AuthUsecase, cfg.GRPC.Token)}
+	if dependencies.RateLimitUsecase != nil {
+		interceptors = append(interceptors, rateLimitInterceptor(dependencies.RateLimitUsecase))
+	}
+
+	serverOptions := []grpc.ServerOption{
+		// Health probes arrive every few seconds and would drown out the traces worth looking at.
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithFilter(filters.Not(filters.HealthCheck())))),
+		grpc.ChainUnaryInterceptor(interceptors...),
+	}
+	if maxUploadBytes > 0 {
+		// Leave headroom for the protobuf envelope around the image bytes.
+		serverOptions = append(serverOptions, grpc.MaxRecvMsgSize(maxUploadBytes+64<<10))
+	}
+
+	grpcServer := grpc.NewServer(serverOptions...)
+
+	imagesHandler := handlers.NewImageGRPCHandler(dependencies.ImageUsecase, dependencies.ReprocessUsecase, dependencies.AuthUsecase, dependencies.UsageUsecase, dependencies.GRPCImageAssembler, maxUploadBytes)
+	images.RegisterImageServiceServer(grpcServer, imagesHandler)
+
+	healthHandler := handlers.NewHealthGRPCHandler(dependencies.HealthUsecase, cfg.Health.WatchInterval)
+	grpc_health_v1.RegisterHealthServer(grpcServer, healthHandler)
+
 	go func() {
-		fmt.Println("GRPC server started on port 50051")
+		logger.Info("grpc server started", zap.Int("port", cfg.GRPC.Port))
 
-		listener, err := net.Listen("tcp", "0.0.0.0:50051")
+		listener, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", cfg.GRPC.Port))
 		if err != nil {
-			log.Fatal("failed to listen", zap.Error(err))
+			logger.Fatal("failed to listen for grpc", zap.Error(err))
 		}
 
-		grpcToken := os.Getenv("GRPC_TOKEN")
+		if err := grpcServer.Serve(listener); err != nil {
+			logger.Fatal("failed to serve grpc", zap.Error(err))
+		}
+	}()
+
+	if interval := cfg.Reaper.Interval; interval > 0 {
+		go utils.RunEvery(ctx, "stuck-job-reaper", interval, func(ctx context.Context) {
+			report, err := dependencies.ReaperUsecase.Reap(ctx)
+			if err != nil {
+				logger.Error("failed to reap stuck images", zap.Error(err))
+			}
+			if report != nil && report.Requeued+report.TimedOut+report.ResumedJobs > 0 {
+				logger.Info("stuck images reaped", zap.Int("requeued", report.Requeued), zap.Int("timed_out", report.TimedOut), zap.Int("resumed_jobs", report.ResumedJobs))
+			}
+		})
+	}
 
-		var grpcServer *grpc.Server
-		if grpcToken != "" {
-			grpcServer = grpc.NewServer(
-				grpc.UnaryInterceptor(tokenAuthInterceptor(grpcToken)),
-			)
-		} else {
-			grpcServer = grpc.NewServer()
+	if interval := cfg.OrphanGC.Interval; interval > 0 {
+		dryRun := cfg.OrphanGC.DryRun
+		gracePeriod := cfg.OrphanGC.GracePeriod
+
+		go utils.RunEvery(ctx, "orphan-gc", interval, func(ctx context.Context) {
+			if _, err := dependencies.MaintenanceUsecase.CollectOrphans(ctx, dryRun, gracePeriod); err != nil {
+				logger.Error("failed to collect orphaned objects", zap.Error(err))
+			}
+		})
+	}
+
+	<-ctx.Done()
+	stop()
+
+	shutdown(httpServer, grpcServer, healthHandler, cfg.Shutdown.Timeout)
+}
+
+// shutdown stops accepting work, lets in-flight requests finish and drains background processing,
+// all within timeout. Processing still running at the deadline is cancelled and its images are
+// returned to pending, where the reaper picks them up after the restart.
+func shutdown(httpServer *http.Server, grpcServer *grpc.Server, healthHandler *handlers.HealthGRPCHandler, timeout time.Duration) {
+	logger := dependencies.Logger
+	logger.Info("shutting down", zap.Duration("timeout", timeout))
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Report NOT_SERVING and end health watches, which would otherwise hold GracefulStop open.
+	healthHandler.Shutdown()
+
+	// Servers close their listeners first and finish in-flight requests, so uploads that were
+	// already accepted still get their processing dispatched before it is drained.
+	var wg sync.WaitGroup
+	wg.Add(3)
+
+	go func() {
+		defer wg.Done()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			logger.Warn("http server did not shut down cleanly", zap.Error(err))
 		}
+	}()
 
-		imagesHandler := handlers.NewImageGRPCHandler(dependencies.ImageUsecase, dependencies.GRPCImageAssembler)
-		images.RegisterImageServiceServer(grpcServer, imagesHandler)
+	go func() {
+		defer wg.Done()
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
 
-		if err := grpcServer.Serve(listener); err != nil {
-			log.Fatal("failed to serve", zap.Error(err))
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			logger.Warn("grpc server did not stop before the deadline, closing connections")
+			grpcServer.Stop()
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		if err := dependencies.ReprocessUsecase.Drain(ctx); err != nil {
+			logger.Warn("reprocess jobs did not stop", zap.Error(err))
 		}
 	}()
 
-	select {}
+	wg.Wait()
+
+	if err := dependencies.ImageUsecase.Drain(ctx); err != nil {
+		logger.Warn("image processing did not drain", zap.Error(err))
+	}
+
+	// Spans of the drained work are exported last, with whatever time is left.
+	if err := dependencies.ShutdownTracing(ctx); err != nil {
+		logger.Warn("failed to flush traces", zap.Error(err))
+	}
+
+	logger.Info("shutdown complete")
+	_ = logger.Sync()
+}
+
+// requestLoggingInterceptor attaches a request_id to the call, taken from the x-request-id metadata
+// when the client sent a usable one, returns it in the response header and logs the call once it is
+// handled. Health checks are logged at debug level.
+func requestLoggingInterceptor(logger *logging.Logger) grpc.UnaryServerInterceptor {
+	metadataKey := strings.ToLower(logging.RequestIDHeader)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		startedAt := time.Now()
+
+		incoming := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(metadataKey); len(values) > 0 {
+				incoming = values[0]
+			}
+		}
+		requestID := logging.RequestIDFrom(incoming)
+
+		ctx = logging.WithRequestID(ctx, requestID)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(metadataKey, requestID))
+
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		level := zap.InfoLevel
+		switch {
+		case code == codes.Internal || code == codes.Unknown || code == codes.Unavailable || code == codes.DataLoss:
+			level = zap.ErrorLevel
+		case strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/"):
+			level = zap.DebugLevel
+		}
+
+		fields := []zap.Field{
+			zap.String("method", info.FullMethod),
+			zap.String("code", code.String()),
+			zap.Duration("duration", time.Since(startedAt)),
+		}
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+		}
+
+		logger.Ctx(ctx).Log(level, "grpc request", fields...)
+
+		return resp, err
+	}
+}
+
+// methodScopes is the scope each ImageService method requires. Methods missing here require ScopeAdmin.
+var methodScopes = map[string]domain.Scope{
+	images.ImageService_UploadImage_FullMethodName:       domain.ScopeImagesUpload,
+	images.ImageService_GetImage_FullMethodName:          domain.ScopeImagesRead,
+	images.ImageService_DeleteImage_FullMethodName:       domain.ScopeImagesDelete,
+	images.ImageService_CreateAccessToken_FullMethodName: domain.ScopeImagesRead,
+	images.ImageService_ReprocessImage_FullMethodName:    domain.ScopeImagesUpload,
+	images.ImageService_BulkReprocess_FullMethodName:     domain.ScopeAdmin,
+	images.ImageService_GetReprocessJob_FullMethodName:   domain.ScopeAdmin,
+	images.ImageService_GetUsage_FullMethodName:          domain.ScopeImagesRead,
+}
+
+// authInterceptor authenticates the call from its authorization metadata, either "Bearer <token>" or
+// an API key, scopes it to the tenant of the caller and checks the scope of the method. token is the
+// legacy authorization of the default tenant.
+func authInterceptor(authUseCase ports.AuthUseCase, token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		// Orchestrator probes carry no credentials.
+		if strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") {
+			return handler(ctx, req)
+		}
+
+		credentials := ports.Credentials{LegacyToken: token}
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("authorization"); len(values) > 0 {
+				if bearer, ok := strings.CutPrefix(values[0], "Bearer "); ok {
+					credentials.BearerToken = strings.TrimSpace(bearer)
+				} else {
+					credentials.APIKey = values[0]
+				}
+			}
+		}
+
+		principal, err := authUseCase.Authenticate(ctx, credentials)
+		if errors.Is(err, domain.ErrUnauthenticated) {
+			return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+		}
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		scope, known := methodScopes[info.FullMethod]
+		if !known {
+			scope = domain.ScopeAdmin
+		}
+		if !principal.Allows(scope) {
+			return nil, status.Errorf(codes.PermissionDenied, "%s: %s", domain.ErrForbidden, scope)
+		}
+
+		ctx = domain.WithTenant(ctx, principal.TenantID)
+		ctx = domain.WithPrincipal(ctx, principal)
+		ctx = logging.With(ctx, zap.String("tenant_id", principal.TenantID), zap.String("subject", principal.Subject))
+		return handler(ctx, req)
+	}
+}
+
+// methodRateLimits is the rate limit class of each ImageService method. Other methods are not limited.
+var methodRateLimits = map[string]domain.RateLimitClass{
+	images.ImageService_UploadImage_FullMethodName:       domain.RateLimitUpload,
+	images.ImageService_ReprocessImage_FullMethodName:    domain.RateLimitUpload,
+	images.ImageService_DeleteImage_FullMethodName:       domain.RateLimitUpload,
+	images.ImageService_GetImage_FullMethodName:          domain.RateLimitRead,
+	images.ImageService_CreateAccessToken_FullMethodName: domain.RateLimitRead,
+	images.ImageService_GetUsage_FullMethodName:          domain.RateLimitRead,
 }
 
-func tokenAuthInterceptor(token string) grpc.UnaryServerInterceptor {
-	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			return nil, status.Error(codes.Unauthenticated, "missing metadata")
+// rateLimitInterceptor counts the call against the rate limits of its method for the principal set by
+// authInterceptor and the peer address. The RateLimit-* values are sent as header metadata, and
+// rejected calls fail with ResourceExhausted.
+func rateLimitInterceptor(rateLimitUseCase ports.RateLimitUseCase) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		class, limited := methodRateLimits[info.FullMethod]
+		principal := domain.PrincipalFromContext(ctx)
+		if !limited || principal == nil {
+			return handler(ctx, req)
 		}
 
-		authHeader, exists := md["authorization"]
-		if !exists || len(authHeader) == 0 {
-			return nil, status.Error(codes.Unauthenticated, "missing token")
+		var clientIP string
+		if p, ok := peer.FromContext(ctx); ok {
+			if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+				clientIP = host
+			}
 		}
 
-		if authHeader[0] != token {
-			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		rateLimit, err := rateLimitUseCase.Allow(ctx, class, principal, clientIP)
+		if err != nil {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+		if rateLimit == nil {
+			return handler(ctx, req)
+		}
+
+		headers := rateLimit.Headers()
+		_ = grpc.SetHeader(ctx, metadata.New(headers))
+
+		if !rateLimit.Allowed {
+			return nil, status.Errorf(codes.ResourceExhausted, "%s rate limit per %s exceeded, retry after %ss", class, rateLimit.Exhausted, headers["Retry-After"])
 		}
 
 		return handler(ctx, req)
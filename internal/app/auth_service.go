@@ -0,0 +1,143 @@
+package app
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/logging"
+	"image-resizing-service/pkg/utils"
+	"time"
+)
+
+type AuthService struct {
+	tokens  *utils.TokenVerifier
+	tenants ports.TenantUseCase
+	logger  *logging.Logger
+	// accessSecret signs image access tokens, which are disabled while it is empty.
+	accessSecret []byte
+	accessTTL    time.Duration
+	accessMaxTTL time.Duration
+}
+
+func NewAuthService(
+	tokens *utils.TokenVerifier,
+	tenants ports.TenantUseCase,
+	logger *logging.Logger,
+	accessSecret []byte,
+	accessTTL time.Duration,
+	accessMaxTTL time.Duration,
+) ports.AuthUseCase {
+	return &AuthService{
+		tokens:       tokens,
+		tenants:      tenants,
+		logger:       logger,
+		accessSecret: accessSecret,
+		accessTTL:    accessTTL,
+		accessMaxTTL: accessMaxTTL,
+	}
+}
+
+// Authenticate accepts, in this order, a bearer token, the legacy token of the transport, a tenant
+// API key or an image access token. Requests without credentials are only accepted while neither bearer tokens nor the legacy
+// token are configured, as before authentication existed; they act for the default tenant with every scope.
+func (s *AuthService) Authenticate(ctx context.Context, credentials ports.Credentials) (*domain.Principal, error) {
+	switch {
+	case credentials.BearerToken != "":
+		return s.authenticateToken(ctx, credentials.BearerToken)
+	case credentials.APIKey != "" && credentials.LegacyToken != "" &&
+		subtle.ConstantTimeCompare([]byte(credentials.APIKey), []byte(credentials.LegacyToken)) == 1:
+		// The legacy token predates scopes and keeps the access it always had.
+		return &domain.Principal{Subject: "legacy-token", TenantID: domain.DefaultTenantID, Scopes: domain.Scopes}, nil
+	case credentials.APIKey != "":
+		tenant, err := s.tenants.Authenticate(ctx, credentials.APIKey)
+		if errors.Is(err, domain.ErrInvalidAPIKey) {
+			return nil, fmt.Errorf("%w: %w", domain.ErrUnauthenticated, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &domain.Principal{Subject: credentials.APIKey[:apiKeyDisplayLength], TenantID: tenant.ID, Scopes: domain.TenantScopes}, nil
+	case credentials.AccessToken != "":
+		return s.authenticateAccessToken(ctx, credentials.AccessToken)
+	case credentials.LegacyToken == "" && !s.tokens.Enabled():
+		return &domain.Principal{Subject: domain.AnonymousSubject, TenantID: domain.DefaultTenantID, Scopes: domain.Scopes}, nil
+	default:
+		return nil, fmt.Errorf("%w: credentials are required", domain.ErrUnauthenticated)
+	}
+}
+
+func (s *AuthService) authenticateToken(ctx context.Context, token string) (*domain.Principal, error) {
+	claims, err := s.tokens.Verify(ctx, token)
+	if err != nil {
+		s.logger.Ctx(ctx).Debug("bearer token rejected", zap.Error(err))
+		return nil, fmt.Errorf("%w: %w", domain.ErrUnauthenticated, err)
+	}
+
+	tenantID := claims.TenantID
+	if tenantID == "" {
+		tenantID = domain.DefaultTenantID
+	}
+	if uuid.Validate(tenantID) != nil {
+		return nil, fmt.Errorf("%w: tenant_id must be a tenant UUID", domain.ErrUnauthenticated)
+	}
+
+	principal := &domain.Principal{Subject: claims.Subject, TenantID: tenantID}
+	for _, scope := range claims.Scopes() {
+		// Scopes meant for other services sharing the issuer are ignored.
+		if domain.IsValidScope(domain.Scope(scope)) {
+			principal.Scopes = append(principal.Scopes, domain.Scope(scope))
+		}
+	}
+
+	return principal, nil
+}
+
+func (s *AuthService) authenticateAccessToken(ctx context.Context, token string) (*domain.Principal, error) {
+	if len(s.accessSecret) == 0 {
+		return nil, fmt.Errorf("%w: %w", domain.ErrUnauthenticated, domain.ErrAccessTokenDisabled)
+	}
+
+	access, err := utils.VerifyImageAccess(s.accessSecret, token)
+	if err != nil {
+		s.logger.Ctx(ctx).Debug("access token rejected", zap.Error(err))
+		return nil, fmt.Errorf("%w: %w", domain.ErrUnauthenticated, err)
+	}
+
+	return &domain.Principal{
+		Subject:  "access-token:" + access.ImageID,
+		TenantID: access.TenantID,
+		Scopes:   []domain.Scope{domain.ScopeImagesRead},
+		ImageID:  access.ImageID,
+	}, nil
+}
+
+func (s *AuthService) IssueAccessToken(ctx context.Context, image *domain.Image, ttl time.Duration) (*ports.AccessToken, error) {
+	if len(s.accessSecret) == 0 {
+		return nil, domain.ErrAccessTokenDisabled
+	}
+
+	if ttl <= 0 {
+		ttl = s.accessTTL
+	}
+	ttl = min(ttl, s.accessMaxTTL)
+
+	// Tokens carry the expiry in whole seconds.
+	expiresAt := time.Now().Add(ttl).Truncate(time.Second)
+	token, err := utils.SignImageAccess(s.accessSecret, utils.ImageAccess{
+		TenantID:  image.TenantID,
+		ImageID:   image.ID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	s.logger.Ctx(ctx).Info("access token issued", zap.String("image_id", image.ID), zap.Time("expires_at", expiresAt))
+
+	return &ports.AccessToken{Token: token, ExpiresAt: expiresAt}, nil
+}
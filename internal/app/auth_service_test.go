@@ -0,0 +1,219 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/logging"
+	"image-resizing-service/pkg/utils"
+	"slices"
+	"testing"
+	"time"
+)
+
+const (
+	testTenantID = "2f0c8f4e-2d4b-4c1e-9a57-8a1f3c5e7d90"
+	testImageID  = "7b1d3f5a-9c2e-4e6f-8a0b-1c3d5e7f9a2b"
+	testAPIKey   = apiKeyPrefix + "0123456789abcdef"
+	testLegacy   = "legacy-token"
+)
+
+var errTenantLookup = errors.New("database is down")
+
+// fakeTenants authenticates testAPIKey and answers every other key as invalid.
+type fakeTenants struct {
+	ports.TenantUseCase
+	err error
+}
+
+func (f *fakeTenants) Authenticate(_ context.Context, apiKey string) (*domain.Tenant, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if apiKey != testAPIKey {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	return &domain.Tenant{ID: testTenantID}, nil
+}
+
+func TestAuthServiceAuthenticate(t *testing.T) {
+	tokenSecret := []byte("token-secret")
+	accessSecret := []byte("access-secret")
+
+	bearer, err := utils.GenerateToken(tokenSecret, "", "", "token-subject", testTenantID, []string{"images:read", "billing:write"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bearerWithoutTenant, err := utils.GenerateToken(tokenSecret, "", "", "token-subject", "", []string{"admin"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bearerWithBadTenant, err := utils.GenerateToken(tokenSecret, "", "", "token-subject", "tenant-name", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessToken, err := utils.SignImageAccess(accessSecret, utils.ImageAccess{TenantID: testTenantID, ImageID: testImageID, ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withTokens := utils.NewTokenVerifier(tokenSecret, nil, "", "")
+	withoutTokens := utils.NewTokenVerifier(nil, nil, "", "")
+
+	tests := []struct {
+		name        string
+		tokens      *utils.TokenVerifier
+		tenantErr   error
+		credentials ports.Credentials
+		want        *domain.Principal
+		wantErr     error
+	}{
+		{
+			name:        "bearer token",
+			tokens:      withTokens,
+			credentials: ports.Credentials{BearerToken: bearer},
+			want:        &domain.Principal{Subject: "token-subject", TenantID: testTenantID, Scopes: []domain.Scope{domain.ScopeImagesRead}},
+		},
+		{
+			name:        "bearer token without tenant acts for the default tenant",
+			tokens:      withTokens,
+			credentials: ports.Credentials{BearerToken: bearerWithoutTenant},
+			want:        &domain.Principal{Subject: "token-subject", TenantID: domain.DefaultTenantID, Scopes: []domain.Scope{domain.ScopeAdmin}},
+		},
+		{
+			name:        "bearer token with a tenant that is not a UUID",
+			tokens:      withTokens,
+			credentials: ports.Credentials{BearerToken: bearerWithBadTenant},
+			wantErr:     domain.ErrUnauthenticated,
+		},
+		{
+			name:        "bearer token wins over every other credential",
+			tokens:      withTokens,
+			credentials: ports.Credentials{BearerToken: bearer, APIKey: testLegacy, LegacyToken: testLegacy, AccessToken: accessToken},
+			want:        &domain.Principal{Subject: "token-subject", TenantID: testTenantID, Scopes: []domain.Scope{domain.ScopeImagesRead}},
+		},
+		{
+			name:        "invalid bearer token does not fall through to the api key",
+			tokens:      withTokens,
+			credentials: ports.Credentials{BearerToken: bearer + "x", APIKey: testAPIKey},
+			wantErr:     domain.ErrUnauthenticated,
+		},
+		{
+			name:        "bearer token while tokens are disabled",
+			tokens:      withoutTokens,
+			credentials: ports.Credentials{BearerToken: bearer},
+			wantErr:     domain.ErrUnauthenticated,
+		},
+		{
+			name:        "legacy token",
+			tokens:      withTokens,
+			tenantErr:   errTenantLookup,
+			credentials: ports.Credentials{APIKey: testLegacy, LegacyToken: testLegacy},
+			want:        &domain.Principal{Subject: "legacy-token", TenantID: domain.DefaultTenantID, Scopes: domain.Scopes},
+		},
+		{
+			name:        "api key that is not the legacy token",
+			tokens:      withTokens,
+			credentials: ports.Credentials{APIKey: testAPIKey, LegacyToken: testLegacy},
+			want:        &domain.Principal{Subject: testAPIKey[:apiKeyDisplayLength], TenantID: testTenantID, Scopes: domain.TenantScopes},
+		},
+		{
+			name:        "api key wins over an access token",
+			tokens:      withTokens,
+			credentials: ports.Credentials{APIKey: testAPIKey, AccessToken: accessToken},
+			want:        &domain.Principal{Subject: testAPIKey[:apiKeyDisplayLength], TenantID: testTenantID, Scopes: domain.TenantScopes},
+		},
+		{
+			name:        "invalid api key",
+			tokens:      withoutTokens,
+			credentials: ports.Credentials{APIKey: apiKeyPrefix + "fedcba9876543210"},
+			wantErr:     domain.ErrUnauthenticated,
+		},
+		{
+			name:        "invalid api key does not fall through to the access token",
+			tokens:      withoutTokens,
+			credentials: ports.Credentials{APIKey: apiKeyPrefix + "fedcba9876543210", AccessToken: accessToken},
+			wantErr:     domain.ErrUnauthenticated,
+		},
+		{
+			name:        "tenant lookup failure is not an authentication failure",
+			tokens:      withoutTokens,
+			tenantErr:   errTenantLookup,
+			credentials: ports.Credentials{APIKey: testAPIKey},
+			wantErr:     errTenantLookup,
+		},
+		{
+			name:        "access token",
+			tokens:      withTokens,
+			credentials: ports.Credentials{AccessToken: accessToken, LegacyToken: testLegacy},
+			want:        &domain.Principal{Subject: "access-token:" + testImageID, TenantID: testTenantID, Scopes: []domain.Scope{domain.ScopeImagesRead}, ImageID: testImageID},
+		},
+		{
+			name:        "tampered access token",
+			tokens:      withTokens,
+			credentials: ports.Credentials{AccessToken: accessToken + "A"},
+			wantErr:     domain.ErrUnauthenticated,
+		},
+		{
+			name:        "no credentials without any authentication configured",
+			tokens:      withoutTokens,
+			credentials: ports.Credentials{},
+			want:        &domain.Principal{Subject: domain.AnonymousSubject, TenantID: domain.DefaultTenantID, Scopes: domain.Scopes},
+		},
+		{
+			name:        "no credentials with a legacy token configured",
+			tokens:      withoutTokens,
+			credentials: ports.Credentials{LegacyToken: testLegacy},
+			wantErr:     domain.ErrUnauthenticated,
+		},
+		{
+			name:        "no credentials with bearer tokens configured",
+			tokens:      withTokens,
+			credentials: ports.Credentials{},
+			wantErr:     domain.ErrUnauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewAuthService(tt.tokens, &fakeTenants{err: tt.tenantErr}, logging.New(zap.NewNop()), accessSecret, time.Hour, time.Hour)
+
+			got, err := service.Authenticate(context.Background(), tt.credentials)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Authenticate error = %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantErr != domain.ErrUnauthenticated && errors.Is(err, domain.ErrUnauthenticated) {
+					t.Errorf("Authenticate error %v is reported as unauthenticated", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if !samePrincipal(got, tt.want) {
+				t.Errorf("Authenticate = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthServiceAccessTokensDisabled(t *testing.T) {
+	token, err := utils.SignImageAccess([]byte("access-secret"), utils.ImageAccess{TenantID: testTenantID, ImageID: testImageID, ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewAuthService(utils.NewTokenVerifier(nil, nil, "", ""), &fakeTenants{}, logging.New(zap.NewNop()), nil, time.Hour, time.Hour)
+
+	_, err = service.Authenticate(context.Background(), ports.Credentials{AccessToken: token})
+	if !errors.Is(err, domain.ErrUnauthenticated) || !errors.Is(err, domain.ErrAccessTokenDisabled) {
+		t.Errorf("Authenticate error = %v, want ErrUnauthenticated and ErrAccessTokenDisabled", err)
+	}
+}
+
+func samePrincipal(a, b *domain.Principal) bool {
+	return a.Subject == b.Subject && a.TenantID == b.TenantID && a.ImageID == b.ImageID && slices.Equal(a.Scopes, b.Scopes)
+}
@@ -0,0 +1,17 @@
+package app
+
+import (
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/pkg/utils"
+)
+
+func webpOptions(settings domain.EncodingSettings) utils.WebpOptions {
+	return utils.WebpOptions{
+		Mode:          utils.WebpMode(settings.Mode),
+		Quality:       settings.Quality,
+		NearLossless:  settings.NearLosslessLevel,
+		Exact:         settings.ExactAlpha,
+		TryLossless:   settings.TryLossless,
+		KeepWideGamut: settings.KeepWideGamut,
+	}
+}
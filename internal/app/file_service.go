@@ -0,0 +1,130 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/logging"
+	"image-resizing-service/pkg/utils"
+	"path"
+)
+
+// imageIDLength is the length of the image UUIDs that file keys are named after.
+const imageIDLength = 36
+
+type FileService struct {
+	imageRepository ports.ImageRepository
+	minio           *utils.MinioClient
+	logger          *logging.Logger
+}
+
+func NewFileService(imageRepo ports.ImageRepository, minio *utils.MinioClient, logger *logging.Logger) ports.FileUseCase {
+	return &FileService{
+		imageRepository: imageRepo,
+		minio:           minio,
+		logger:          logger,
+	}
+}
+
+func (s *FileService) Open(ctx context.Context, key string) (*ports.File, error) {
+	// Every key ends in the ID of its image: originals/<id>, compressed/<id>.webp or
+	// thumbnails/<id>_<preset>.webp, under the prefix of the tenant or uploads/.
+	name := path.Base(key)
+	if len(name) < imageIDLength || uuid.Validate(name[:imageIDLength]) != nil {
+		return nil, domain.ErrFileNotFound
+	}
+
+	image, err := s.readableImage(ctx, name[:imageIDLength])
+	if err != nil {
+		return nil, err
+	}
+	if !storesKey(image, key) {
+		return nil, domain.ErrFileNotFound
+	}
+
+	return s.open(ctx, image, key)
+}
+
+func (s *FileService) OpenOriginal(ctx context.Context, imageID string) (*ports.File, error) {
+	image, err := s.readableImage(ctx, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.open(ctx, image, image.OriginalKey)
+}
+
+func (s *FileService) OpenThumbnail(ctx context.Context, imageID, label string) (*ports.File, error) {
+	image, err := s.readableImage(ctx, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, thumbnail := range image.Thumbnails {
+		if thumbnail.Size == label && thumbnail.Status == domain.RenditionReady {
+			return s.open(ctx, image, thumbnail.Key)
+		}
+	}
+
+	return nil, domain.ErrFileNotFound
+}
+
+// readableImage finds the image with the given ID if the principal in ctx may read its files.
+func (s *FileService) readableImage(ctx context.Context, id string) (*domain.Image, error) {
+	image, err := s.imageRepository.WithContext(ctx).FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrFileNotFound
+		}
+		return nil, fmt.Errorf("failed to find image: %w", err)
+	}
+
+	if image.Visibility == domain.VisibilityPublic {
+		return image, nil
+	}
+
+	// Callers without a tenant get the same answer as for a missing file, so they cannot probe which IDs exist.
+	principal := domain.PrincipalFromContext(ctx)
+	switch {
+	case principal == nil || principal.TenantID == "",
+		principal.TenantID != image.TenantID, principal.ImageID != "" && principal.ImageID != image.ID:
+		return nil, domain.ErrFileNotFound
+	case !principal.Allows(domain.ScopeImagesRead):
+		return nil, fmt.Errorf("%w: %s", domain.ErrForbidden, domain.ScopeImagesRead)
+	}
+
+	return image, nil
+}
+
+func (s *FileService) open(ctx context.Context, image *domain.Image, key string) (*ports.File, error) {
+	object, err := s.minio.OpenObject(ctx, key)
+	if errors.Is(err, utils.ErrObjectNotFound) {
+		s.logger.Ctx(ctx).Warn("file of image is missing from storage", zap.String("image_id", image.ID), zap.String("key", key))
+		return nil, domain.ErrFileNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return &ports.File{Image: image, Key: key, Object: object}, nil
+}
+
+// storesKey reports whether key is the original or a rendered file of image.
+func storesKey(image *domain.Image, key string) bool {
+	if key == image.OriginalKey || (image.CompressedKey != "" && key == image.CompressedKey) {
+		return true
+	}
+
+	for _, thumbnail := range image.Thumbnails {
+		if thumbnail.Key == key && thumbnail.Status == domain.RenditionReady {
+			return true
+		}
+	}
+
+	return false
+}
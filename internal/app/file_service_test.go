@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/logging"
+	"testing"
+)
+
+var errImageLookup = errors.New("database is down")
+
+// fakeImages finds the images it holds by ID, or fails every lookup with err.
+type fakeImages struct {
+	ports.ImageRepository
+	images map[string]*domain.Image
+	err    error
+}
+
+func (f *fakeImages) WithContext(context.Context) ports.ImageRepository {
+	return f
+}
+
+func (f *fakeImages) FindByID(id string) (*domain.Image, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	image, ok := f.images[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return image, nil
+}
+
+func TestFileServiceReadableImage(t *testing.T) {
+	const otherTenantID = "5c9e1a3b-7d2f-4b6a-8e0c-2f4a6c8e0b1d"
+	const otherImageID = "9d2f4b6a-1c3e-4a5b-8d7f-0e2a4c6e8f1b"
+
+	reader := &domain.Principal{Subject: "reader", TenantID: testTenantID, Scopes: []domain.Scope{domain.ScopeImagesRead}}
+
+	tests := []struct {
+		name       string
+		visibility domain.Visibility
+		principal  *domain.Principal
+		lookupErr  error
+		imageID    string
+		wantErr    error
+	}{
+		{name: "public image without credentials", visibility: domain.VisibilityPublic},
+		{name: "public image of another tenant", visibility: domain.VisibilityPublic, principal: &domain.Principal{Subject: "other", TenantID: otherTenantID, Scopes: domain.TenantScopes}},
+		{name: "private image without credentials", visibility: domain.VisibilityPrivate, wantErr: domain.ErrFileNotFound},
+		{name: "private image for a principal without a tenant", visibility: domain.VisibilityPrivate, principal: &domain.Principal{Subject: domain.AnonymousSubject, Scopes: domain.Scopes}, wantErr: domain.ErrFileNotFound},
+		{name: "private image of the tenant", visibility: domain.VisibilityPrivate, principal: reader},
+		{name: "private image of another tenant", visibility: domain.VisibilityPrivate, principal: &domain.Principal{Subject: "other", TenantID: otherTenantID, Scopes: domain.TenantScopes}, wantErr: domain.ErrFileNotFound},
+		{name: "access token for the image", visibility: domain.VisibilityPrivate, principal: &domain.Principal{Subject: "access-token:" + testImageID, TenantID: testTenantID, Scopes: []domain.Scope{domain.ScopeImagesRead}, ImageID: testImageID}},
+		{name: "access token for another image", visibility: domain.VisibilityPrivate, principal: &domain.Principal{Subject: "access-token:" + otherImageID, TenantID: testTenantID, Scopes: []domain.Scope{domain.ScopeImagesRead}, ImageID: otherImageID}, wantErr: domain.ErrFileNotFound},
+		{name: "private image without the read scope", visibility: domain.VisibilityPrivate, principal: &domain.Principal{Subject: "uploader", TenantID: testTenantID, Scopes: []domain.Scope{domain.ScopeImagesUpload}}, wantErr: domain.ErrForbidden},
+		{name: "missing image", visibility: domain.VisibilityPublic, principal: reader, imageID: otherImageID, wantErr: domain.ErrFileNotFound},
+		{name: "lookup failure", visibility: domain.VisibilityPublic, principal: reader, lookupErr: errImageLookup, wantErr: errImageLookup},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			image := &domain.Image{ID: testImageID, TenantID: testTenantID, Visibility: tt.visibility}
+			repository := &fakeImages{images: map[string]*domain.Image{testImageID: image}, err: tt.lookupErr}
+			service := &FileService{imageRepository: repository, logger: logging.New(zap.NewNop())}
+
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = domain.WithPrincipal(ctx, tt.principal)
+			}
+			id := tt.imageID
+			if id == "" {
+				id = testImageID
+			}
+
+			got, err := service.readableImage(ctx, id)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("readableImage error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readableImage: %v", err)
+			}
+			if got != image {
+				t.Errorf("readableImage = %+v, want %+v", got, image)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/logging"
+	"image-resizing-service/pkg/utils"
+	"sync"
+	"time"
+)
+
+type HealthService struct {
+	db           *gorm.DB
+	redis        *redis.Client
+	minio        *utils.MinioClient
+	imageUseCase ports.ImageUseCase
+	// timeout bounds each dependency check, so a hanging connection fails the probe instead of stalling it.
+	timeout time.Duration
+	logger  *logging.Logger
+}
+
+func NewHealthService(db *gorm.DB, redis *redis.Client, minio *utils.MinioClient, imageUseCase ports.ImageUseCase, timeout time.Duration, logger *logging.Logger) ports.HealthUseCase {
+	return &HealthService{
+		db:           db,
+		redis:        redis,
+		minio:        minio,
+		imageUseCase: imageUseCase,
+		timeout:      timeout,
+		logger:       logger,
+	}
+}
+
+func (s *HealthService) Ready(ctx context.Context) *ports.HealthReport {
+	checks := []struct {
+		name  string
+		check func(ctx context.Context) (map[string]any, error)
+	}{
+		{"database", s.checkDatabase},
+		{"redis", s.checkRedis},
+		{"storage", s.checkStorage},
+		{"workers", s.checkWorkers},
+	}
+
+	report := &ports.HealthReport{
+		Ready:  true,
+		Checks: make([]ports.HealthCheck, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, s.timeout)
+			defer cancel()
+
+			start := time.Now()
+			details, err := c.check(checkCtx)
+
+			result := ports.HealthCheck{
+				Name:       c.name,
+				Healthy:    err == nil,
+				DurationMs: time.Since(start).Milliseconds(),
+				Details:    details,
+			}
+			if err != nil {
+				result.Error = err.Error()
+			}
+			report.Checks[i] = result
+		}()
+	}
+	wg.Wait()
+
+	for _, check := range report.Checks {
+		if !check.Healthy {
+			report.Ready = false
+			s.logger.Ctx(ctx).Warn("readiness check failed", zap.String("check", check.Name), zap.String("error", check.Error))
+		}
+	}
+
+	return report
+}
+
+func (s *HealthService) checkDatabase(ctx context.Context) (map[string]any, error) {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, sqlDB.PingContext(ctx)
+}
+
+func (s *HealthService) checkRedis(ctx context.Context) (map[string]any, error) {
+	return nil, s.redis.Ping(ctx).Err()
+}
+
+func (s *HealthService) checkStorage(ctx context.Context) (map[string]any, error) {
+	return nil, s.minio.CheckBucket(ctx)
+}
+
+// checkWorkers fails while processing is draining for shutdown.
+func (s *HealthService) checkWorkers(_ context.Context) (map[string]any, error) {
+	state := s.imageUseCase.State()
+	details := map[string]any{"in_flight": state.InFlight}
+
+	if state.Draining {
+		return details, errors.New("image processing is shutting down")
+	}
+
+	return details, nil
+}
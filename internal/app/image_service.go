@@ -1,144 +1,691 @@
 package app
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 	"image-resizing-service/internal/domain"
 	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/logging"
+	"image-resizing-service/pkg/metrics"
+	"image-resizing-service/pkg/tracing"
 	"image-resizing-service/pkg/utils"
 	"os"
+	"sync"
+	"time"
 )
 
 type ImageService struct {
 	db              *gorm.DB
 	imageRepository ports.ImageRepository
+	usageRepository ports.UsageRepository
 	resizeService   ports.ResizeUseCase
-	minio           *utils.MinioClient
-	logger          *zap.Logger
+	presets         ports.PresetUseCase
+	usage           ports.UsageUseCase
+	// cache is nil when caching is disabled.
+	cache    ports.ImageCache
+	minio    *utils.MinioClient
+	logger   *logging.Logger
+	limits   utils.ImageLimits
+	encoding domain.EncodingSettings
+	// defaultVisibility applies to uploads that do not choose a visibility.
+	defaultVisibility domain.Visibility
+	// inFlight holds the IDs of images currently being processed, so an upload, a reprocess
+	// request and a bulk job never render the same image at the same time.
+	inFlight sync.Map
+
+	// ctx is cancelled when Drain runs out of time, aborting background processing.
+	ctx        context.Context
+	cancel     context.CancelFunc
+	lifecycle  sync.Mutex
+	draining   bool
+	background sync.WaitGroup
 }
 
+// drainCancelGrace is how long Drain waits for cancelled processing to record its state.
+const drainCancelGrace = 10 * time.Second
+
 func NewImageService(
 	db *gorm.DB,
 	imageRepo ports.ImageRepository,
+	usageRepo ports.UsageRepository,
 	resizeService ports.ResizeUseCase,
+	presets ports.PresetUseCase,
+	usage ports.UsageUseCase,
+	cache ports.ImageCache,
 	minio *utils.MinioClient,
-	logger *zap.Logger,
+	logger *logging.Logger,
+	limits utils.ImageLimits,
+	encoding domain.EncodingSettings,
+	defaultVisibility domain.Visibility,
 ) ports.ImageUseCase {
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return &ImageService{
-		db:              db,
-		imageRepository: imageRepo,
-		resizeService:   resizeService,
-		minio:           minio,
-		logger:          logger,
+		ctx:               ctx,
+		cancel:            cancel,
+		db:                db,
+		imageRepository:   imageRepo,
+		usageRepository:   usageRepo,
+		resizeService:     resizeService,
+		presets:           presets,
+		usage:             usage,
+		cache:             cache,
+		minio:             minio,
+		logger:            logger,
+		limits:            limits,
+		encoding:          encoding,
+		defaultVisibility: defaultVisibility,
+	}
+}
+
+func (s *ImageService) UploadOriginal(ctx context.Context, filePath string, contentType string, visibility domain.Visibility) (_ *ports.UploadResult, err error) {
+	ctx, span := tracing.Start(ctx, "ImageService.UploadOriginal", attribute.String("image.content_type", contentType))
+	defer func() { tracing.End(span, err) }()
+
+	if s.isDraining() {
+		return nil, domain.ErrShuttingDown
+	}
+
+	image, quotaWarning, err := s.storeOriginal(ctx, filePath, contentType, visibility)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("image.id", image.ID))
+	ctx = logging.With(ctx, zap.String("image_id", image.ID))
+
+	s.inFlight.Store(image.ID, struct{}{})
+	s.dispatch(ctx, uuid.MustParse(image.ID), ports.ProcessOptions{})
+
+	return &ports.UploadResult{
+		ID:           image.ID,
+		TenantID:     image.TenantID,
+		OriginalKey:  image.OriginalKey,
+		ContentType:  image.ContentType,
+		Status:       string(domain.StatusPending),
+		Visibility:   image.Visibility,
+		QuotaWarning: quotaWarning,
+	}, nil
+}
+
+func (s *ImageService) ImportFile(ctx context.Context, filePath string, contentType string, visibility domain.Visibility) (*domain.Image, error) {
+	image, _, err := s.storeOriginal(ctx, filePath, contentType, visibility)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ProcessImage(ctx, image.ID, ports.ProcessOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to process image %s: %w", image.ID, err)
 	}
+
+	return s.imageRepository.WithContext(ctx).FindByID(image.ID)
 }
 
-func (s *ImageService) UploadOriginal(ctx context.Context, filePath string, contentType string) (*ports.UploadResult, error) {
+// storeOriginal also returns a warning when the upload reached a soft quota of the tenant.
+func (s *ImageService) storeOriginal(ctx context.Context, filePath string, contentType string, visibility domain.Visibility) (*domain.Image, string, error) {
+	visibility, err := domain.ParseVisibility(string(visibility), s.defaultVisibility)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fileBytes, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+
+	if err := s.limits.Check(fileBytes); err != nil {
+		countError(err, "rejected")
+		return nil, "", err
+	}
+
+	// The original is handed out as is, so an SVG is stored the way it is rasterised: without scripts,
+	// event handlers or external references that would run on the origin serving it.
+	sanitized := contentType == utils.SVGMimeType
+	if sanitized {
+		fileBytes, err = utils.SanitizeSVG(bytes.NewReader(fileBytes))
+		if err != nil {
+			err = fmt.Errorf("%w: %v", utils.ErrInvalidImage, err)
+			countError(err, "rejected")
+			return nil, "", err
+		}
+	}
+
+	tenantID, ok := domain.TenantFromContext(ctx)
+	if !ok {
+		tenantID = domain.DefaultTenantID
+	}
+
+	quotaCheck, err := s.usage.CheckUpload(ctx, tenantID, int64(len(fileBytes)))
+	if err != nil {
+		countError(err, "rejected")
+		return nil, "", err
+	}
+	if quotaCheck.Warning != "" {
+		s.logger.Ctx(ctx).Warn("tenant is over its soft quota", zap.String("warning", quotaCheck.Warning))
+	}
+
 	id := uuid.New()
+	ctx = logging.With(ctx, zap.String("image_id", id.String()))
 
-	originalKey := fmt.Sprintf("uploads/originals/%s", id.String())
+	originalKey := domain.OriginalKey(tenantID, id.String())
 
-	if err := s.minio.UploadFile(ctx, originalKey, filePath, contentType); err != nil {
-		return nil, fmt.Errorf("failed to upload original file: %w", err)
+	if sanitized {
+		err = s.minio.UploadBytes(ctx, originalKey, fileBytes, contentType)
+	} else {
+		err = s.minio.UploadFile(ctx, originalKey, filePath, contentType)
+	}
+	if err != nil {
+		countError(err, "storage")
+		return nil, "", fmt.Errorf("failed to upload original file: %w", err)
 	}
 
 	image := &domain.Image{
-		ID:          id.String(),
-		OriginalKey: originalKey,
-		Status:      domain.StatusPending,
+		ID:            id.String(),
+		TenantID:      tenantID,
+		OriginalKey:   originalKey,
+		Status:        domain.StatusPending,
+		Visibility:    visibility,
+		ContentType:   contentType,
+		OriginalBytes: int64(len(fileBytes)),
 	}
 
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		imgRepo := s.imageRepository.WithTx(tx)
-		return imgRepo.Save(image)
+		if err := imgRepo.Save(image); err != nil {
+			return err
+		}
+		added, err := s.usageRepository.WithTx(tx).AddWithinQuota(tenantID, image.OriginalBytes, 1, quotaCheck.Quota)
+		if err != nil {
+			return err
+		}
+		if !added {
+			// Concurrent uploads took the rest of the quota since CheckUpload.
+			return fmt.Errorf("%w: %d more bytes would take the tenant past a hard limit", domain.ErrQuotaExceeded, image.OriginalBytes)
+		}
+		return nil
 	})
+	if errors.Is(err, domain.ErrQuotaExceeded) {
+		countError(err, "rejected")
+		if removeErr := s.minio.RemoveObject(context.WithoutCancel(ctx), originalKey); removeErr != nil {
+			s.logger.Ctx(ctx).Warn("failed to remove original of rejected upload", zap.String("key", originalKey), zap.Error(removeErr))
+		}
+		return nil, "", err
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to save image record: %w", err)
+		countError(err, "database")
+		return nil, "", fmt.Errorf("failed to save image record: %w", err)
 	}
 
-	go s.compressAndDispatch(context.Background(), id, originalKey)
+	transport := metrics.TransportFromContext(ctx)
+	metrics.Uploads.WithLabelValues(transport, contentType).Inc()
+	metrics.UploadBytes.WithLabelValues(transport, contentType).Add(float64(len(fileBytes)))
+	recordTransition("", domain.StatusPending)
 
-	return &ports.UploadResult{
-		ID:          id.String(),
-		OriginalKey: originalKey,
-		Status:      string(domain.StatusPending),
-	}, nil
+	s.logger.Ctx(ctx).Info("original stored", zap.String("content_type", contentType), zap.Int("bytes", len(fileBytes)), zap.String("visibility", string(visibility)))
+
+	return image, quotaCheck.Warning, nil
 }
 
+// FindByID reports images of other tenants as not found, so callers cannot tell them apart from missing ones.
 func (s *ImageService) FindByID(ctx context.Context, id string) (*domain.Image, error) {
-	return s.imageRepository.FindByID(id)
+	image, err := s.imageRepository.WithContext(ctx).FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrImageNotFound
+		}
+		return nil, fmt.Errorf("failed to find image: %w", err)
+	}
+
+	return image, nil
+}
+
+func (s *ImageService) Delete(ctx context.Context, id string) error {
+	ctx = logging.With(ctx, zap.String("image_id", id))
+
+	image, err := s.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Claiming the image keeps processing from starting while its rows and objects go away.
+	if _, busy := s.inFlight.LoadOrStore(image.ID, struct{}{}); busy {
+		return domain.ErrProcessingInProgress
+	}
+	defer s.inFlight.Delete(image.ID)
+
+	deleted := false
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if deleted, err = s.imageRepository.WithTx(tx).Delete(image.ID); err != nil || !deleted {
+			return err
+		}
+		return s.usageRepository.WithTx(tx).Add(image.TenantID, -image.StoredBytes(), -1)
+	})
+	if err != nil {
+		countError(err, "database")
+		return fmt.Errorf("failed to delete image: %w", err)
+	}
+	if !deleted {
+		return domain.ErrImageNotFound
+	}
+	s.forget(ctx, image)
+
+	keys := []string{image.OriginalKey, image.CompressedKey}
+	for _, thumbnail := range image.Thumbnails {
+		keys = append(keys, thumbnail.Key)
+	}
+
+	// The rows are gone, so objects that fail to be removed here are orphans and left to the orphan GC.
+	removed := 0
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := s.minio.RemoveObject(ctx, key); err != nil {
+			countError(err, "storage")
+			s.logger.Ctx(ctx).Warn("failed to remove object of deleted image", zap.String("key", key), zap.Error(err))
+			continue
+		}
+		removed++
+	}
+
+	s.logger.Ctx(ctx).Info("image deleted", zap.Int("objects", removed))
+
+	return nil
+}
+
+func (s *ImageService) Reprocess(ctx context.Context, id string, presets []string) (*domain.Image, error) {
+	if s.isDraining() {
+		return nil, domain.ErrShuttingDown
+	}
+	ctx = logging.With(ctx, zap.String("image_id", id))
+
+	if _, err := s.presets.Resolve(ctx, presets); err != nil {
+		return nil, err
+	}
+
+	imageRepo := s.imageRepository.WithContext(ctx)
+
+	image, err := imageRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrImageNotFound
+		}
+		return nil, fmt.Errorf("failed to find image: %w", err)
+	}
+
+	if _, busy := s.inFlight.LoadOrStore(image.ID, struct{}{}); busy {
+		return nil, domain.ErrProcessingInProgress
+	}
+
+	previous := image.Status
+	image.Status = domain.StatusProcessing
+	image.ErrorMessage = nil
+
+	if err := imageRepo.Update(image); err != nil {
+		s.inFlight.Delete(image.ID)
+		return nil, fmt.Errorf("failed to update image status: %w", err)
+	}
+	recordTransition(previous, domain.StatusProcessing)
+	s.forget(ctx, image)
+
+	s.dispatch(ctx, uuid.MustParse(image.ID), ports.ProcessOptions{Presets: presets, Force: true})
+
+	return image, nil
+}
+
+func (s *ImageService) Requeue(ctx context.Context, id string) error {
+	if s.isDraining() {
+		return domain.ErrShuttingDown
+	}
+
+	imageID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid image id: %w", err)
+	}
+
+	if _, busy := s.inFlight.LoadOrStore(imageID.String(), struct{}{}); busy {
+		return domain.ErrProcessingInProgress
+	}
+
+	s.dispatch(logging.With(ctx, zap.String("image_id", imageID.String())), imageID, ports.ProcessOptions{})
+
+	return nil
+}
+
+// Drain stops new background processing and waits for the running one. If ctx expires first,
+// the remaining work is cancelled and its images are returned to pending for the reaper.
+func (s *ImageService) Drain(ctx context.Context) error {
+	s.lifecycle.Lock()
+	s.draining = true
+	s.lifecycle.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.cancel()
+
+	select {
+	case <-done:
+	case <-time.After(drainCancelGrace):
+	}
+
+	return fmt.Errorf("processing did not finish before the deadline: %w", ctx.Err())
+}
+
+func (s *ImageService) State() ports.WorkerState {
+	inFlight := 0
+	s.inFlight.Range(func(_, _ any) bool {
+		inFlight++
+		return true
+	})
+
+	return ports.WorkerState{
+		Draining: s.isDraining(),
+		InFlight: inFlight,
+	}
+}
+
+func (s *ImageService) isDraining() bool {
+	s.lifecycle.Lock()
+	defer s.lifecycle.Unlock()
+
+	return s.draining
 }
 
-func (s *ImageService) compressAndDispatch(ctx context.Context, id uuid.UUID, originalKey string) {
+// dispatch processes the image in the background. The caller must already have marked the image
+// in flight. Once draining has started the image is left pending instead, for the reaper to resume.
+// The processing trace is linked to the span in ctx, the request that caused it.
+func (s *ImageService) dispatch(ctx context.Context, id uuid.UUID, options ports.ProcessOptions) {
+	s.lifecycle.Lock()
+	if s.draining {
+		s.lifecycle.Unlock()
+		s.inFlight.Delete(id.String())
+		return
+	}
+	s.background.Add(1)
+	s.lifecycle.Unlock()
+
+	go func() {
+		defer s.background.Done()
+		s.compressAndDispatch(ctx, id, options)
+	}()
+}
+
+// compressAndDispatch runs processing in the background. The caller must already have marked the image in flight.
+// It only takes the trace from origin; the work itself runs under the service context, which outlives the request.
+func (s *ImageService) compressAndDispatch(origin context.Context, id uuid.UUID, options ports.ProcessOptions) {
+	ctx, span := tracing.StartLinked(s.ctx, origin, "ImageService.process", attribute.String("image.id", id.String()))
+	ctx = logging.With(logging.Inherit(ctx, origin), zap.String("image_id", id.String()), zap.String("stage", "process"))
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	defer s.inFlight.Delete(id.String())
 	defer func() {
 		if r := recover(); r != nil {
-			s.logger.Error("panic in compressAndDispatch", zap.Any("recover", r))
+			s.logger.Ctx(ctx).Error("panic in compressAndDispatch", zap.Any("recover", r))
+			metrics.Errors.WithLabelValues("panic").Inc()
+			err = fmt.Errorf("panic: %v", r)
+			s.markAsError(ctx, id, err)
 		}
 	}()
 
-	originalFile, err := s.minio.GetFileAsBytes(ctx, originalKey)
+	if err = s.process(ctx, id, options); err != nil {
+		s.logger.Ctx(ctx).Error("failed to process image", zap.Error(err))
+	}
+}
+
+// ProcessImage produces the renditions selected by options that are not already stored and verified.
+// It is safe to re-run after a failure: finished renditions are skipped, so a retry only downloads
+// and decodes the original when something is actually missing.
+func (s *ImageService) ProcessImage(ctx context.Context, id string, options ports.ProcessOptions) (err error) {
+	ctx, span := tracing.Start(ctx, "ImageService.ProcessImage", attribute.String("image.id", id))
+	defer func() { tracing.End(span, err) }()
+	ctx = logging.With(ctx, zap.String("image_id", id), zap.String("stage", "process"))
+
+	imageID, err := uuid.Parse(id)
 	if err != nil {
-		s.markAsError(ctx, id, fmt.Errorf("failed to get file as bytes: %w", err))
-		return
+		return fmt.Errorf("invalid image id: %w", err)
+	}
+
+	if _, busy := s.inFlight.LoadOrStore(imageID.String(), struct{}{}); busy {
+		return domain.ErrProcessingInProgress
+	}
+	defer s.inFlight.Delete(imageID.String())
+
+	return s.process(ctx, imageID, options)
+}
+
+// process renders the image and records the outcome on it, marking the image as failed on error.
+func (s *ImageService) process(ctx context.Context, imageID uuid.UUID, options ports.ProcessOptions) error {
+	startedAt := time.Now()
+
+	if err := s.render(ctx, imageID, options); err != nil {
+		outcome := "error"
+		switch {
+		case ctx.Err() != nil:
+			outcome = "interrupted"
+			s.markAsInterrupted(ctx, imageID)
+		case errors.Is(err, domain.ErrImageNotFound):
+			outcome = "not_found"
+		default:
+			s.markAsError(ctx, imageID, err)
+		}
+		metrics.ProcessingDuration.WithLabelValues(outcome).Observe(time.Since(startedAt).Seconds())
+		return err
+	}
+
+	metrics.ProcessingDuration.WithLabelValues("ready").Observe(time.Since(startedAt).Seconds())
+
+	return nil
+}
+
+func (s *ImageService) render(ctx context.Context, imageID uuid.UUID, options ports.ProcessOptions) error {
+	startedAt := time.Now()
+
+	selected, err := s.presets.Resolve(ctx, options.Presets)
+	if err != nil {
+		return err
 	}
 
-	webpPath, err := utils.ConvertBytesToWebp(originalFile)
+	imageRepo := s.imageRepository.WithContext(ctx)
+
+	image, err := imageRepo.FindByID(imageID.String())
 	if err != nil {
-		s.markAsError(ctx, id, fmt.Errorf("failed to convert to webp: %w", err))
-		return
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrImageNotFound
+		}
+		return fmt.Errorf("failed to find image: %w", err)
 	}
-	defer os.Remove(webpPath)
 
-	compressedKey := fmt.Sprintf("uploads/compressed/%s.webp", id.String())
+	compressedReady := false
+	if !options.Force || len(options.Presets) > 0 {
+		if compressedReady, err = s.compressedReady(ctx, image); err != nil {
+			return err
+		}
+	}
 
-	if err := s.minio.UploadFile(ctx, compressedKey, webpPath, "image/webp"); err != nil {
-		s.markAsError(ctx, id, fmt.Errorf("failed to upload compressed webp: %w", err))
-		return
+	presets := selected
+	if !options.Force {
+		if presets, err = s.resizeService.PendingPresets(ctx, imageID, selected); err != nil {
+			return fmt.Errorf("failed to find pending thumbnails: %w", err)
+		}
 	}
 
-	image, err := s.imageRepository.FindByID(id.String())
+	if !compressedReady || len(presets) > 0 {
+		if err := s.renderMissing(ctx, image, imageID, compressedReady, presets); err != nil {
+			return err
+		}
+	}
+
+	previous := image.Status
+	image.Status = domain.StatusReady
+	image.ErrorMessage = nil
+	image.ProcessingMs = time.Since(startedAt).Milliseconds()
+	image.Attempts = 0
+
+	if err := imageRepo.Update(image); err != nil {
+		countError(err, "database")
+		return fmt.Errorf("failed to update image status: %w", err)
+	}
+	recordTransition(previous, domain.StatusReady)
+	s.forget(ctx, image)
+
+	s.logger.Ctx(ctx).Info("image processed", zap.Int64("processing_ms", image.ProcessingMs), zap.Int("thumbnails", len(presets)))
+
+	return nil
+}
+
+func (s *ImageService) renderMissing(ctx context.Context, image *domain.Image, imageID uuid.UUID, compressedReady bool, presets []domain.ThumbnailSize) error {
+	stageCtx, endStage := startStage(ctx, "download", "original")
+	originalFile, err := s.minio.GetFileAsBytes(stageCtx, image.OriginalKey)
+	endStage(err)
 	if err != nil {
-		s.logger.Error("failed to find image for update", zap.Error(err))
-		return
+		countError(err, "storage")
+		return fmt.Errorf("failed to get file as bytes: %w", err)
+	}
+
+	// The original is decoded once; the compressed rendition and every thumbnail are derived
+	// from this in-memory image rather than from each other.
+	_, endStage = startStage(ctx, "decode", "original")
+	source, err := utils.DecodeSource(originalFile, s.limits)
+	endStage(err)
+	if err != nil {
+		countError(err, "decode")
+		return err
 	}
 
-	image.CompressedKey = compressedKey
+	var compressedDelta int64
+	if !compressedReady {
+		compressed, err := s.resizeService.EncodeCompressed(ctx, source, s.encoding)
+		if err != nil {
+			countError(err, "encode")
+			return err
+		}
+
+		compressedKey := domain.CompressedKey(image.TenantID, image.ID)
+
+		stageCtx, endStage = startStage(ctx, "upload", "compressed")
+		err = s.minio.UploadBytes(stageCtx, compressedKey, compressed, "image/webp")
+		endStage(err)
+		if err != nil {
+			countError(err, "storage")
+			return fmt.Errorf("failed to upload compressed webp: %w", err)
+		}
+
+		// A compressed rendition stored before replaces the previous one under the same key.
+		compressedDelta = int64(len(compressed)) - image.CompressedBytes
+		image.CompressedKey = compressedKey
+		image.CompressedBytes = int64(len(compressed))
+	}
+
+	previous := image.Status
 	image.Status = domain.StatusProcessing
+	image.ErrorMessage = nil
 
-	if err := s.imageRepository.Update(image); err != nil {
-		s.logger.Error("failed to update image after compression", zap.Error(err))
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.imageRepository.WithTx(tx).Update(image); err != nil {
+			return err
+		}
+		return s.usageRepository.WithTx(tx).Add(image.TenantID, compressedDelta, 0)
+	})
+	if err != nil {
+		countError(err, "database")
+		return fmt.Errorf("failed to update image after compression: %w", err)
+	}
+	recordTransition(previous, domain.StatusProcessing)
+	s.forget(ctx, image)
+
+	if len(presets) == 0 {
+		return nil
+	}
+
+	if err := s.resizeService.ResizeThumbnails(ctx, image.TenantID, imageID, source, presets); err != nil {
+		return fmt.Errorf("failed to resize thumbnails: %w", err)
+	}
+
+	return nil
+}
+
+func (s *ImageService) compressedReady(ctx context.Context, image *domain.Image) (bool, error) {
+	if image.CompressedKey == "" {
+		return false, nil
+	}
+
+	exists, err := s.minio.ObjectExists(ctx, image.CompressedKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify compressed rendition: %w", err)
+	}
+
+	return exists, nil
+}
+
+// markAsInterrupted returns an image whose processing was cancelled to pending, so the reaper resumes it.
+// ctx is already cancelled, so only its trace is kept.
+func (s *ImageService) markAsInterrupted(ctx context.Context, id uuid.UUID) {
+	imageRepo := s.imageRepository.WithContext(context.WithoutCancel(ctx))
+
+	image, err := imageRepo.FindByID(id.String())
+	if err != nil {
+		s.logger.Ctx(ctx).Error("failed to find interrupted image", zap.Error(err))
 		return
 	}
 
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				s.logger.Error("panic in resize thumbnails", zap.Any("recover", r))
-			}
-		}()
+	previous := image.Status
+	image.Status = domain.StatusPending
+	image.ErrorMessage = nil
 
-		if err := s.resizeService.ResizeThumbnails(context.Background(), id, compressedKey); err != nil {
-			s.logger.Error("failed to resize thumbnails", zap.Error(err))
-			s.markAsError(context.Background(), id, err)
-		}
-	}()
+	if err := imageRepo.Update(image); err != nil {
+		s.logger.Ctx(ctx).Error("failed to return interrupted image to pending", zap.Error(err))
+		return
+	}
+	recordTransition(previous, domain.StatusPending)
+	s.forget(ctx, image)
+	metrics.Errors.WithLabelValues("interrupted").Inc()
+
+	s.logger.Ctx(ctx).Warn("image processing interrupted, returned to pending")
 }
 
 func (s *ImageService) markAsError(ctx context.Context, id uuid.UUID, originalErr error) {
-	image, err := s.imageRepository.FindByID(id.String())
+	// The failure may have come from a cancelled ctx; it must still be recorded.
+	imageRepo := s.imageRepository.WithContext(context.WithoutCancel(ctx))
+
+	image, err := imageRepo.FindByID(id.String())
 	if err != nil {
-		s.logger.Error("failed to find image for error update", zap.Error(err))
+		s.logger.Ctx(ctx).Error("failed to find image for error update", zap.Error(err))
 		return
 	}
 
 	errorMessage := originalErr.Error()
+	previous := image.Status
 	image.Status = domain.StatusError
 	image.ErrorMessage = &errorMessage
 
-	if err := s.imageRepository.Update(image); err != nil {
-		s.logger.Error("failed to update image status to error", zap.Error(err))
+	if err := imageRepo.Update(image); err != nil {
+		s.logger.Ctx(ctx).Error("failed to update image status to error", zap.Error(err))
+		return
+	}
+	recordTransition(previous, domain.StatusError)
+	s.forget(ctx, image)
+}
+
+// forget drops the cached lookup of the image after it changed. It runs even when ctx was cancelled,
+// since the change is already stored.
+func (s *ImageService) forget(ctx context.Context, image *domain.Image) {
+	if s.cache != nil {
+		s.cache.Invalidate(context.WithoutCancel(ctx), image.TenantID, image.ID)
 	}
 }
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/pkg/logging"
+	"image-resizing-service/pkg/metrics"
+	"image-resizing-service/pkg/tracing"
+	"image-resizing-service/pkg/utils"
+	"time"
+)
+
+// recordTransition counts a status change once it has been persisted.
+func recordTransition(from, to domain.ImageStatus) {
+	if from == to {
+		return
+	}
+
+	label := string(from)
+	if label == "" {
+		label = "new"
+	}
+
+	metrics.StatusTransitions.WithLabelValues(label, string(to)).Inc()
+}
+
+// countError counts a failure under the reason of err, falling back to reason for errors that are
+// not about the image itself (storage, database, encoder).
+func countError(err error, reason string) {
+	var limitErr *utils.LimitError
+	switch {
+	case errors.Is(err, utils.ErrUnsupportedFormat):
+		reason = "unsupported_format"
+	case errors.As(err, &limitErr):
+		reason = "limits_exceeded"
+	case errors.Is(err, utils.ErrInvalidImage):
+		reason = "invalid_image"
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		reason = "quota_exceeded"
+	}
+
+	metrics.Errors.WithLabelValues(reason).Inc()
+}
+
+// startStage traces one processing stage and tags the log lines written within it. The returned
+// function ends the span and, when the stage succeeded, records its duration in the stage histogram.
+func startStage(ctx context.Context, stage, preset string) (context.Context, func(error)) {
+	ctx, span := tracing.Start(ctx, "stage."+stage, attribute.String("stage", stage), attribute.String("preset", preset))
+	ctx = logging.With(ctx, zap.String("stage", stage))
+	startedAt := time.Now()
+
+	return ctx, func(err error) {
+		if err == nil {
+			metrics.ObserveStage(stage, preset, startedAt)
+		}
+		tracing.End(span, err)
+	}
+}
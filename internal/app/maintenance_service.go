@@ -0,0 +1,394 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/logging"
+	"image-resizing-service/pkg/utils"
+	"time"
+)
+
+const maintenanceBatchSize = 500
+
+// orphanPrefixes are the storage prefixes written by the processing pipeline. The uploads/ ones
+// hold the objects stored before tenants existed.
+var orphanPrefixes = []string{domain.TenantKeyPrefix, "uploads/originals/", "uploads/compressed/", "uploads/thumbnails/"}
+
+// MaintenanceService implements the operator tasks run from irsctl.
+type MaintenanceService struct {
+	imageRepository     ports.ImageRepository
+	thumbnailRepository ports.ThumbnailRepository
+	jobRepository       ports.ReprocessJobRepository
+	usageRepository     ports.UsageRepository
+	// cache is nil when caching is disabled.
+	cache         ports.ImageCache
+	imageUseCase  ports.ImageUseCase
+	resizeService ports.ResizeUseCase
+	presets       ports.PresetUseCase
+	minio         *utils.MinioClient
+	logger        *logging.Logger
+}
+
+func NewMaintenanceService(
+	imageRepo ports.ImageRepository,
+	thumbnailRepo ports.ThumbnailRepository,
+	jobRepo ports.ReprocessJobRepository,
+	usageRepo ports.UsageRepository,
+	cache ports.ImageCache,
+	imageUseCase ports.ImageUseCase,
+	resizeService ports.ResizeUseCase,
+	presets ports.PresetUseCase,
+	minio *utils.MinioClient,
+	logger *logging.Logger,
+) ports.MaintenanceUseCase {
+	return &MaintenanceService{
+		imageRepository:     imageRepo,
+		thumbnailRepository: thumbnailRepo,
+		jobRepository:       jobRepo,
+		usageRepository:     usageRepo,
+		cache:               cache,
+		imageUseCase:        imageUseCase,
+		resizeService:       resizeService,
+		presets:             presets,
+		minio:               minio,
+		logger:              logger,
+	}
+}
+
+func (s *MaintenanceService) Stats(ctx context.Context) (*ports.Stats, error) {
+	images, err := s.imageRepository.CountByStatus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count images: %w", err)
+	}
+
+	thumbnails, err := s.thumbnailRepository.CountByStatus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count thumbnails: %w", err)
+	}
+
+	presetCounts, err := s.thumbnailRepository.CountBySize()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count thumbnails per preset: %w", err)
+	}
+
+	jobs, err := s.jobRepository.CountByStatus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count reprocess jobs: %w", err)
+	}
+
+	enabled, err := s.presets.Resolve(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ports.Stats{
+		Images:         images,
+		Thumbnails:     thumbnails,
+		Presets:        presetCounts,
+		ReprocessJobs:  jobs,
+		EnabledPresets: len(enabled),
+	}, nil
+}
+
+func (s *MaintenanceService) Verify(ctx context.Context, repair bool) (*ports.VerifyReport, error) {
+	enabled, err := s.presets.Resolve(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ports.VerifyReport{Issues: []ports.VerifyIssue{}}
+	afterID := ""
+
+	for {
+		ids, err := s.imageRepository.FindIDsByFilter(domain.ReprocessFilter{}, afterID, maintenanceBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list images: %w", err)
+		}
+		if len(ids) == 0 {
+			return report, nil
+		}
+
+		for _, id := range ids {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+
+			report.Checked++
+
+			problems, repairable, err := s.inspectRenditions(ctx, id, enabled)
+			if err != nil {
+				return nil, err
+			}
+			if len(problems) == 0 {
+				continue
+			}
+
+			report.Broken++
+			issue := ports.VerifyIssue{ImageID: id, Problems: problems}
+
+			if repair && repairable {
+				if err := s.imageUseCase.ProcessImage(ctx, id, ports.ProcessOptions{}); err != nil {
+					issue.Error = err.Error()
+				} else {
+					issue.Repaired = true
+					report.Repaired++
+				}
+			}
+
+			report.Issues = append(report.Issues, issue)
+		}
+
+		afterID = ids[len(ids)-1]
+	}
+}
+
+// inspectRenditions lists what is wrong with a single image. Images still pending or processing
+// are skipped, since their renditions may legitimately be missing.
+func (s *MaintenanceService) inspectRenditions(ctx context.Context, id string, enabled []domain.ThumbnailSize) ([]string, bool, error) {
+	image, err := s.imageRepository.FindByID(id)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find image %s: %w", id, err)
+	}
+
+	if image.Status == domain.StatusPending || image.Status == domain.StatusProcessing {
+		return nil, false, nil
+	}
+
+	var problems []string
+
+	originalExists, err := s.minio.ObjectExists(ctx, image.OriginalKey)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to verify original of %s: %w", id, err)
+	}
+	if !originalExists {
+		// Nothing can be regenerated without the original.
+		return []string{"original missing: " + image.OriginalKey}, false, nil
+	}
+
+	if image.Status == domain.StatusError {
+		message := "processing failed"
+		if image.ErrorMessage != nil {
+			message = "processing failed: " + *image.ErrorMessage
+		}
+		problems = append(problems, message)
+	}
+
+	if image.CompressedKey == "" {
+		problems = append(problems, "compressed rendition missing")
+	} else {
+		exists, err := s.minio.ObjectExists(ctx, image.CompressedKey)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to verify compressed rendition of %s: %w", id, err)
+		}
+		if !exists {
+			problems = append(problems, "compressed rendition missing: "+image.CompressedKey)
+		}
+	}
+
+	imageID, err := uuid.Parse(image.ID)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid image id %s: %w", id, err)
+	}
+
+	pending, err := s.resizeService.PendingPresets(ctx, imageID, enabled)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to verify thumbnails of %s: %w", id, err)
+	}
+	for _, size := range pending {
+		problems = append(problems, "thumbnail missing: "+size.Label)
+	}
+
+	return problems, true, nil
+}
+
+func (s *MaintenanceService) CollectGarbage(ctx context.Context, options ports.GCOptions) (*ports.GCReport, error) {
+	report := &ports.GCReport{DryRun: options.DryRun, DisabledPresetThumbnails: []string{}}
+
+	presets, err := s.presets.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var disabled []string
+	for _, preset := range presets {
+		if !preset.Enabled {
+			disabled = append(disabled, preset.Label)
+		}
+	}
+
+	if len(disabled) > 0 {
+		if err := s.collectDisabledPresetThumbnails(ctx, disabled, options.DryRun, report); err != nil {
+			return nil, err
+		}
+	}
+
+	before := time.Now().Add(-options.JobRetention)
+	if options.DryRun {
+		report.ReprocessJobs, err = s.jobRepository.CountFinishedBefore(before)
+	} else {
+		report.ReprocessJobs, err = s.jobRepository.DeleteFinishedBefore(before)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect reprocess jobs: %w", err)
+	}
+
+	if options.Orphans {
+		if report.Orphans, err = s.CollectOrphans(ctx, options.DryRun, options.GracePeriod); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+func (s *MaintenanceService) CollectOrphans(ctx context.Context, dryRun bool, gracePeriod time.Duration) (*ports.OrphanReport, error) {
+	report := &ports.OrphanReport{DryRun: dryRun, Orphans: []ports.OrphanObject{}}
+	cutoff := time.Now().Add(-gracePeriod)
+
+	for _, prefix := range orphanPrefixes {
+		batch := make([]utils.StoredObject, 0, maintenanceBatchSize)
+
+		err := s.minio.ListObjects(ctx, prefix, func(object utils.StoredObject) error {
+			batch = append(batch, object)
+			if len(batch) < maintenanceBatchSize {
+				return nil
+			}
+
+			err := s.reconcileBatch(ctx, batch, cutoff, dryRun, report)
+			batch = batch[:0]
+			return err
+		})
+		if err == nil && len(batch) > 0 {
+			err = s.reconcileBatch(ctx, batch, cutoff, dryRun, report)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to reconcile %s: %w", prefix, err)
+		}
+	}
+
+	s.logger.Ctx(ctx).Info("orphaned objects collected",
+		zap.Bool("dry_run", dryRun),
+		zap.Int("scanned", report.Scanned),
+		zap.Int("orphans", len(report.Orphans)),
+		zap.Int("in_grace_period", report.InGracePeriod),
+		zap.Int64("bytes", report.Bytes),
+	)
+
+	return report, nil
+}
+
+func (s *MaintenanceService) reconcileBatch(ctx context.Context, batch []utils.StoredObject, cutoff time.Time, dryRun bool, report *ports.OrphanReport) error {
+	keys := make([]string, len(batch))
+	for i, object := range batch {
+		keys[i] = object.Key
+	}
+
+	imageKeys, err := s.imageRepository.ReferencedKeys(keys)
+	if err != nil {
+		return fmt.Errorf("failed to match image keys: %w", err)
+	}
+
+	thumbnailKeys, err := s.thumbnailRepository.ReferencedKeys(keys)
+	if err != nil {
+		return fmt.Errorf("failed to match thumbnail keys: %w", err)
+	}
+
+	for _, object := range batch {
+		report.Scanned++
+
+		if imageKeys[object.Key] || thumbnailKeys[object.Key] {
+			continue
+		}
+
+		if object.LastModified.After(cutoff) {
+			report.InGracePeriod++
+			continue
+		}
+
+		if !dryRun {
+			if err := s.minio.RemoveObject(ctx, object.Key); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", object.Key, err)
+			}
+
+			s.logger.Ctx(ctx).Info("removed orphaned object",
+				zap.String("key", object.Key),
+				zap.Int64("size", object.Size),
+				zap.Time("last_modified", object.LastModified),
+			)
+		}
+
+		report.Orphans = append(report.Orphans, ports.OrphanObject{
+			Key:          object.Key,
+			Size:         object.Size,
+			LastModified: object.LastModified,
+		})
+		report.Bytes += object.Size
+	}
+
+	return nil
+}
+
+// forgetThumbnail takes a removed thumbnail off the usage of its tenant and the cached lookup of its image.
+func (s *MaintenanceService) forgetThumbnail(ctx context.Context, thumbnail domain.Thumbnail) error {
+	image, err := s.imageRepository.WithContext(ctx).FindByID(thumbnail.ImageID.String())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find image %s: %w", thumbnail.ImageID, err)
+	}
+
+	if s.cache != nil {
+		s.cache.Invalidate(ctx, image.TenantID, image.ID)
+	}
+
+	if err := s.usageRepository.WithContext(ctx).Add(image.TenantID, -thumbnail.Bytes, 0); err != nil {
+		return fmt.Errorf("failed to update usage of tenant %s: %w", image.TenantID, err)
+	}
+	return nil
+}
+
+func (s *MaintenanceService) collectDisabledPresetThumbnails(ctx context.Context, disabled []string, dryRun bool, report *ports.GCReport) error {
+	afterID := ""
+
+	for {
+		thumbnails, err := s.thumbnailRepository.FindBySizes(disabled, afterID, maintenanceBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list thumbnails of disabled presets: %w", err)
+		}
+		if len(thumbnails) == 0 {
+			return nil
+		}
+
+		for _, thumbnail := range thumbnails {
+			report.DisabledPresetThumbnails = append(report.DisabledPresetThumbnails, thumbnail.Key)
+			if dryRun {
+				continue
+			}
+
+			if err := s.minio.RemoveObject(ctx, thumbnail.Key); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", thumbnail.Key, err)
+			}
+			if err := s.thumbnailRepository.Delete(thumbnail.ID); err != nil {
+				return fmt.Errorf("failed to delete thumbnail record %s: %w", thumbnail.ID, err)
+			}
+			if err := s.forgetThumbnail(ctx, thumbnail); err != nil {
+				return err
+			}
+
+			s.logger.Ctx(ctx).Info("removed thumbnail of disabled preset",
+				zap.String("image_id", thumbnail.ImageID.String()),
+				zap.String("preset", thumbnail.Size),
+				zap.String("key", thumbnail.Key),
+			)
+		}
+
+		afterID = thumbnails[len(thumbnails)-1].ID
+	}
+}
@@ -0,0 +1,136 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/logging"
+	"regexp"
+)
+
+// presetLabelPattern keeps labels safe to embed in storage keys.
+var presetLabelPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,50}$`)
+
+type PresetService struct {
+	presetRepository ports.PresetRepository
+	logger           *logging.Logger
+}
+
+func NewPresetService(presetRepo ports.PresetRepository, logger *logging.Logger) ports.PresetUseCase {
+	return &PresetService{presetRepository: presetRepo, logger: logger}
+}
+
+func (s *PresetService) List(ctx context.Context) ([]domain.ThumbnailPreset, error) {
+	presets, err := s.presetRepository.WithContext(ctx).FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list presets: %w", err)
+	}
+
+	return presets, nil
+}
+
+func (s *PresetService) Add(ctx context.Context, preset *domain.ThumbnailPreset) error {
+	if err := validatePreset(preset); err != nil {
+		return err
+	}
+
+	_, err := s.presetRepository.WithContext(ctx).FindByLabel(preset.Label)
+	if err == nil {
+		return fmt.Errorf("%w: %s", domain.ErrPresetExists, preset.Label)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to find preset: %w", err)
+	}
+
+	preset.Enabled = true
+
+	if err := s.presetRepository.WithContext(ctx).Save(preset); err != nil {
+		return fmt.Errorf("failed to save preset: %w", err)
+	}
+
+	s.logger.Ctx(ctx).Info("preset added", zap.String("preset", preset.Label), zap.Int("width", preset.Width), zap.Int("height", preset.Height))
+
+	return nil
+}
+
+func (s *PresetService) Disable(ctx context.Context, label string) error {
+	preset, err := s.presetRepository.WithContext(ctx).FindByLabel(label)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %s", domain.ErrUnknownPreset, label)
+		}
+		return fmt.Errorf("failed to find preset: %w", err)
+	}
+
+	preset.Enabled = false
+
+	if err := s.presetRepository.WithContext(ctx).Update(preset); err != nil {
+		return fmt.Errorf("failed to update preset: %w", err)
+	}
+
+	s.logger.Ctx(ctx).Info("preset disabled", zap.String("preset", label))
+
+	return nil
+}
+
+func (s *PresetService) Resolve(ctx context.Context, labels []string) ([]domain.ThumbnailSize, error) {
+	presets, err := s.presetRepository.WithContext(ctx).FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list presets: %w", err)
+	}
+
+	enabled := make(map[string]domain.ThumbnailSize, len(presets))
+	sizes := make([]domain.ThumbnailSize, 0, len(presets))
+	for _, preset := range presets {
+		if !preset.Enabled {
+			continue
+		}
+		enabled[preset.Label] = preset.Size()
+		sizes = append(sizes, preset.Size())
+	}
+
+	if len(labels) == 0 {
+		return sizes, nil
+	}
+
+	selected := make([]domain.ThumbnailSize, 0, len(labels))
+	for _, label := range labels {
+		size, ok := enabled[label]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", domain.ErrUnknownPreset, label)
+		}
+		selected = append(selected, size)
+	}
+
+	return selected, nil
+}
+
+func validatePreset(preset *domain.ThumbnailPreset) error {
+	if !presetLabelPattern.MatchString(preset.Label) {
+		return fmt.Errorf("%w: label must be 1-50 letters, digits, '-' or '_'", domain.ErrInvalidPreset)
+	}
+	if preset.Width <= 0 || preset.Height <= 0 {
+		return fmt.Errorf("%w: width and height must be positive", domain.ErrInvalidPreset)
+	}
+	if preset.Type == "" || len(preset.Type) > 20 {
+		return fmt.Errorf("%w: type must be 1-20 characters", domain.ErrInvalidPreset)
+	}
+
+	switch preset.Encoding.Mode {
+	case domain.EncodingAuto, domain.EncodingLossy, domain.EncodingLossless, domain.EncodingNearLossless:
+	default:
+		return fmt.Errorf("%w: unknown encoding mode %q", domain.ErrInvalidPreset, preset.Encoding.Mode)
+	}
+	if preset.Encoding.Quality < 0 || preset.Encoding.Quality > 100 {
+		return fmt.Errorf("%w: quality must be between 0 and 100", domain.ErrInvalidPreset)
+	}
+	if preset.Encoding.NearLosslessLevel < 0 || preset.Encoding.NearLosslessLevel > 100 {
+		return fmt.Errorf("%w: near-lossless level must be between 0 and 100", domain.ErrInvalidPreset)
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/logging"
+	"image-resizing-service/pkg/metrics"
+	"image-resizing-service/pkg/utils"
+	"math"
+	"strconv"
+	"time"
+)
+
+// tokenBucketScript refills the buckets in KEYS by the time elapsed since their last request, using
+// the clock of Redis so replicas agree, and takes one token from every bucket only if each holds one.
+// ARGV holds the capacity and refill period in milliseconds of every key. It returns 1 or 0 for
+// allowed, followed by the tokens left in every bucket.
+var tokenBucketScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local tokens = {}
+local allowed = 1
+for i, key in ipairs(KEYS) do
+	local capacity = tonumber(ARGV[i * 2 - 1])
+	local period = tonumber(ARGV[i * 2])
+	local state = redis.call('HMGET', key, 'tokens', 'at')
+	local available = tonumber(state[1]) or capacity
+	local at = tonumber(state[2]) or now
+	available = math.min(capacity, available + math.max(0, now - at) * capacity / period)
+	tokens[i] = available
+	if available < 1 then
+		allowed = 0
+	end
+end
+
+local result = {allowed}
+for i, key in ipairs(KEYS) do
+	if allowed == 1 then
+		tokens[i] = tokens[i] - 1
+	end
+	redis.call('HSET', key, 'tokens', tostring(tokens[i]), 'at', now)
+	redis.call('PEXPIRE', key, ARGV[i * 2])
+	result[i + 1] = tostring(tokens[i])
+end
+
+return result
+`)
+
+// rateLimitKeyPrefix starts the Redis keys of the buckets.
+const rateLimitKeyPrefix = "ratelimit:"
+
+// RateLimits are the requests allowed per period for one class. A zero limit is disabled.
+type RateLimits struct {
+	PerKey    int
+	PerTenant int
+	PerIP     int
+}
+
+type RateLimitSettings struct {
+	// Period is how long an empty bucket takes to refill; a bucket holds as many tokens as its limit.
+	Period time.Duration
+	// FailOpen lets requests through when Redis cannot be reached, instead of rejecting them.
+	FailOpen bool
+	Limits   map[domain.RateLimitClass]RateLimits
+}
+
+type RateLimitService struct {
+	redis    utils.RedisClient
+	settings RateLimitSettings
+	logger   *logging.Logger
+}
+
+func NewRateLimitService(redis utils.RedisClient, settings RateLimitSettings, logger *logging.Logger) ports.RateLimitUseCase {
+	return &RateLimitService{redis: redis, settings: settings, logger: logger}
+}
+
+type rateLimitBucket struct {
+	limit    string
+	key      string
+	capacity int
+}
+
+func (s *RateLimitService) Allow(ctx context.Context, class domain.RateLimitClass, principal *domain.Principal, clientIP string) (*ports.RateLimit, error) {
+	limits := s.settings.Limits[class]
+
+	var buckets []rateLimitBucket
+	add := func(limit, id string, capacity int) {
+		if capacity > 0 && id != "" {
+			buckets = append(buckets, rateLimitBucket{
+				limit:    limit,
+				key:      fmt.Sprintf("%s%s:%s:%s", rateLimitKeyPrefix, class, limit, id),
+				capacity: capacity,
+			})
+		}
+	}
+	// Requests without credentials have no key of their own; the IP limit covers them. Token issuers pick
+	// subjects freely, so the same subject in two tenants is two keys.
+	if principal.Subject != domain.AnonymousSubject {
+		add("key", principal.TenantID+":"+principal.Subject, limits.PerKey)
+	}
+	add("tenant", principal.TenantID, limits.PerTenant)
+	add("ip", clientIP, limits.PerIP)
+
+	if len(buckets) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(buckets))
+	args := make([]interface{}, 0, 2*len(buckets))
+	for i, bucket := range buckets {
+		keys[i] = bucket.key
+		args = append(args, bucket.capacity, s.settings.Period.Milliseconds())
+	}
+
+	result, err := s.redis.RunScript(ctx, tokenBucketScript, keys, args...)
+	if err == nil {
+		allowed, tokens, parseErr := parseBucketTokens(result, len(buckets))
+		if parseErr == nil {
+			return s.decide(class, buckets, allowed, tokens), nil
+		}
+		err = parseErr
+	}
+
+	if s.settings.FailOpen {
+		s.logger.Ctx(ctx).Warn("rate limits are not enforced, letting the request through", zap.Error(err))
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("failed to check rate limits: %w", err)
+}
+
+// decide reports the bucket that limits the request most: when it is rejected, the one that takes
+// longest to hold a token again; otherwise the one with the fewest tokens left.
+func (s *RateLimitService) decide(class domain.RateLimitClass, buckets []rateLimitBucket, allowed bool, tokens []float64) *ports.RateLimit {
+	// refillTime is how long the bucket takes to gain the given number of tokens.
+	refillTime := func(bucket rateLimitBucket, missing float64) time.Duration {
+		return time.Duration(math.Ceil(missing * float64(s.settings.Period) / float64(bucket.capacity)))
+	}
+
+	chosen := -1
+	var retryAfter time.Duration
+	for i, bucket := range buckets {
+		if allowed {
+			if chosen < 0 || tokens[i]/float64(bucket.capacity) < tokens[chosen]/float64(buckets[chosen].capacity) {
+				chosen = i
+			}
+			continue
+		}
+		if wait := refillTime(bucket, 1-tokens[i]); tokens[i] < 1 && wait > retryAfter {
+			chosen, retryAfter = i, wait
+		}
+	}
+
+	bucket := buckets[chosen]
+	rateLimit := &ports.RateLimit{
+		Allowed:    allowed,
+		Limit:      bucket.capacity,
+		Remaining:  max(0, int(tokens[chosen])),
+		Reset:      refillTime(bucket, float64(bucket.capacity)-tokens[chosen]),
+		RetryAfter: retryAfter,
+	}
+	if !allowed {
+		rateLimit.Exhausted = bucket.limit
+		metrics.RateLimited.WithLabelValues(string(class), bucket.limit).Inc()
+	}
+
+	return rateLimit
+}
+
+func parseBucketTokens(result interface{}, buckets int) (bool, []float64, error) {
+	values, ok := result.([]interface{})
+	if !ok || len(values) != buckets+1 {
+		return false, nil, fmt.Errorf("unexpected rate limit script result %v", result)
+	}
+
+	tokens := make([]float64, buckets)
+	for i := range tokens {
+		text, _ := values[i+1].(string)
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return false, nil, fmt.Errorf("unexpected rate limit script result %v", result)
+		}
+		tokens[i] = value
+	}
+
+	return values[0] == int64(1), tokens, nil
+}
@@ -0,0 +1,174 @@
+package app
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/logging"
+	"image-resizing-service/pkg/utils"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRateLimitServiceDecide(t *testing.T) {
+	key := rateLimitBucket{limit: "key", key: "ratelimit:upload:key:k", capacity: 10}
+	tenant := rateLimitBucket{limit: "tenant", key: "ratelimit:upload:tenant:t", capacity: 100}
+	ip := rateLimitBucket{limit: "ip", key: "ratelimit:upload:ip:i", capacity: 2}
+
+	tests := []struct {
+		name    string
+		buckets []rateLimitBucket
+		allowed bool
+		tokens  []float64
+		want    ports.RateLimit
+	}{
+		{
+			name:    "allowed by a single bucket",
+			buckets: []rateLimitBucket{key},
+			allowed: true,
+			tokens:  []float64{9},
+			want:    ports.RateLimit{Allowed: true, Limit: 10, Remaining: 9, Reset: 6 * time.Second},
+		},
+		{
+			name:    "allowed reports the bucket with the smallest share left",
+			buckets: []rateLimitBucket{key, tenant},
+			allowed: true,
+			tokens:  []float64{5, 20},
+			want:    ports.RateLimit{Allowed: true, Limit: 100, Remaining: 20, Reset: 48 * time.Second},
+		},
+		{
+			name:    "allowed rounds remaining tokens down",
+			buckets: []rateLimitBucket{key},
+			allowed: true,
+			tokens:  []float64{2.5},
+			want:    ports.RateLimit{Allowed: true, Limit: 10, Remaining: 2, Reset: 45 * time.Second},
+		},
+		{
+			name:    "rejected by one exhausted bucket",
+			buckets: []rateLimitBucket{key, tenant},
+			allowed: false,
+			tokens:  []float64{0.5, 50},
+			want:    ports.RateLimit{Allowed: false, Limit: 10, Remaining: 0, Reset: 57 * time.Second, RetryAfter: 3 * time.Second, Exhausted: "key"},
+		},
+		{
+			name:    "rejected reports the bucket that refills last",
+			buckets: []rateLimitBucket{key, tenant, ip},
+			allowed: false,
+			tokens:  []float64{0.9, 0.1, 0},
+			want:    ports.RateLimit{Allowed: false, Limit: 2, Remaining: 0, Reset: time.Minute, RetryAfter: 30 * time.Second, Exhausted: "ip"},
+		},
+		{
+			name:    "rejected ignores buckets that still hold a token",
+			buckets: []rateLimitBucket{ip, key},
+			allowed: false,
+			tokens:  []float64{1, 0.75},
+			want:    ports.RateLimit{Allowed: false, Limit: 10, Remaining: 0, Reset: 55500 * time.Millisecond, RetryAfter: 1500 * time.Millisecond, Exhausted: "key"},
+		},
+	}
+
+	service := &RateLimitService{settings: RateLimitSettings{Period: time.Minute}, logger: logging.New(zap.NewNop())}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := service.decide(domain.RateLimitUpload, tt.buckets, tt.allowed, tt.tokens)
+			if *got != tt.want {
+				t.Errorf("decide = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeBuckets allows every request and records the keys of the buckets it was asked to take from.
+type fakeBuckets struct {
+	utils.RedisClient
+	keys []string
+}
+
+func (f *fakeBuckets) RunScript(_ context.Context, _ *redis.Script, keys []string, _ ...interface{}) (interface{}, error) {
+	f.keys = keys
+	result := []interface{}{int64(1)}
+	for range keys {
+		result = append(result, "1")
+	}
+	return result, nil
+}
+
+func TestRateLimitServiceAllowBucketKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal domain.Principal
+		want      []string
+	}{
+		{
+			name:      "key buckets are per tenant",
+			principal: domain.Principal{Subject: "ci", TenantID: "tenant-a"},
+			want:      []string{"ratelimit:upload:key:tenant-a:ci", "ratelimit:upload:tenant:tenant-a", "ratelimit:upload:ip:192.0.2.1"},
+		},
+		{
+			name:      "same subject in another tenant",
+			principal: domain.Principal{Subject: "ci", TenantID: "tenant-b"},
+			want:      []string{"ratelimit:upload:key:tenant-b:ci", "ratelimit:upload:tenant:tenant-b", "ratelimit:upload:ip:192.0.2.1"},
+		},
+		{
+			name:      "anonymous requests have no key bucket",
+			principal: domain.Principal{Subject: domain.AnonymousSubject, TenantID: "tenant-a"},
+			want:      []string{"ratelimit:upload:tenant:tenant-a", "ratelimit:upload:ip:192.0.2.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buckets := &fakeBuckets{}
+			service := NewRateLimitService(buckets, RateLimitSettings{
+				Period: time.Minute,
+				Limits: map[domain.RateLimitClass]RateLimits{domain.RateLimitUpload: {PerKey: 10, PerTenant: 100, PerIP: 20}},
+			}, logging.New(zap.NewNop()))
+
+			if _, err := service.Allow(context.Background(), domain.RateLimitUpload, &tt.principal, "192.0.2.1"); err != nil {
+				t.Fatalf("Allow: %v", err)
+			}
+			if !slices.Equal(buckets.keys, tt.want) {
+				t.Errorf("bucket keys = %v, want %v", buckets.keys, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBucketTokens(t *testing.T) {
+	tests := []struct {
+		name        string
+		result      interface{}
+		buckets     int
+		wantAllowed bool
+		wantTokens  []float64
+		wantErr     bool
+	}{
+		{name: "allowed", result: []interface{}{int64(1), "9", "0.5"}, buckets: 2, wantAllowed: true, wantTokens: []float64{9, 0.5}},
+		{name: "rejected", result: []interface{}{int64(0), "0.25"}, buckets: 1, wantAllowed: false, wantTokens: []float64{0.25}},
+		{name: "fewer tokens than buckets", result: []interface{}{int64(1), "9"}, buckets: 2, wantErr: true},
+		{name: "more tokens than buckets", result: []interface{}{int64(1), "9", "8"}, buckets: 1, wantErr: true},
+		{name: "not a list", result: "OK", buckets: 1, wantErr: true},
+		{name: "token count is not a string", result: []interface{}{int64(1), int64(9)}, buckets: 1, wantErr: true},
+		{name: "token count is not a number", result: []interface{}{int64(1), "nine"}, buckets: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, tokens, err := parseBucketTokens(tt.result, tt.buckets)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseBucketTokens succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseBucketTokens: %v", err)
+			}
+			if allowed != tt.wantAllowed || !slices.Equal(tokens, tt.wantTokens) {
+				t.Errorf("parseBucketTokens = %v, %v, want %v, %v", allowed, tokens, tt.wantAllowed, tt.wantTokens)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/logging"
+	"image-resizing-service/pkg/metrics"
+	"sync/atomic"
+	"time"
+)
+
+const reaperBatchSize = 100
+
+// ReaperSettings controls when an image counts as stuck and how often it is retried.
+type ReaperSettings struct {
+	// Timeout is how long an image may stay pending or processing without being updated.
+	Timeout time.Duration
+	// MaxAttempts caps how often a stuck image is re-enqueued before it is marked as failed.
+	MaxAttempts int
+}
+
+// ReaperService recovers images left in pending or processing by a crash or a panic.
+type ReaperService struct {
+	imageRepository ports.ImageRepository
+	imageUseCase    ports.ImageUseCase
+	reprocess       ports.ReprocessUseCase
+	// cache is nil when caching is disabled.
+	cache    ports.ImageCache
+	settings ReaperSettings
+	logger   *logging.Logger
+	requeued atomic.Int64
+	timedOut atomic.Int64
+}
+
+func NewReaperService(
+	imageRepo ports.ImageRepository,
+	imageUseCase ports.ImageUseCase,
+	reprocess ports.ReprocessUseCase,
+	cache ports.ImageCache,
+	settings ReaperSettings,
+	logger *logging.Logger,
+) ports.ReaperUseCase {
+	return &ReaperService{
+		imageRepository: imageRepo,
+		imageUseCase:    imageUseCase,
+		reprocess:       reprocess,
+		cache:           cache,
+		settings:        settings,
+		logger:          logger,
+	}
+}
+
+func (s *ReaperService) Reap(ctx context.Context) (*ports.ReapReport, error) {
+	before := time.Now().Add(-s.settings.Timeout)
+
+	imageRepo := s.imageRepository.WithContext(ctx)
+
+	stale, err := imageRepo.FindStale(before, reaperBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stuck images: %w", err)
+	}
+
+	report := &ports.ReapReport{}
+	for _, image := range stale {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		ctx := logging.With(ctx, zap.String("image_id", image.ID))
+		logger := s.logger.Ctx(ctx).With(
+			zap.String("status", string(image.Status)),
+			zap.Time("updated_at", image.UpdatedAt),
+			zap.Int("attempts", image.Attempts),
+		)
+
+		if image.Attempts >= s.settings.MaxAttempts {
+			message := fmt.Sprintf("processing timed out: stuck in %s for more than %s after %d attempts", image.Status, s.settings.Timeout, image.Attempts+1)
+
+			failed, err := imageRepo.FailStale(image.ID, before, message)
+			if err != nil {
+				return report, fmt.Errorf("failed to mark image %s as timed out: %w", image.ID, err)
+			}
+			if failed {
+				report.TimedOut++
+				metrics.ReaperImages.WithLabelValues("timed_out").Inc()
+				recordTransition(image.Status, domain.StatusError)
+				if s.cache != nil {
+					s.cache.Invalidate(ctx, image.TenantID, image.ID)
+				}
+				logger.Warn("stuck image marked as failed", zap.Int64("timed_out_total", s.timedOut.Add(1)))
+			}
+			continue
+		}
+
+		claimed, err := imageRepo.ClaimStale(image.ID, before)
+		if err != nil {
+			return report, fmt.Errorf("failed to claim stuck image %s: %w", image.ID, err)
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := s.imageUseCase.Requeue(ctx, image.ID); err != nil {
+			// Nothing was dispatched, so the claim must not count as an attempt.
+			if releaseErr := imageRepo.ReleaseClaim(image.ID); releaseErr != nil {
+				logger.Error("failed to release claim on stuck image", zap.Error(releaseErr))
+			}
+			if errors.Is(err, domain.ErrProcessingInProgress) {
+				logger.Warn("stuck image is still being processed by this instance")
+				continue
+			}
+			return report, fmt.Errorf("failed to requeue image %s: %w", image.ID, err)
+		}
+
+		report.Requeued++
+		metrics.ReaperImages.WithLabelValues("requeued").Inc()
+		logger.Warn("stuck image re-enqueued", zap.Int64("requeued_total", s.requeued.Add(1)))
+	}
+
+	resumed, err := s.reprocess.ResumeStale(ctx, before)
+	report.ResumedJobs = resumed
+	if err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
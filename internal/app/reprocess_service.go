@@ -0,0 +1,284 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/logging"
+	"image-resizing-service/pkg/tracing"
+	"sync"
+	"time"
+)
+
+// ReprocessThrottle paces bulk jobs so they never compete with uploads for more than a trickle of work.
+// Images of a job are processed one at a time, at most one every Interval.
+type ReprocessThrottle struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+type ReprocessService struct {
+	jobRepository   ports.ReprocessJobRepository
+	imageRepository ports.ImageRepository
+	imageUseCase    ports.ImageUseCase
+	presets         ports.PresetUseCase
+	throttle        ReprocessThrottle
+	logger          *logging.Logger
+
+	// ctx is cancelled by Drain to stop running jobs.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+}
+
+func NewReprocessService(
+	jobRepo ports.ReprocessJobRepository,
+	imageRepo ports.ImageRepository,
+	imageUseCase ports.ImageUseCase,
+	presets ports.PresetUseCase,
+	throttle ReprocessThrottle,
+	logger *logging.Logger,
+) ports.ReprocessUseCase {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &ReprocessService{
+		ctx:             ctx,
+		cancel:          cancel,
+		jobRepository:   jobRepo,
+		imageRepository: imageRepo,
+		imageUseCase:    imageUseCase,
+		presets:         presets,
+		throttle:        throttle,
+		logger:          logger,
+	}
+}
+
+func (s *ReprocessService) StartBulk(ctx context.Context, filter domain.ReprocessFilter) (*domain.ReprocessJob, error) {
+	if _, err := s.presets.Resolve(ctx, filter.Presets); err != nil {
+		return nil, err
+	}
+
+	total, err := s.imageRepository.WithContext(ctx).CountByFilter(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count images: %w", err)
+	}
+
+	job := &domain.ReprocessJob{
+		ID:     uuid.New().String(),
+		Status: domain.JobPending,
+		Filter: filter,
+		Total:  int(total),
+	}
+	if tenantID, ok := domain.TenantFromContext(ctx); ok {
+		job.TenantID = &tenantID
+	}
+
+	if err := s.jobRepository.WithContext(ctx).Save(job); err != nil {
+		return nil, fmt.Errorf("failed to save reprocess job: %w", err)
+	}
+
+	s.launch(ctx, *job)
+
+	return job, nil
+}
+
+func (s *ReprocessService) ResumeStale(ctx context.Context, before time.Time) (int, error) {
+	// Jobs stopped by Drain stay pending for the next instance.
+	if s.ctx.Err() != nil {
+		return 0, nil
+	}
+
+	jobRepo := s.jobRepository.WithContext(ctx)
+
+	stale, err := jobRepo.FindStale(before, reaperBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find interrupted reprocess jobs: %w", err)
+	}
+
+	resumed := 0
+	for _, job := range stale {
+		claimed, err := jobRepo.ClaimStale(job.ID, before)
+		if err != nil {
+			return resumed, fmt.Errorf("failed to claim reprocess job %s: %w", job.ID, err)
+		}
+		if !claimed {
+			continue
+		}
+
+		s.logger.Ctx(ctx).Warn("resuming interrupted reprocess job",
+			zap.String("job_id", job.ID),
+			zap.String("status", string(job.Status)),
+			zap.Time("updated_at", job.UpdatedAt),
+			zap.String("cursor", job.Cursor),
+		)
+		s.launch(ctx, job)
+		resumed++
+	}
+
+	return resumed, nil
+}
+
+// launch runs job in the background until it finishes or Drain stops it.
+func (s *ReprocessService) launch(origin context.Context, job domain.ReprocessJob) {
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		s.run(s.ctx, origin, job)
+	}()
+}
+
+// Drain stops running jobs and waits until they have recorded their progress or ctx expires.
+func (s *ReprocessService) Drain(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("reprocess jobs did not stop before the deadline: %w", ctx.Err())
+	}
+}
+
+func (s *ReprocessService) FindJob(ctx context.Context, id string) (*domain.ReprocessJob, error) {
+	job, err := s.jobRepository.WithContext(ctx).FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrJobNotFound
+		}
+		return nil, fmt.Errorf("failed to find reprocess job: %w", err)
+	}
+
+	return job, nil
+}
+
+// run processes the images of job under ctx. Each image gets a trace of its own, linked to the
+// request in origin that started the job.
+func (s *ReprocessService) run(ctx context.Context, origin context.Context, job domain.ReprocessJob) {
+	ctx = logging.With(logging.Inherit(ctx, origin), zap.String("job_id", job.ID))
+	if job.TenantID != nil {
+		ctx = domain.WithTenant(ctx, *job.TenantID)
+	}
+	logger := s.logger.Ctx(ctx)
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("panic in reprocess job", zap.Any("recover", r))
+			s.finish(ctx, &job, fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	if job.StartedAt == nil {
+		startedAt := time.Now()
+		job.StartedAt = &startedAt
+	}
+	job.Status = domain.JobRunning
+	s.saveProgress(ctx, &job)
+
+	logger.Info("reprocess job started", zap.Int("total", job.Total), zap.String("cursor", job.Cursor))
+
+	ticker := time.NewTicker(max(s.throttle.Interval, time.Millisecond))
+	defer ticker.Stop()
+
+	options := ports.ProcessOptions{Presets: job.Filter.Presets, Force: true}
+	batchSize := max(s.throttle.BatchSize, 1)
+
+	for {
+		ids, err := s.imageRepository.WithContext(ctx).FindIDsByFilter(job.Filter, job.Cursor, batchSize)
+		if err != nil {
+			s.finish(ctx, &job, fmt.Errorf("failed to list images: %w", err))
+			return
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		for _, id := range ids {
+			select {
+			case <-ctx.Done():
+				s.interrupt(ctx, &job)
+				return
+			case <-ticker.C:
+			}
+
+			imageCtx, span := tracing.StartLinked(ctx, origin, "ReprocessService.process",
+				attribute.String("job.id", job.ID),
+				attribute.String("image.id", id),
+			)
+			err := s.imageUseCase.ProcessImage(imageCtx, id, options)
+			tracing.End(span, err)
+			if ctx.Err() != nil {
+				// The image was cut short and goes back to pending, so the resumed job starts with it again.
+				s.interrupt(ctx, &job)
+				return
+			}
+
+			switch {
+			case err == nil:
+				job.Processed++
+			case errors.Is(err, domain.ErrProcessingInProgress), errors.Is(err, domain.ErrImageNotFound):
+				job.Skipped++
+			default:
+				job.Failed++
+				logger.Warn("failed to reprocess image", zap.String("image_id", id), zap.Error(err))
+			}
+
+			job.Cursor = id
+			s.saveProgress(ctx, &job)
+		}
+	}
+
+	s.finish(ctx, &job, nil)
+}
+
+func (s *ReprocessService) finish(ctx context.Context, job *domain.ReprocessJob, cause error) {
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	job.Status = domain.JobCompleted
+
+	if cause != nil {
+		message := cause.Error()
+		job.Status = domain.JobFailed
+		job.ErrorMessage = &message
+	}
+
+	s.saveProgress(ctx, job)
+
+	s.logger.Ctx(ctx).Info("reprocess job finished",
+		zap.String("status", string(job.Status)),
+		zap.Int("processed", job.Processed),
+		zap.Int("failed", job.Failed),
+		zap.Int("skipped", job.Skipped),
+	)
+}
+
+// interrupt returns job to pending when Drain stops it, so the reaper resumes it after its cursor.
+func (s *ReprocessService) interrupt(ctx context.Context, job *domain.ReprocessJob) {
+	job.Status = domain.JobPending
+	s.saveProgress(ctx, job)
+
+	s.logger.Ctx(ctx).Info("reprocess job interrupted",
+		zap.String("cursor", job.Cursor),
+		zap.Int("processed", job.Processed),
+		zap.Int("failed", job.Failed),
+		zap.Int("skipped", job.Skipped),
+	)
+}
+
+// saveProgress records job. Progress is saved even once ctx is cancelled by shutdown.
+func (s *ReprocessService) saveProgress(ctx context.Context, job *domain.ReprocessJob) {
+	if err := s.jobRepository.WithContext(context.WithoutCancel(ctx)).Update(job); err != nil {
+		s.logger.Ctx(ctx).Error("failed to save reprocess job progress", zap.Error(err))
+	}
+}
@@ -1,24 +1,41 @@
 package app
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"github.com/chai2010/webp"
 	"github.com/disintegration/imaging"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
 	"gorm.io/gorm"
 	"image"
 	"image-resizing-service/internal/domain"
 	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/logging"
+	"image-resizing-service/pkg/tracing"
 	"image-resizing-service/pkg/utils"
+	"math"
+	"time"
 )
 
+// ResizeConcurrency bounds thumbnail generation. PerImage is the number of presets of one image
+// processed at the same time; CPUSlots is shared by all in-flight images and caps concurrent
+// resize and encode work across the whole process.
+type ResizeConcurrency struct {
+	PerImage int
+	CPUSlots *semaphore.Weighted
+}
+
 type ResizeService struct {
 	db                  *gorm.DB
 	minio               *utils.MinioClient
 	thumbnailRepository ports.ThumbnailRepository
 	imageRepository     ports.ImageRepository
+	usageRepository     ports.UsageRepository
+	concurrency         ResizeConcurrency
+	logger              *logging.Logger
 }
 
 func NewResizeService(
@@ -26,76 +43,265 @@ func NewResizeService(
 	minio *utils.MinioClient,
 	thumbnailRepo ports.ThumbnailRepository,
 	imageRepo ports.ImageRepository,
+	usageRepo ports.UsageRepository,
+	concurrency ResizeConcurrency,
+	logger *logging.Logger,
 ) ports.ResizeUseCase {
 	return &ResizeService{
 		db:                  db,
 		minio:               minio,
 		thumbnailRepository: thumbnailRepo,
 		imageRepository:     imageRepo,
+		usageRepository:     usageRepo,
+		concurrency:         concurrency,
+		logger:              logger,
 	}
 }
 
-func (s *ResizeService) ResizeThumbnails(ctx context.Context, imageID uuid.UUID, originalKey string) error {
-	originalBytes, err := s.minio.GetFileAsBytes(ctx, originalKey)
+func (s *ResizeService) PendingPresets(ctx context.Context, imageID uuid.UUID, presets []domain.ThumbnailSize) ([]domain.ThumbnailSize, error) {
+	thumbnails, err := s.thumbnailRepository.WithContext(ctx).FindByImageID(imageID)
 	if err != nil {
-		return fmt.Errorf("failed to download original: %w", err)
+		return nil, err
 	}
 
-	img, _, err := image.Decode(bytes.NewReader(originalBytes))
-	if err != nil {
-		return fmt.Errorf("failed to decode original image: %w", err)
+	stored := make(map[string]domain.Thumbnail, len(thumbnails))
+	for _, thumbnail := range thumbnails {
+		stored[thumbnail.Size] = thumbnail
 	}
 
-	for _, size := range domain.ThumbnailSizes {
-		if err := s.generateAndSaveThumbnail(ctx, imageID, img, size); err != nil {
-			return fmt.Errorf("failed to process thumbnail %s: %w", size.Label, err)
+	var pending []domain.ThumbnailSize
+	for _, size := range presets {
+		thumbnail, ok := stored[size.Label]
+		if ok && thumbnail.Status == domain.RenditionReady {
+			exists, err := s.minio.ObjectExists(ctx, thumbnail.Key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to verify thumbnail %s: %w", size.Label, err)
+			}
+			if exists {
+				continue
+			}
 		}
+
+		pending = append(pending, size)
 	}
 
-	imageEntity, err := s.imageRepository.FindByID(imageID.String())
+	return pending, nil
+}
+
+// ResizeThumbnails renders the given presets. A failing preset does not cancel the others, so
+// every preset that can be rendered is stored and a retry only has the failed ones left to do.
+func (s *ResizeService) ResizeThumbnails(ctx context.Context, tenantID string, imageID uuid.UUID, source *utils.SourceImage, presets []domain.ThumbnailSize) (err error) {
+	ctx, span := tracing.Start(ctx, "ResizeService.ResizeThumbnails",
+		attribute.String("image.id", imageID.String()),
+		attribute.Int("thumbnail.presets", len(presets)),
+	)
+	defer func() { tracing.End(span, err) }()
+	ctx = logging.With(ctx, zap.String("image_id", imageID.String()), zap.String("stage", "thumbnails"))
+
+	startedAt := time.Now()
+
+	levels, err := s.buildPyramid(ctx, source.Image, presets)
 	if err != nil {
-		return fmt.Errorf("failed to find image entity: %w", err)
+		return fmt.Errorf("failed to prepare resize sources: %w", err)
 	}
 
-	imageEntity.Status = domain.StatusReady
+	var group errgroup.Group
+	group.SetLimit(max(s.concurrency.PerImage, 1))
 
-	if err := s.imageRepository.Update(imageEntity); err != nil {
-		return fmt.Errorf("failed to update image status: %w", err)
+	for _, size := range presets {
+		group.Go(func() error {
+			ctx, span := tracing.Start(ctx, "thumbnail "+size.Label, attribute.String("preset", size.Label))
+			ctx = logging.With(ctx, zap.String("preset", size.Label))
+			img := pickResizeSource(levels, size)
+			err := s.generateAndSaveThumbnail(ctx, tenantID, imageID, img, source.ICCProfile, size)
+			if err != nil {
+				// Only the first failure is returned, so each one is logged here.
+				s.logger.Ctx(ctx).Warn("failed to generate thumbnail", zap.Error(err))
+				countError(err, "thumbnail")
+				s.markThumbnailFailed(ctx, tenantID, imageID, size, err)
+				err = fmt.Errorf("failed to process thumbnail %s: %w", size.Label, err)
+			}
+			tracing.End(span, err)
+			return err
+		})
 	}
 
+	if err := group.Wait(); err != nil {
+		return err
+	}
+
+	s.logger.Ctx(ctx).Info("thumbnails generated",
+		zap.Int("presets", len(presets)),
+		zap.Duration("duration", time.Since(startedAt)),
+	)
+
 	return nil
 }
 
-func (s *ResizeService) generateAndSaveThumbnail(ctx context.Context, imageID uuid.UUID, img image.Image, size domain.ThumbnailSize) error {
-	thumb := imaging.Resize(img, size.Width, size.Height, imaging.Lanczos)
-	
-	var buf bytes.Buffer
-	options := &webp.Options{Quality: 80}
-	if err := webp.Encode(&buf, thumb, options); err != nil {
-		return fmt.Errorf("failed to encode thumbnail to webp: %w", err)
+func (s *ResizeService) generateAndSaveThumbnail(ctx context.Context, tenantID string, imageID uuid.UUID, img image.Image, iccProfile []byte, size domain.ThumbnailSize) error {
+	startedAt := time.Now()
+
+	encoded, err := s.renderThumbnail(ctx, img, iccProfile, size)
+	if err != nil {
+		return err
 	}
+	renderedAt := time.Now()
 
-	thumbKey := fmt.Sprintf("uploads/thumbnails/%s_%s.webp", imageID, size.Label)
+	thumbKey := domain.ThumbnailKey(tenantID, imageID.String(), size.Label)
 
-	if err := s.minio.UploadBytes(ctx, thumbKey, buf.Bytes(), "image/webp"); err != nil {
+	uploadCtx, endUpload := startStage(ctx, "upload", size.Label)
+	err = s.minio.UploadBytes(uploadCtx, thumbKey, encoded, "image/webp")
+	endUpload(err)
+	if err != nil {
 		return fmt.Errorf("failed to upload thumbnail to storage: %w", err)
 	}
 
 	thumbnail := &domain.Thumbnail{
-		ID:      uuid.New().String(),
-		ImageID: imageID,
-		Size:    size.Label,
-		Key:     thumbKey,
-		Type:    string(size.Type),
+		ID:           uuid.New().String(),
+		ImageID:      imageID,
+		Size:         size.Label,
+		Key:          thumbKey,
+		Type:         string(size.Type),
+		Status:       domain.RenditionReady,
+		ProcessingMs: time.Since(startedAt).Milliseconds(),
+		Bytes:        int64(len(encoded)),
 	}
 
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		thumbRepo := s.thumbnailRepository.WithTx(tx)
-		return thumbRepo.Save(thumbnail)
+		// The new object replaced the one stored for the preset before, if any.
+		previousBytes, err := thumbRepo.StoredBytes(imageID, size.Label)
+		if err != nil {
+			return err
+		}
+		if err := thumbRepo.Upsert(thumbnail); err != nil {
+			return err
+		}
+		// Every stored thumbnail is a heartbeat that keeps the reaper away from an image still in progress.
+		if err := s.imageRepository.WithTx(tx).Touch(imageID.String()); err != nil {
+			return err
+		}
+		return s.usageRepository.WithTx(tx).Add(tenantID, thumbnail.Bytes-previousBytes, 0)
 	})
 	if err != nil {
 		return fmt.Errorf("failed to save thumbnail record: %w", err)
 	}
 
+	s.logger.Ctx(ctx).Debug("thumbnail generated",
+		zap.Duration("render", renderedAt.Sub(startedAt)),
+		zap.Duration("upload", time.Since(renderedAt)),
+	)
+
 	return nil
 }
+
+func (s *ResizeService) markThumbnailFailed(ctx context.Context, tenantID string, imageID uuid.UUID, size domain.ThumbnailSize, cause error) {
+	message := cause.Error()
+	thumbnail := &domain.Thumbnail{
+		ImageID:      imageID,
+		Size:         size.Label,
+		Key:          domain.ThumbnailKey(tenantID, imageID.String(), size.Label),
+		Type:         string(size.Type),
+		Status:       domain.RenditionFailed,
+		ErrorMessage: &message,
+	}
+
+	// Cancellation is one of the failures recorded here.
+	if err := s.thumbnailRepository.WithContext(context.WithoutCancel(ctx)).Upsert(thumbnail); err != nil {
+		s.logger.Ctx(ctx).Error("failed to record thumbnail failure", zap.Error(err))
+	}
+}
+
+// buildPyramid halves the source repeatedly while every level stays at least twice the size of
+// the smallest preset. Presets are then resized from the smallest level that is still at least
+// twice their size, so large-to-small sizes cascade instead of all resampling the full original.
+func (s *ResizeService) buildPyramid(ctx context.Context, img image.Image, sizes []domain.ThumbnailSize) (_ []image.Image, err error) {
+	ctx, span := tracing.Start(ctx, "ResizeService.buildPyramid")
+	defer func() { tracing.End(span, err) }()
+
+	minWidth, minHeight := math.MaxInt, math.MaxInt
+	for _, size := range sizes {
+		minWidth = min(minWidth, size.Width)
+		minHeight = min(minHeight, size.Height)
+	}
+
+	levels := []image.Image{img}
+	for {
+		last := levels[len(levels)-1].Bounds()
+		width, height := last.Dx()/2, last.Dy()/2
+		if width < 2*minWidth || height < 2*minHeight {
+			span.SetAttributes(attribute.Int("pyramid.levels", len(levels)))
+			return levels, nil
+		}
+
+		if err := s.acquireCPU(ctx); err != nil {
+			return nil, err
+		}
+		levels = append(levels, imaging.Resize(levels[len(levels)-1], width, height, imaging.Box))
+		s.releaseCPU()
+	}
+}
+
+func pickResizeSource(levels []image.Image, size domain.ThumbnailSize) image.Image {
+	source := levels[0]
+	for _, level := range levels[1:] {
+		bounds := level.Bounds()
+		if bounds.Dx() < 2*size.Width || bounds.Dy() < 2*size.Height {
+			break
+		}
+		source = level
+	}
+
+	return source
+}
+
+func (s *ResizeService) acquireCPU(ctx context.Context) error {
+	if s.concurrency.CPUSlots == nil {
+		return nil
+	}
+
+	return s.concurrency.CPUSlots.Acquire(ctx, 1)
+}
+
+func (s *ResizeService) releaseCPU() {
+	if s.concurrency.CPUSlots != nil {
+		s.concurrency.CPUSlots.Release(1)
+	}
+}
+
+func (s *ResizeService) EncodeCompressed(ctx context.Context, source *utils.SourceImage, encoding domain.EncodingSettings) ([]byte, error) {
+	if err := s.acquireCPU(ctx); err != nil {
+		return nil, err
+	}
+	defer s.releaseCPU()
+
+	_, endStage := startStage(ctx, "encode", "compressed")
+	encoded, err := utils.EncodeWebp(source.Image, source.ICCProfile, webpOptions(encoding))
+	endStage(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert to webp: %w", err)
+	}
+
+	return encoded, nil
+}
+
+// renderThumbnail resizes and encodes one preset while holding a global CPU slot.
+func (s *ResizeService) renderThumbnail(ctx context.Context, img image.Image, iccProfile []byte, size domain.ThumbnailSize) ([]byte, error) {
+	if err := s.acquireCPU(ctx); err != nil {
+		return nil, err
+	}
+	defer s.releaseCPU()
+
+	_, endStage := startStage(ctx, "resize", size.Label)
+	thumb := imaging.Resize(img, size.Width, size.Height, imaging.Lanczos)
+	endStage(nil)
+
+	_, endStage = startStage(ctx, "encode", size.Label)
+	encoded, err := utils.EncodeWebp(thumb, iccProfile, webpOptions(size.Encoding))
+	endStage(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail to webp: %w", err)
+	}
+
+	return encoded, nil
+}
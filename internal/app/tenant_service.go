@@ -0,0 +1,169 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/logging"
+	"regexp"
+	"strings"
+)
+
+// apiKeyPrefix marks the keys issued by the service, so they are easy to spot in leaked configs.
+const apiKeyPrefix = "irs_"
+
+// apiKeyDisplayLength is how much of a key is stored in clear to identify it.
+const apiKeyDisplayLength = len(apiKeyPrefix) + 8
+
+var tenantNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+type TenantService struct {
+	tenantRepository ports.TenantRepository
+	logger           *logging.Logger
+}
+
+func NewTenantService(tenantRepo ports.TenantRepository, logger *logging.Logger) ports.TenantUseCase {
+	return &TenantService{tenantRepository: tenantRepo, logger: logger}
+}
+
+func (s *TenantService) Authenticate(ctx context.Context, apiKey string) (*domain.Tenant, error) {
+	if !strings.HasPrefix(apiKey, apiKeyPrefix) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	key, err := s.tenantRepository.WithContext(ctx).FindAPIKeyByHash(hashAPIKey(apiKey))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("failed to find api key: %w", err)
+	}
+
+	// The tenant is missing when it was deleted directly in the database.
+	if key.Tenant == nil {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	return key.Tenant, nil
+}
+
+func (s *TenantService) List(ctx context.Context) ([]domain.Tenant, error) {
+	tenants, err := s.tenantRepository.WithContext(ctx).FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	return tenants, nil
+}
+
+func (s *TenantService) Create(ctx context.Context, name string) (*domain.Tenant, error) {
+	if !tenantNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: name must be 1-50 lowercase letters, digits, '-' or '_'", domain.ErrInvalidTenant)
+	}
+
+	tenantRepo := s.tenantRepository.WithContext(ctx)
+
+	_, err := tenantRepo.FindByName(name)
+	if err == nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrTenantExists, name)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to find tenant: %w", err)
+	}
+
+	tenant := &domain.Tenant{Name: name}
+	if err := tenantRepo.Save(tenant); err != nil {
+		return nil, fmt.Errorf("failed to save tenant: %w", err)
+	}
+
+	s.logger.Ctx(ctx).Info("tenant created", zap.String("tenant", name), zap.String("tenant_id", tenant.ID))
+
+	return tenant, nil
+}
+
+func (s *TenantService) Resolve(ctx context.Context, name string) (*domain.Tenant, error) {
+	tenant, err := s.tenantRepository.WithContext(ctx).FindByName(name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrTenantNotFound, name)
+		}
+		return nil, fmt.Errorf("failed to find tenant: %w", err)
+	}
+
+	return tenant, nil
+}
+
+func (s *TenantService) SetQuota(ctx context.Context, tenantName string, quota domain.QuotaOverride) (*domain.Tenant, error) {
+	tenant, err := s.Resolve(ctx, tenantName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, limit := range []*int64{quota.SoftBytes, quota.HardBytes, quota.SoftImages, quota.HardImages} {
+		if limit != nil && *limit < 0 {
+			return nil, fmt.Errorf("%w: quota limits must not be negative, use 0 to disable a limit", domain.ErrInvalidTenant)
+		}
+	}
+
+	if err := s.tenantRepository.WithContext(ctx).UpdateQuota(tenant.ID, quota); err != nil {
+		return nil, fmt.Errorf("failed to update tenant quota: %w", err)
+	}
+	tenant.Quota = quota
+
+	s.logger.Ctx(ctx).Info("tenant quota updated", zap.String("tenant", tenant.Name))
+
+	return tenant, nil
+}
+
+func (s *TenantService) IssueAPIKey(ctx context.Context, tenantName string) (*ports.IssuedAPIKey, error) {
+	tenant, err := s.Resolve(ctx, tenantName)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	apiKey := apiKeyPrefix + hex.EncodeToString(secret)
+
+	key := &domain.APIKey{
+		TenantID: tenant.ID,
+		Prefix:   apiKey[:apiKeyDisplayLength],
+		Hash:     hashAPIKey(apiKey),
+	}
+	if err := s.tenantRepository.WithContext(ctx).SaveAPIKey(key); err != nil {
+		return nil, fmt.Errorf("failed to save api key: %w", err)
+	}
+
+	s.logger.Ctx(ctx).Info("api key issued", zap.String("tenant", tenant.Name), zap.String("prefix", key.Prefix))
+
+	return &ports.IssuedAPIKey{Tenant: tenant.Name, Prefix: key.Prefix, Key: apiKey}, nil
+}
+
+func (s *TenantService) RevokeAPIKey(ctx context.Context, prefix string) error {
+	revoked, err := s.tenantRepository.WithContext(ctx).DeleteAPIKey(prefix)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if revoked == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrAPIKeyNotFound, prefix)
+	}
+
+	s.logger.Ctx(ctx).Info("api key revoked", zap.String("prefix", prefix))
+
+	return nil
+}
+
+// hashAPIKey returns the stored form of a key. Keys are random, so an unsalted hash is enough.
+func hashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
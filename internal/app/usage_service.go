@@ -0,0 +1,241 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/logging"
+	"image-resizing-service/pkg/utils"
+	"sort"
+	"time"
+)
+
+type UsageService struct {
+	db                  *gorm.DB
+	usageRepository     ports.UsageRepository
+	tenantRepository    ports.TenantRepository
+	imageRepository     ports.ImageRepository
+	thumbnailRepository ports.ThumbnailRepository
+	minio               *utils.MinioClient
+	// quota applies to tenants that do not override it.
+	quota  domain.Quota
+	logger *logging.Logger
+}
+
+func NewUsageService(
+	db *gorm.DB,
+	usageRepo ports.UsageRepository,
+	tenantRepo ports.TenantRepository,
+	imageRepo ports.ImageRepository,
+	thumbnailRepo ports.ThumbnailRepository,
+	minio *utils.MinioClient,
+	quota domain.Quota,
+	logger *logging.Logger,
+) ports.UsageUseCase {
+	return &UsageService{
+		db:                  db,
+		usageRepository:     usageRepo,
+		tenantRepository:    tenantRepo,
+		imageRepository:     imageRepo,
+		thumbnailRepository: thumbnailRepo,
+		minio:               minio,
+		quota:               quota,
+		logger:              logger,
+	}
+}
+
+func (s *UsageService) Usage(ctx context.Context, tenantID string) (*ports.Usage, error) {
+	usage, err := s.usageRepository.WithContext(ctx).Find(tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find usage: %w", err)
+	}
+
+	quota, err := s.tenantQuota(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ports.Usage{
+		TenantID:  tenantID,
+		Bytes:     usage.Bytes,
+		Images:    usage.Images,
+		Quota:     quota,
+		UpdatedAt: usage.UpdatedAt,
+	}, nil
+}
+
+// CheckUpload only counts the original: its renditions are added once rendered, so a tenant can end
+// up slightly past its hard byte limit, but never by more than the renditions of one image.
+func (s *UsageService) CheckUpload(ctx context.Context, tenantID string, bytes int64) (*ports.UploadCheck, error) {
+	usage, err := s.Usage(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	quota := usage.Quota
+	check := &ports.UploadCheck{Quota: quota}
+
+	if quota.HardImages > 0 && usage.Images+1 > quota.HardImages {
+		return nil, fmt.Errorf("%w: the tenant stores %d of at most %d images", domain.ErrQuotaExceeded, usage.Images, quota.HardImages)
+	}
+	if quota.HardBytes > 0 && usage.Bytes+bytes > quota.HardBytes {
+		return nil, fmt.Errorf("%w: %d more bytes would exceed the limit of %d bytes, %d are used", domain.ErrQuotaExceeded, bytes, quota.HardBytes, usage.Bytes)
+	}
+
+	if quota.SoftImages > 0 && usage.Images+1 > quota.SoftImages {
+		check.Warning = fmt.Sprintf("soft quota exceeded: %d images stored, the soft limit is %d", usage.Images+1, quota.SoftImages)
+	} else if quota.SoftBytes > 0 && usage.Bytes+bytes > quota.SoftBytes {
+		check.Warning = fmt.Sprintf("soft quota exceeded: %d bytes stored, the soft limit is %d", usage.Bytes+bytes, quota.SoftBytes)
+	}
+
+	return check, nil
+}
+
+func (s *UsageService) tenantQuota(ctx context.Context, tenantID string) (domain.Quota, error) {
+	tenant, err := s.tenantRepository.WithContext(ctx).FindByID(tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s.quota, nil
+		}
+		return domain.Quota{}, fmt.Errorf("failed to find tenant: %w", err)
+	}
+
+	return tenant.Quota.Apply(s.quota), nil
+}
+
+func (s *UsageService) Recalculate(ctx context.Context) (*ports.UsageRecalculation, error) {
+	report := &ports.UsageRecalculation{}
+
+	sizes := make(map[string]int64)
+	for _, prefix := range orphanPrefixes {
+		err := s.minio.ListObjects(ctx, prefix, func(object utils.StoredObject) error {
+			sizes[object.Key] = object.Size
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects under %s: %w", prefix, err)
+		}
+	}
+	report.Objects = len(sizes)
+
+	tenants, err := s.tenantRepository.WithContext(ctx).FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	// Tenants without images are reset too.
+	usages := make(map[string]*domain.TenantUsage, len(tenants))
+	for _, tenant := range tenants {
+		usages[tenant.ID] = &domain.TenantUsage{TenantID: tenant.ID}
+	}
+
+	imageRepo := s.imageRepository.WithContext(ctx)
+	afterID := ""
+	for {
+		ids, err := imageRepo.FindIDsByFilter(domain.ReprocessFilter{}, afterID, maintenanceBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list images: %w", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		for _, id := range ids {
+			image, err := imageRepo.FindByID(id)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to find image %s: %w", id, err)
+			}
+
+			corrected, err := s.recordStoredBytes(ctx, image, sizes)
+			if err != nil {
+				return nil, err
+			}
+			if corrected {
+				report.Corrected++
+			}
+			report.Images++
+
+			usage, ok := usages[image.TenantID]
+			if !ok {
+				usage = &domain.TenantUsage{TenantID: image.TenantID}
+				usages[image.TenantID] = usage
+			}
+			usage.Bytes += image.StoredBytes()
+			usage.Images++
+		}
+
+		afterID = ids[len(ids)-1]
+	}
+
+	rows := make([]domain.TenantUsage, 0, len(usages))
+	for _, usage := range usages {
+		usage.UpdatedAt = time.Now()
+		rows = append(rows, *usage)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].TenantID < rows[j].TenantID })
+
+	if err := s.usageRepository.WithContext(ctx).Replace(rows); err != nil {
+		return nil, fmt.Errorf("failed to save usage: %w", err)
+	}
+
+	for _, row := range rows {
+		quota, err := s.tenantQuota(ctx, row.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		report.Tenants = append(report.Tenants, ports.Usage{
+			TenantID:  row.TenantID,
+			Bytes:     row.Bytes,
+			Images:    row.Images,
+			Quota:     quota,
+			UpdatedAt: row.UpdatedAt,
+		})
+	}
+
+	s.logger.Ctx(ctx).Info("usage recalculated",
+		zap.Int("objects", report.Objects),
+		zap.Int("images", report.Images),
+		zap.Int("corrected", report.Corrected),
+	)
+
+	return report, nil
+}
+
+// recordStoredBytes sets the object sizes of the image and its thumbnails to the listed ones,
+// saving those that changed. Missing objects count as empty.
+func (s *UsageService) recordStoredBytes(ctx context.Context, image *domain.Image, sizes map[string]int64) (bool, error) {
+	corrected := false
+
+	originalBytes := sizes[image.OriginalKey]
+	var compressedBytes int64
+	if image.CompressedKey != "" {
+		compressedBytes = sizes[image.CompressedKey]
+	}
+	if originalBytes != image.OriginalBytes || compressedBytes != image.CompressedBytes {
+		if err := s.imageRepository.WithContext(ctx).UpdateStoredBytes(image.ID, originalBytes, compressedBytes); err != nil {
+			return false, fmt.Errorf("failed to update sizes of image %s: %w", image.ID, err)
+		}
+		image.OriginalBytes, image.CompressedBytes = originalBytes, compressedBytes
+		corrected = true
+	}
+
+	for i, thumbnail := range image.Thumbnails {
+		bytes := sizes[thumbnail.Key]
+		if bytes == thumbnail.Bytes {
+			continue
+		}
+		if err := s.thumbnailRepository.WithContext(ctx).UpdateBytes(thumbnail.ID, bytes); err != nil {
+			return false, fmt.Errorf("failed to update size of thumbnail %s: %w", thumbnail.ID, err)
+		}
+		image.Thumbnails[i].Bytes = bytes
+		corrected = true
+	}
+
+	return corrected, nil
+}
@@ -0,0 +1,197 @@
+package assembler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/dto"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/utils"
+	"net/url"
+	"time"
+)
+
+// URLPolicy decides how the files of an image are linked, depending on its visibility.
+type URLPolicy struct {
+	Strategy domain.URLStrategy
+	// PrivateTTL is the lifetime of the presigned URLs and access tokens of private files.
+	PrivateTTL time.Duration
+	// PublicTTL is the lifetime of the presigned URLs of public files.
+	PublicTTL time.Duration
+	// PublicBaseURL is the CDN base URL under which the cdn strategy links public files.
+	PublicBaseURL string
+	// ProxyBaseURL is the external URL of the service, under which the proxy strategy links files.
+	ProxyBaseURL string
+	// AccessSecret signs the access tokens in the proxy URLs of private files.
+	AccessSecret []byte
+	// Disposition is the Content-Disposition of presigned downloads.
+	Disposition utils.Disposition
+}
+
+// fileOwner is the image a file belongs to, which decides how the file is linked.
+type fileOwner struct {
+	TenantID   string
+	ImageID    string
+	Visibility domain.Visibility
+}
+
+func ownerOf(image *domain.Image) fileOwner {
+	return fileOwner{TenantID: image.TenantID, ImageID: image.ID, Visibility: image.Visibility}
+}
+
+type fileURLs struct {
+	minio  *utils.MinioClient
+	policy URLPolicy
+	// cache is nil when caching is disabled.
+	cache ports.ImageCache
+}
+
+// renditionContentType is the content type of the compressed rendition and thumbnails.
+const renditionContentType = "image/webp"
+
+// get links the file stored under key, whose content type decides how presigned downloads are served.
+func (u fileURLs) get(ctx context.Context, owner fileOwner, key, contentType string) (string, error) {
+	if owner.Visibility == domain.VisibilityPublic {
+		switch u.policy.Strategy {
+		case domain.URLCDN:
+			return url.JoinPath(u.policy.PublicBaseURL, key)
+		case domain.URLProxy:
+			return u.proxyURL(key)
+		}
+	}
+
+	// The strategy and visibility decide what the URL looks like and how long it lasts.
+	name := string(u.policy.Strategy) + ":" + string(owner.Visibility) + ":" + key
+	if u.cache != nil {
+		if cached, ok := u.cache.URL(ctx, name); ok {
+			return cached, nil
+		}
+	}
+
+	signed, err := u.sign(ctx, owner, key, contentType)
+	if err != nil {
+		return "", err
+	}
+
+	if u.cache != nil {
+		u.cache.StoreURL(ctx, name, signed)
+	}
+
+	return signed, nil
+}
+
+// sign returns a URL of the file that expires: a presigned storage URL, or a proxy URL carrying an
+// access token for the image.
+func (u fileURLs) sign(ctx context.Context, owner fileOwner, key, contentType string) (string, error) {
+	ttl := u.policy.PrivateTTL
+	if owner.Visibility == domain.VisibilityPublic {
+		ttl = u.policy.PublicTTL
+	}
+
+	if u.policy.Strategy != domain.URLProxy {
+		return u.minio.GetFileURL(ctx, key, ttl, u.policy.Disposition.Header(key, contentType))
+	}
+
+	token, err := utils.SignImageAccess(u.policy.AccessSecret, utils.ImageAccess{
+		TenantID:  owner.TenantID,
+		ImageID:   owner.ImageID,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	link, err := u.proxyURL(key)
+	if err != nil {
+		return "", err
+	}
+
+	return link + "?access_token=" + url.QueryEscape(token), nil
+}
+
+func (u fileURLs) proxyURL(key string) (string, error) {
+	return url.JoinPath(u.policy.ProxyBaseURL, "files", key)
+}
+
+// image links the files of image. Both transports build their responses from it, and it is what
+// the cache keeps.
+func (u fileURLs) image(ctx context.Context, image *domain.Image) (*dto.ImageWithThumbnails, error) {
+	thumbnails := make([]dto.ThumbnailShort, 0, len(image.Thumbnails))
+	for _, thumb := range image.Thumbnails {
+		if thumb.Status != domain.RenditionReady {
+			continue
+		}
+
+		url, err := u.get(ctx, ownerOf(image), thumb.Key, renditionContentType)
+		if err != nil {
+			return nil, err
+		}
+
+		thumbnails = append(thumbnails, dto.ThumbnailShort{
+			Size: thumb.Size,
+			Url:  url,
+			Type: thumb.Type,
+		})
+	}
+
+	originalUrl, err := u.get(ctx, ownerOf(image), image.OriginalKey, image.ContentType)
+	if err != nil {
+		return nil, err
+	}
+
+	compressedUrl, err := u.get(ctx, ownerOf(image), image.CompressedKey, renditionContentType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ImageWithThumbnails{
+		ID:            image.ID,
+		OriginalUrl:   originalUrl,
+		CompressedUrl: compressedUrl,
+		Status:        string(image.Status),
+		Visibility:    string(image.Visibility),
+		ErrorMessage:  image.ErrorMessage,
+		Thumbnails:    thumbnails,
+	}, nil
+}
+
+// cachedImage returns the cached response of the image with the given ID of the tenant in ctx.
+func (u fileURLs) cachedImage(ctx context.Context, id string) (*dto.ImageWithThumbnails, bool) {
+	if u.cache == nil {
+		return nil, false
+	}
+
+	tenantID, ok := domain.TenantFromContext(ctx)
+	if !ok {
+		tenantID = domain.DefaultTenantID
+	}
+
+	data, ok := u.cache.Image(ctx, tenantID, id)
+	if !ok {
+		return nil, false
+	}
+
+	var response dto.ImageWithThumbnails
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, false
+	}
+
+	return &response, true
+}
+
+// cachedLookup builds the response of a lookup of image and caches it until the image changes.
+// Only lookups are cached: other responses, such as that of a reprocess request, are built just as
+// processing starts to change the image.
+func (u fileURLs) cachedLookup(ctx context.Context, image *domain.Image) (*dto.ImageWithThumbnails, error) {
+	response, err := u.image(ctx, image)
+	if err != nil || u.cache == nil {
+		return response, err
+	}
+
+	if data, err := json.Marshal(response); err == nil {
+		u.cache.StoreImage(ctx, image.TenantID, image.ID, data)
+	}
+
+	return response, nil
+}
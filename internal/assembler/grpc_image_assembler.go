@@ -4,20 +4,23 @@ import (
 	"context"
 	images "image-resizing-service/internal/delivery/grpc/pb"
 	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/dto"
 	"image-resizing-service/internal/ports"
 	"image-resizing-service/pkg/utils"
+	"time"
 )
 
 type GRPCImageAssembler struct {
-	minio *utils.MinioClient
+	files fileURLs
 }
 
-func NewGRPCImageAssembler(minio *utils.MinioClient) *GRPCImageAssembler {
-	return &GRPCImageAssembler{minio: minio}
+// NewGRPCImageAssembler links files according to policy. cache may be nil to disable caching.
+func NewGRPCImageAssembler(minio *utils.MinioClient, policy URLPolicy, cache ports.ImageCache) *GRPCImageAssembler {
+	return &GRPCImageAssembler{files: fileURLs{minio: minio, policy: policy, cache: cache}}
 }
 
 func (g *GRPCImageAssembler) BuildImage(ctx context.Context, image *ports.UploadResult) *images.ImageResponse {
-	originalUrl, err := g.minio.GetFileURL(ctx, image.OriginalKey)
+	originalUrl, err := g.files.get(ctx, fileOwner{TenantID: image.TenantID, ImageID: image.ID, Visibility: image.Visibility}, image.OriginalKey, image.ContentType)
 	if err != nil {
 		return nil
 	}
@@ -26,39 +29,93 @@ func (g *GRPCImageAssembler) BuildImage(ctx context.Context, image *ports.Upload
 		Id:          image.ID,
 		OriginalUrl: originalUrl,
 		Status:      image.Status,
+		Visibility:  string(image.Visibility),
 	}
 }
 
 func (g *GRPCImageAssembler) BuildImageWithThumbnails(ctx context.Context, image *domain.Image) *images.ImageResponse {
-	originalUrl, err := g.minio.GetFileURL(ctx, image.OriginalKey)
+	response, err := g.files.image(ctx, image)
 	if err != nil {
 		return nil
 	}
 
-	compressedUrl, err := g.minio.GetFileURL(ctx, image.CompressedKey)
+	return imageResponse(response)
+}
+
+// CachedImage returns the cached lookup of the image with the given ID, or nil.
+func (g *GRPCImageAssembler) CachedImage(ctx context.Context, id string) *images.ImageResponse {
+	response, ok := g.files.cachedImage(ctx, id)
+	if !ok {
+		return nil
+	}
+
+	return imageResponse(response)
+}
+
+// BuildImageLookup is BuildImageWithThumbnails for image lookups, whose responses are cached.
+func (g *GRPCImageAssembler) BuildImageLookup(ctx context.Context, image *domain.Image) *images.ImageResponse {
+	response, err := g.files.cachedLookup(ctx, image)
 	if err != nil {
 		return nil
 	}
 
+	return imageResponse(response)
+}
+
+func imageResponse(image *dto.ImageWithThumbnails) *images.ImageResponse {
 	var thumbnails []*images.ThumbnailShort
 	for _, thumb := range image.Thumbnails {
-		url, err := g.minio.GetFileURL(ctx, thumb.Key)
-		if err != nil {
-			return nil
-		}
-
 		thumbnails = append(thumbnails, &images.ThumbnailShort{
 			Size: thumb.Size,
-			Url:  url,
+			Url:  thumb.Url,
 			Type: thumb.Type,
 		})
 	}
 
 	return &images.ImageResponse{
 		Id:            image.ID,
-		OriginalUrl:   originalUrl,
-		CompressedUrl: &compressedUrl,
-		Status:        string(image.Status),
+		OriginalUrl:   image.OriginalUrl,
+		CompressedUrl: &image.CompressedUrl,
+		Status:        image.Status,
+		ErrorMessage:  image.ErrorMessage,
 		Thumbnails:    thumbnails,
+		Visibility:    image.Visibility,
+	}
+}
+
+func (g *GRPCImageAssembler) BuildReprocessJob(job *domain.ReprocessJob) *images.ReprocessJobResponse {
+	response := &images.ReprocessJobResponse{
+		Id:           job.ID,
+		Status:       string(job.Status),
+		Total:        int32(job.Total),
+		Processed:    int32(job.Processed),
+		Failed:       int32(job.Failed),
+		Skipped:      int32(job.Skipped),
+		ErrorMessage: job.ErrorMessage,
+		CreatedAt:    job.CreatedAt.Format(time.RFC3339),
+	}
+
+	if job.StartedAt != nil {
+		startedAt := job.StartedAt.Format(time.RFC3339)
+		response.StartedAt = &startedAt
+	}
+	if job.FinishedAt != nil {
+		finishedAt := job.FinishedAt.Format(time.RFC3339)
+		response.FinishedAt = &finishedAt
+	}
+
+	return response
+}
+
+func (g *GRPCImageAssembler) BuildUsage(usage *ports.Usage) *images.UsageResponse {
+	return &images.UsageResponse{
+		TenantId:   usage.TenantID,
+		Bytes:      usage.Bytes,
+		Images:     usage.Images,
+		SoftBytes:  usage.Quota.SoftBytes,
+		HardBytes:  usage.Quota.HardBytes,
+		SoftImages: usage.Quota.SoftImages,
+		HardImages: usage.Quota.HardImages,
+		UpdatedAt:  usage.UpdatedAt.Format(time.RFC3339),
 	}
 }
@@ -9,17 +9,16 @@ import (
 )
 
 type RestImageAssembler struct {
-	minio *utils.MinioClient
+	files fileURLs
 }
 
-func NewRestImageAssembler(minio *utils.MinioClient) *RestImageAssembler {
-	return &RestImageAssembler{minio: minio}
+// NewRestImageAssembler links files according to policy. cache may be nil to disable caching.
+func NewRestImageAssembler(minio *utils.MinioClient, policy URLPolicy, cache ports.ImageCache) *RestImageAssembler {
+	return &RestImageAssembler{files: fileURLs{minio: minio, policy: policy, cache: cache}}
 }
 
-func (a *RestImageAssembler) BuildImage(image *ports.UploadResult) *dto.ImageWithThumbnails {
-	ctx := context.Background()
-
-	originalUrl, err := a.minio.GetFileURL(ctx, image.OriginalKey)
+func (a *RestImageAssembler) BuildImage(ctx context.Context, image *ports.UploadResult) *dto.ImageWithThumbnails {
+	originalUrl, err := a.files.get(ctx, fileOwner{TenantID: image.TenantID, ImageID: image.ID, Visibility: image.Visibility}, image.OriginalKey, image.ContentType)
 	if err != nil {
 		return nil
 	}
@@ -28,42 +27,61 @@ func (a *RestImageAssembler) BuildImage(image *ports.UploadResult) *dto.ImageWit
 		ID:          image.ID,
 		OriginalUrl: originalUrl,
 		Status:      image.Status,
+		Visibility:  string(image.Visibility),
 	}
 }
 
-func (a *RestImageAssembler) BuildImageWithThumbnails(image *domain.Image) *dto.ImageWithThumbnails {
-	ctx := context.Background()
+func (a *RestImageAssembler) BuildImageWithThumbnails(ctx context.Context, image *domain.Image) *dto.ImageWithThumbnails {
+	response, err := a.files.image(ctx, image)
+	if err != nil {
+		return nil
+	}
 
-	thumbnails := make([]dto.ThumbnailShort, 0, len(image.Thumbnails))
-	for _, thumb := range image.Thumbnails {
-		url, err := a.minio.GetFileURL(ctx, thumb.Key)
-		if err != nil {
-			return nil
-		}
+	return response
+}
 
-		thumbnails = append(thumbnails, dto.ThumbnailShort{
-			Size: thumb.Size,
-			Url:  url,
-			Type: thumb.Type,
-		})
-	}
+// CachedImage returns the cached lookup of the image with the given ID, or nil.
+func (a *RestImageAssembler) CachedImage(ctx context.Context, id string) *dto.ImageWithThumbnails {
+	response, _ := a.files.cachedImage(ctx, id)
+	return response
+}
 
-	originalUrl, err := a.minio.GetFileURL(ctx, image.OriginalKey)
+// BuildImageLookup is BuildImageWithThumbnails for image lookups, whose responses are cached.
+func (a *RestImageAssembler) BuildImageLookup(ctx context.Context, image *domain.Image) *dto.ImageWithThumbnails {
+	response, err := a.files.cachedLookup(ctx, image)
 	if err != nil {
 		return nil
 	}
 
-	compressedUrl, err := a.minio.GetFileURL(ctx, image.CompressedKey)
-	if err != nil {
-		return nil
+	return response
+}
+
+func (a *RestImageAssembler) BuildReprocessJob(job *domain.ReprocessJob) *dto.ReprocessJob {
+	return &dto.ReprocessJob{
+		ID:           job.ID,
+		Status:       string(job.Status),
+		Total:        job.Total,
+		Processed:    job.Processed,
+		Failed:       job.Failed,
+		Skipped:      job.Skipped,
+		ErrorMessage: job.ErrorMessage,
+		CreatedAt:    job.CreatedAt,
+		StartedAt:    job.StartedAt,
+		FinishedAt:   job.FinishedAt,
 	}
+}
 
-	return &dto.ImageWithThumbnails{
-		ID:            image.ID,
-		OriginalUrl:   originalUrl,
-		CompressedUrl: compressedUrl,
-		Status:        string(image.Status),
-		ErrorMessage:  image.ErrorMessage,
-		Thumbnails:    thumbnails,
+func (a *RestImageAssembler) BuildUsage(usage *ports.Usage) *dto.Usage {
+	return &dto.Usage{
+		TenantID: usage.TenantID,
+		Bytes:    usage.Bytes,
+		Images:   usage.Images,
+		Quota: dto.Quota{
+			SoftBytes:  usage.Quota.SoftBytes,
+			HardBytes:  usage.Quota.HardBytes,
+			SoftImages: usage.Quota.SoftImages,
+			HardImages: usage.Quota.HardImages,
+		},
+		UpdatedAt: usage.UpdatedAt,
 	}
 }
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	images "image-resizing-service/internal/delivery/grpc/pb"
+	"image-resizing-service/internal/ports"
+	"sync"
+	"time"
+)
+
+// HealthGRPCHandler implements grpc.health.v1.Health on top of the same readiness checks as /readyz.
+// The overall server ("") and images.ImageService always share one status.
+type HealthGRPCHandler struct {
+	grpc_health_v1.UnimplementedHealthServer
+	useCase ports.HealthUseCase
+	// interval is how often Watch re-runs the checks.
+	interval time.Duration
+	shutdown chan struct{}
+	once     sync.Once
+}
+
+func NewHealthGRPCHandler(useCase ports.HealthUseCase, interval time.Duration) *HealthGRPCHandler {
+	return &HealthGRPCHandler{
+		useCase:  useCase,
+		interval: interval,
+		shutdown: make(chan struct{}),
+	}
+}
+
+var healthServices = []string{"", images.ImageService_ServiceDesc.ServiceName}
+
+func (h *HealthGRPCHandler) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if !isHealthService(req.Service) {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.Service)
+	}
+
+	return &grpc_health_v1.HealthCheckResponse{Status: h.status(ctx)}, nil
+}
+
+func (h *HealthGRPCHandler) List(ctx context.Context, _ *grpc_health_v1.HealthListRequest) (*grpc_health_v1.HealthListResponse, error) {
+	servingStatus := h.status(ctx)
+
+	statuses := make(map[string]*grpc_health_v1.HealthCheckResponse, len(healthServices))
+	for _, service := range healthServices {
+		statuses[service] = &grpc_health_v1.HealthCheckResponse{Status: servingStatus}
+	}
+
+	return &grpc_health_v1.HealthListResponse{Statuses: statuses}, nil
+}
+
+// Watch sends the status on every change until the client goes away or the server shuts down.
+func (h *HealthGRPCHandler) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	ctx := stream.Context()
+	known := isHealthService(req.Service)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	last := grpc_health_v1.HealthCheckResponse_ServingStatus(-1)
+	for {
+		current := grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+		if known {
+			current = h.status(ctx)
+		}
+
+		if current != last {
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-h.shutdown:
+			// Watch streams would otherwise keep GracefulStop waiting until the deadline.
+			if known {
+				return stream.Send(&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING})
+			}
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown reports NOT_SERVING from now on and ends every Watch stream.
+func (h *HealthGRPCHandler) Shutdown() {
+	h.once.Do(func() {
+		close(h.shutdown)
+	})
+}
+
+func (h *HealthGRPCHandler) status(ctx context.Context) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	select {
+	case <-h.shutdown:
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	default:
+	}
+
+	if !h.useCase.Ready(ctx).Ready {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+
+	return grpc_health_v1.HealthCheckResponse_SERVING
+}
+
+func isHealthService(service string) bool {
+	for _, known := range healthServices {
+		if service == known {
+			return true
+		}
+	}
+
+	return false
+}
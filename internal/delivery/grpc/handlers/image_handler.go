@@ -3,25 +3,42 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"errors"
 	"github.com/google/uuid"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"image-resizing-service/internal/assembler"
 	images "image-resizing-service/internal/delivery/grpc/pb"
+	"image-resizing-service/internal/domain"
 	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/metrics"
 	"image-resizing-service/pkg/utils"
+	"time"
 )
 
+// quotaWarningMetadata is the header metadata carrying the warning of an upload that reached a soft quota.
+const quotaWarningMetadata = "x-quota-warning"
+
 type ImageGRPCHandler struct {
 	images.UnimplementedImageServiceServer
-	useCase       ports.ImageUseCase
-	grpcAssembler *assembler.GRPCImageAssembler
+	useCase          ports.ImageUseCase
+	reprocessUseCase ports.ReprocessUseCase
+	authUseCase      ports.AuthUseCase
+	usageUseCase     ports.UsageUseCase
+	grpcAssembler    *assembler.GRPCImageAssembler
+	maxUploadBytes   int
 }
 
-func NewImageGRPCHandler(useCase ports.ImageUseCase, grpcAssembler *assembler.GRPCImageAssembler) *ImageGRPCHandler {
+func NewImageGRPCHandler(useCase ports.ImageUseCase, reprocessUseCase ports.ReprocessUseCase, authUseCase ports.AuthUseCase, usageUseCase ports.UsageUseCase, grpcAssembler *assembler.GRPCImageAssembler, maxUploadBytes int) *ImageGRPCHandler {
 	return &ImageGRPCHandler{
-		useCase:       useCase,
-		grpcAssembler: grpcAssembler,
+		useCase:          useCase,
+		reprocessUseCase: reprocessUseCase,
+		authUseCase:      authUseCase,
+		usageUseCase:     usageUseCase,
+		grpcAssembler:    grpcAssembler,
+		maxUploadBytes:   maxUploadBytes,
 	}
 }
 
@@ -30,15 +47,28 @@ func (h *ImageGRPCHandler) UploadImage(ctx context.Context, req *images.UploadIm
 		return nil, status.Error(codes.InvalidArgument, "image data is required")
 	}
 
+	if h.maxUploadBytes > 0 && len(req.Data) > h.maxUploadBytes {
+		return nil, status.Errorf(codes.InvalidArgument, "upload exceeds the maximum allowed size of %d bytes", h.maxUploadBytes)
+	}
+
 	tempFilePath, contentType, err := utils.SaveTempFile(bytes.NewReader(req.Data))
 	if err != nil {
 		return nil, err
 	}
 	defer utils.RemoveFile(tempFilePath)
 
-	result, err := h.useCase.UploadOriginal(ctx, tempFilePath, contentType)
+	if !utils.IsSupportedContentType(contentType) {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported content type %s", contentType)
+	}
+
+	result, err := h.useCase.UploadOriginal(metrics.WithTransport(ctx, metrics.TransportGRPC), tempFilePath, contentType, domain.Visibility(req.GetVisibility()))
 	if err != nil {
-		return nil, err
+		return nil, uploadError(err)
+	}
+
+	if result.QuotaWarning != "" {
+		// The upload succeeded, so failing to send the warning is not worth failing the call.
+		_ = grpc.SetHeader(ctx, metadata.Pairs(quotaWarningMetadata, result.QuotaWarning))
 	}
 
 	return h.grpcAssembler.BuildImage(ctx, result), nil
@@ -49,10 +79,164 @@ func (h *ImageGRPCHandler) GetImage(ctx context.Context, req *images.GetImageReq
 		return nil, status.Error(codes.InvalidArgument, "invalid id")
 	}
 
+	if cached := h.grpcAssembler.CachedImage(ctx, req.Id); cached != nil {
+		return cached, nil
+	}
+
 	imageData, err := h.useCase.FindByID(ctx, req.Id)
 	if err != nil {
-		return nil, err
+		return nil, processingError(err)
+	}
+
+	return h.grpcAssembler.BuildImageLookup(ctx, imageData), nil
+}
+
+func (h *ImageGRPCHandler) DeleteImage(ctx context.Context, req *images.DeleteImageRequest) (*images.DeleteImageResponse, error) {
+	if uuid.Validate(req.Id) != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+
+	if err := h.useCase.Delete(ctx, req.Id); err != nil {
+		return nil, processingError(err)
+	}
+
+	return &images.DeleteImageResponse{}, nil
+}
+
+func (h *ImageGRPCHandler) CreateAccessToken(ctx context.Context, req *images.CreateAccessTokenRequest) (*images.AccessTokenResponse, error) {
+	if uuid.Validate(req.Id) != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+	if req.GetTtlSeconds() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "ttl_seconds must not be negative")
+	}
+
+	imageData, err := h.useCase.FindByID(ctx, req.Id)
+	if err != nil {
+		return nil, processingError(err)
+	}
+
+	accessToken, err := h.authUseCase.IssueAccessToken(ctx, imageData, time.Duration(req.GetTtlSeconds())*time.Second)
+	if err != nil {
+		return nil, processingError(err)
+	}
+
+	return &images.AccessTokenResponse{
+		Token:     accessToken.Token,
+		ExpiresAt: accessToken.ExpiresAt.Format(time.RFC3339),
+	}, nil
+}
+
+func (h *ImageGRPCHandler) ReprocessImage(ctx context.Context, req *images.ReprocessImageRequest) (*images.ImageResponse, error) {
+	if uuid.Validate(req.Id) != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+
+	imageData, err := h.useCase.Reprocess(ctx, req.Id, req.Presets)
+	if err != nil {
+		return nil, processingError(err)
 	}
 
 	return h.grpcAssembler.BuildImageWithThumbnails(ctx, imageData), nil
 }
+
+func (h *ImageGRPCHandler) BulkReprocess(ctx context.Context, req *images.BulkReprocessRequest) (*images.ReprocessJobResponse, error) {
+	filter := domain.ReprocessFilter{Presets: req.Presets}
+
+	switch imageStatus := domain.ImageStatus(req.GetStatus()); imageStatus {
+	case "", domain.StatusPending, domain.StatusProcessing, domain.StatusReady, domain.StatusError:
+		filter.Status = imageStatus
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid status %q", req.GetStatus())
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseTimestamp(req.CreatedFrom); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid created_from: %v", err)
+	}
+	if filter.CreatedTo, err = parseTimestamp(req.CreatedTo); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid created_to: %v", err)
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return nil, status.Error(codes.InvalidArgument, "created_from must be before created_to")
+	}
+
+	job, err := h.reprocessUseCase.StartBulk(ctx, filter)
+	if err != nil {
+		return nil, processingError(err)
+	}
+
+	return h.grpcAssembler.BuildReprocessJob(job), nil
+}
+
+func (h *ImageGRPCHandler) GetReprocessJob(ctx context.Context, req *images.GetReprocessJobRequest) (*images.ReprocessJobResponse, error) {
+	if uuid.Validate(req.Id) != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+
+	job, err := h.reprocessUseCase.FindJob(ctx, req.Id)
+	if err != nil {
+		return nil, processingError(err)
+	}
+
+	return h.grpcAssembler.BuildReprocessJob(job), nil
+}
+
+func (h *ImageGRPCHandler) GetUsage(ctx context.Context, _ *images.GetUsageRequest) (*images.UsageResponse, error) {
+	tenantID, ok := domain.TenantFromContext(ctx)
+	if !ok {
+		tenantID = domain.DefaultTenantID
+	}
+
+	usage, err := h.usageUseCase.Usage(ctx, tenantID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return h.grpcAssembler.BuildUsage(usage), nil
+}
+
+func parseTimestamp(value *string) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsed, nil
+}
+
+func processingError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrImageNotFound), errors.Is(err, domain.ErrJobNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrUnknownPreset):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrProcessingInProgress), errors.Is(err, domain.ErrAccessTokenDisabled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrShuttingDown):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return err
+	}
+}
+
+func uploadError(err error) error {
+	var limitErr *utils.LimitError
+
+	switch {
+	case errors.As(err, &limitErr):
+		return status.Error(codes.InvalidArgument, limitErr.Error())
+	case errors.Is(err, utils.ErrUnsupportedFormat), errors.Is(err, utils.ErrInvalidImage), errors.Is(err, domain.ErrInvalidVisibility):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, domain.ErrShuttingDown):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return err
+	}
+}
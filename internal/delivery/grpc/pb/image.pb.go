@@ -22,8 +22,10 @@ const (
 )
 
 type UploadImageRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Data  []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	// "private" or "public"; empty uses the configured default.
+	Visibility    *string `protobuf:"bytes,2,opt,name=visibility,proto3,oneof" json:"visibility,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -65,6 +67,13 @@ func (x *UploadImageRequest) GetData() []byte {
 	return nil
 }
 
+func (x *UploadImageRequest) GetVisibility() string {
+	if x != nil && x.Visibility != nil {
+		return *x.Visibility
+	}
+	return ""
+}
+
 type GetImageRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -109,6 +118,191 @@ func (x *GetImageRequest) GetId() string {
 	return ""
 }
 
+type DeleteImageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteImageRequest) Reset() {
+	*x = DeleteImageRequest{}
+	mi := &file_proto_image_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteImageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteImageRequest) ProtoMessage() {}
+
+func (x *DeleteImageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteImageRequest.ProtoReflect.Descriptor instead.
+func (*DeleteImageRequest) Descriptor() ([]byte, []int) {
+	return file_proto_image_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DeleteImageRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteImageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteImageResponse) Reset() {
+	*x = DeleteImageResponse{}
+	mi := &file_proto_image_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteImageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteImageResponse) ProtoMessage() {}
+
+func (x *DeleteImageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteImageResponse.ProtoReflect.Descriptor instead.
+func (*DeleteImageResponse) Descriptor() ([]byte, []int) {
+	return file_proto_image_proto_rawDescGZIP(), []int{3}
+}
+
+// The token reads the image over REST: GET /image/{id}?access_token={token}.
+type CreateAccessTokenRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	TtlSeconds    *int32                 `protobuf:"varint,2,opt,name=ttl_seconds,json=ttlSeconds,proto3,oneof" json:"ttl_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateAccessTokenRequest) Reset() {
+	*x = CreateAccessTokenRequest{}
+	mi := &file_proto_image_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAccessTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAccessTokenRequest) ProtoMessage() {}
+
+func (x *CreateAccessTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAccessTokenRequest.ProtoReflect.Descriptor instead.
+func (*CreateAccessTokenRequest) Descriptor() ([]byte, []int) {
+	return file_proto_image_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateAccessTokenRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CreateAccessTokenRequest) GetTtlSeconds() int32 {
+	if x != nil && x.TtlSeconds != nil {
+		return *x.TtlSeconds
+	}
+	return 0
+}
+
+type AccessTokenResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	ExpiresAt     string                 `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AccessTokenResponse) Reset() {
+	*x = AccessTokenResponse{}
+	mi := &file_proto_image_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AccessTokenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccessTokenResponse) ProtoMessage() {}
+
+func (x *AccessTokenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccessTokenResponse.ProtoReflect.Descriptor instead.
+func (*AccessTokenResponse) Descriptor() ([]byte, []int) {
+	return file_proto_image_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AccessTokenResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *AccessTokenResponse) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
 type ThumbnailShort struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Size          string                 `protobuf:"bytes,1,opt,name=size,proto3" json:"size,omitempty"`
@@ -120,7 +314,7 @@ type ThumbnailShort struct {
 
 func (x *ThumbnailShort) Reset() {
 	*x = ThumbnailShort{}
-	mi := &file_proto_image_proto_msgTypes[2]
+	mi := &file_proto_image_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -132,7 +326,7 @@ func (x *ThumbnailShort) String() string {
 func (*ThumbnailShort) ProtoMessage() {}
 
 func (x *ThumbnailShort) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_image_proto_msgTypes[2]
+	mi := &file_proto_image_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -145,7 +339,7 @@ func (x *ThumbnailShort) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ThumbnailShort.ProtoReflect.Descriptor instead.
 func (*ThumbnailShort) Descriptor() ([]byte, []int) {
-	return file_proto_image_proto_rawDescGZIP(), []int{2}
+	return file_proto_image_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *ThumbnailShort) GetSize() string {
@@ -177,13 +371,14 @@ type ImageResponse struct {
 	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
 	ErrorMessage  *string                `protobuf:"bytes,5,opt,name=error_message,json=errorMessage,proto3,oneof" json:"error_message,omitempty"`
 	Thumbnails    []*ThumbnailShort      `protobuf:"bytes,6,rep,name=thumbnails,proto3" json:"thumbnails,omitempty"`
+	Visibility    string                 `protobuf:"bytes,7,opt,name=visibility,proto3" json:"visibility,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ImageResponse) Reset() {
 	*x = ImageResponse{}
-	mi := &file_proto_image_proto_msgTypes[3]
+	mi := &file_proto_image_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -195,7 +390,7 @@ func (x *ImageResponse) String() string {
 func (*ImageResponse) ProtoMessage() {}
 
 func (x *ImageResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_image_proto_msgTypes[3]
+	mi := &file_proto_image_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -208,7 +403,7 @@ func (x *ImageResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ImageResponse.ProtoReflect.Descriptor instead.
 func (*ImageResponse) Descriptor() ([]byte, []int) {
-	return file_proto_image_proto_rawDescGZIP(), []int{3}
+	return file_proto_image_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ImageResponse) GetId() string {
@@ -253,19 +448,460 @@ func (x *ImageResponse) GetThumbnails() []*ThumbnailShort {
 	return nil
 }
 
+func (x *ImageResponse) GetVisibility() string {
+	if x != nil {
+		return x.Visibility
+	}
+	return ""
+}
+
+type ReprocessImageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Presets       []string               `protobuf:"bytes,2,rep,name=presets,proto3" json:"presets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReprocessImageRequest) Reset() {
+	*x = ReprocessImageRequest{}
+	mi := &file_proto_image_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReprocessImageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReprocessImageRequest) ProtoMessage() {}
+
+func (x *ReprocessImageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReprocessImageRequest.ProtoReflect.Descriptor instead.
+func (*ReprocessImageRequest) Descriptor() ([]byte, []int) {
+	return file_proto_image_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReprocessImageRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ReprocessImageRequest) GetPresets() []string {
+	if x != nil {
+		return x.Presets
+	}
+	return nil
+}
+
+// Dates are RFC 3339 timestamps; empty fields match every image.
+type BulkReprocessRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        *string                `protobuf:"bytes,1,opt,name=status,proto3,oneof" json:"status,omitempty"`
+	CreatedFrom   *string                `protobuf:"bytes,2,opt,name=created_from,json=createdFrom,proto3,oneof" json:"created_from,omitempty"`
+	CreatedTo     *string                `protobuf:"bytes,3,opt,name=created_to,json=createdTo,proto3,oneof" json:"created_to,omitempty"`
+	Presets       []string               `protobuf:"bytes,4,rep,name=presets,proto3" json:"presets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BulkReprocessRequest) Reset() {
+	*x = BulkReprocessRequest{}
+	mi := &file_proto_image_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BulkReprocessRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BulkReprocessRequest) ProtoMessage() {}
+
+func (x *BulkReprocessRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BulkReprocessRequest.ProtoReflect.Descriptor instead.
+func (*BulkReprocessRequest) Descriptor() ([]byte, []int) {
+	return file_proto_image_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *BulkReprocessRequest) GetStatus() string {
+	if x != nil && x.Status != nil {
+		return *x.Status
+	}
+	return ""
+}
+
+func (x *BulkReprocessRequest) GetCreatedFrom() string {
+	if x != nil && x.CreatedFrom != nil {
+		return *x.CreatedFrom
+	}
+	return ""
+}
+
+func (x *BulkReprocessRequest) GetCreatedTo() string {
+	if x != nil && x.CreatedTo != nil {
+		return *x.CreatedTo
+	}
+	return ""
+}
+
+func (x *BulkReprocessRequest) GetPresets() []string {
+	if x != nil {
+		return x.Presets
+	}
+	return nil
+}
+
+type GetReprocessJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReprocessJobRequest) Reset() {
+	*x = GetReprocessJobRequest{}
+	mi := &file_proto_image_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReprocessJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReprocessJobRequest) ProtoMessage() {}
+
+func (x *GetReprocessJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReprocessJobRequest.ProtoReflect.Descriptor instead.
+func (*GetReprocessJobRequest) Descriptor() ([]byte, []int) {
+	return file_proto_image_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetReprocessJobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ReprocessJobResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Total         int32                  `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`
+	Processed     int32                  `protobuf:"varint,4,opt,name=processed,proto3" json:"processed,omitempty"`
+	Failed        int32                  `protobuf:"varint,5,opt,name=failed,proto3" json:"failed,omitempty"`
+	Skipped       int32                  `protobuf:"varint,6,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	ErrorMessage  *string                `protobuf:"bytes,7,opt,name=error_message,json=errorMessage,proto3,oneof" json:"error_message,omitempty"`
+	CreatedAt     string                 `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	StartedAt     *string                `protobuf:"bytes,9,opt,name=started_at,json=startedAt,proto3,oneof" json:"started_at,omitempty"`
+	FinishedAt    *string                `protobuf:"bytes,10,opt,name=finished_at,json=finishedAt,proto3,oneof" json:"finished_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReprocessJobResponse) Reset() {
+	*x = ReprocessJobResponse{}
+	mi := &file_proto_image_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReprocessJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReprocessJobResponse) ProtoMessage() {}
+
+func (x *ReprocessJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReprocessJobResponse.ProtoReflect.Descriptor instead.
+func (*ReprocessJobResponse) Descriptor() ([]byte, []int) {
+	return file_proto_image_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ReprocessJobResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ReprocessJobResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ReprocessJobResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ReprocessJobResponse) GetProcessed() int32 {
+	if x != nil {
+		return x.Processed
+	}
+	return 0
+}
+
+func (x *ReprocessJobResponse) GetFailed() int32 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *ReprocessJobResponse) GetSkipped() int32 {
+	if x != nil {
+		return x.Skipped
+	}
+	return 0
+}
+
+func (x *ReprocessJobResponse) GetErrorMessage() string {
+	if x != nil && x.ErrorMessage != nil {
+		return *x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *ReprocessJobResponse) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *ReprocessJobResponse) GetStartedAt() string {
+	if x != nil && x.StartedAt != nil {
+		return *x.StartedAt
+	}
+	return ""
+}
+
+func (x *ReprocessJobResponse) GetFinishedAt() string {
+	if x != nil && x.FinishedAt != nil {
+		return *x.FinishedAt
+	}
+	return ""
+}
+
+type GetUsageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUsageRequest) Reset() {
+	*x = GetUsageRequest{}
+	mi := &file_proto_image_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsageRequest) ProtoMessage() {}
+
+func (x *GetUsageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsageRequest.ProtoReflect.Descriptor instead.
+func (*GetUsageRequest) Descriptor() ([]byte, []int) {
+	return file_proto_image_proto_rawDescGZIP(), []int{12}
+}
+
+// Limits of 0 are disabled.
+type UsageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TenantId      string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	Bytes         int64                  `protobuf:"varint,2,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Images        int64                  `protobuf:"varint,3,opt,name=images,proto3" json:"images,omitempty"`
+	SoftBytes     int64                  `protobuf:"varint,4,opt,name=soft_bytes,json=softBytes,proto3" json:"soft_bytes,omitempty"`
+	HardBytes     int64                  `protobuf:"varint,5,opt,name=hard_bytes,json=hardBytes,proto3" json:"hard_bytes,omitempty"`
+	SoftImages    int64                  `protobuf:"varint,6,opt,name=soft_images,json=softImages,proto3" json:"soft_images,omitempty"`
+	HardImages    int64                  `protobuf:"varint,7,opt,name=hard_images,json=hardImages,proto3" json:"hard_images,omitempty"`
+	UpdatedAt     string                 `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UsageResponse) Reset() {
+	*x = UsageResponse{}
+	mi := &file_proto_image_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UsageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsageResponse) ProtoMessage() {}
+
+func (x *UsageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UsageResponse.ProtoReflect.Descriptor instead.
+func (*UsageResponse) Descriptor() ([]byte, []int) {
+	return file_proto_image_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UsageResponse) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *UsageResponse) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *UsageResponse) GetImages() int64 {
+	if x != nil {
+		return x.Images
+	}
+	return 0
+}
+
+func (x *UsageResponse) GetSoftBytes() int64 {
+	if x != nil {
+		return x.SoftBytes
+	}
+	return 0
+}
+
+func (x *UsageResponse) GetHardBytes() int64 {
+	if x != nil {
+		return x.HardBytes
+	}
+	return 0
+}
+
+func (x *UsageResponse) GetSoftImages() int64 {
+	if x != nil {
+		return x.SoftImages
+	}
+	return 0
+}
+
+func (x *UsageResponse) GetHardImages() int64 {
+	if x != nil {
+		return x.HardImages
+	}
+	return 0
+}
+
+func (x *UsageResponse) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
 var File_proto_image_proto protoreflect.FileDescriptor
 
 const file_proto_image_proto_rawDesc = "" +
 	"\n" +
-	"\x11proto/image.proto\x12\x06images\"(\n" +
+	"\x11proto/image.proto\x12\x06images\"\\\n" +
 	"\x12UploadImageRequest\x12\x12\n" +
-	"\x04data\x18\x01 \x01(\fR\x04data\"!\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12#\n" +
+	"\n" +
+	"visibility\x18\x02 \x01(\tH\x00R\n" +
+	"visibility\x88\x01\x01B\r\n" +
+	"\v_visibility\"!\n" +
 	"\x0fGetImageRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"J\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"$\n" +
+	"\x12DeleteImageRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x15\n" +
+	"\x13DeleteImageResponse\"`\n" +
+	"\x18CreateAccessTokenRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12$\n" +
+	"\vttl_seconds\x18\x02 \x01(\x05H\x00R\n" +
+	"ttlSeconds\x88\x01\x01B\x0e\n" +
+	"\f_ttl_seconds\"J\n" +
+	"\x13AccessTokenResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\tR\texpiresAt\"J\n" +
 	"\x0eThumbnailShort\x12\x12\n" +
 	"\x04size\x18\x01 \x01(\tR\x04size\x12\x10\n" +
 	"\x03url\x18\x02 \x01(\tR\x03url\x12\x12\n" +
-	"\x04type\x18\x03 \x01(\tR\x04type\"\x8d\x02\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\"\xad\x02\n" +
 	"\rImageResponse\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12!\n" +
 	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\x12*\n" +
@@ -274,12 +910,68 @@ const file_proto_image_proto_rawDesc = "" +
 	"\rerror_message\x18\x05 \x01(\tH\x01R\ferrorMessage\x88\x01\x01\x126\n" +
 	"\n" +
 	"thumbnails\x18\x06 \x03(\v2\x16.images.ThumbnailShortR\n" +
-	"thumbnailsB\x11\n" +
+	"thumbnails\x12\x1e\n" +
+	"\n" +
+	"visibility\x18\a \x01(\tR\n" +
+	"visibilityB\x11\n" +
 	"\x0f_compressed_urlB\x10\n" +
-	"\x0e_error_message2\x90\x01\n" +
+	"\x0e_error_message\"A\n" +
+	"\x15ReprocessImageRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\apresets\x18\x02 \x03(\tR\apresets\"\xc4\x01\n" +
+	"\x14BulkReprocessRequest\x12\x1b\n" +
+	"\x06status\x18\x01 \x01(\tH\x00R\x06status\x88\x01\x01\x12&\n" +
+	"\fcreated_from\x18\x02 \x01(\tH\x01R\vcreatedFrom\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"created_to\x18\x03 \x01(\tH\x02R\tcreatedTo\x88\x01\x01\x12\x18\n" +
+	"\apresets\x18\x04 \x03(\tR\apresetsB\t\n" +
+	"\a_statusB\x0f\n" +
+	"\r_created_fromB\r\n" +
+	"\v_created_to\"(\n" +
+	"\x16GetReprocessJobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xe8\x02\n" +
+	"\x14ReprocessJobResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x05R\x05total\x12\x1c\n" +
+	"\tprocessed\x18\x04 \x01(\x05R\tprocessed\x12\x16\n" +
+	"\x06failed\x18\x05 \x01(\x05R\x06failed\x12\x18\n" +
+	"\askipped\x18\x06 \x01(\x05R\askipped\x12(\n" +
+	"\rerror_message\x18\a \x01(\tH\x00R\ferrorMessage\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\b \x01(\tR\tcreatedAt\x12\"\n" +
+	"\n" +
+	"started_at\x18\t \x01(\tH\x01R\tstartedAt\x88\x01\x01\x12$\n" +
+	"\vfinished_at\x18\n" +
+	" \x01(\tH\x02R\n" +
+	"finishedAt\x88\x01\x01B\x10\n" +
+	"\x0e_error_messageB\r\n" +
+	"\v_started_atB\x0e\n" +
+	"\f_finished_at\"\x11\n" +
+	"\x0fGetUsageRequest\"\xf9\x01\n" +
+	"\rUsageResponse\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12\x14\n" +
+	"\x05bytes\x18\x02 \x01(\x03R\x05bytes\x12\x16\n" +
+	"\x06images\x18\x03 \x01(\x03R\x06images\x12\x1d\n" +
+	"\n" +
+	"soft_bytes\x18\x04 \x01(\x03R\tsoftBytes\x12\x1d\n" +
+	"\n" +
+	"hard_bytes\x18\x05 \x01(\x03R\thardBytes\x12\x1f\n" +
+	"\vsoft_images\x18\x06 \x01(\x03R\n" +
+	"softImages\x12\x1f\n" +
+	"\vhard_images\x18\a \x01(\x03R\n" +
+	"hardImages\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\b \x01(\tR\tupdatedAt2\xda\x04\n" +
 	"\fImageService\x12B\n" +
 	"\vUploadImage\x12\x1a.images.UploadImageRequest\x1a\x15.images.ImageResponse\"\x00\x12<\n" +
-	"\bGetImage\x12\x17.images.GetImageRequest\x1a\x15.images.ImageResponse\"\x00B$Z\"./internal/delivery/grpc/pb;imagesb\x06proto3"
+	"\bGetImage\x12\x17.images.GetImageRequest\x1a\x15.images.ImageResponse\"\x00\x12H\n" +
+	"\vDeleteImage\x12\x1a.images.DeleteImageRequest\x1a\x1b.images.DeleteImageResponse\"\x00\x12T\n" +
+	"\x11CreateAccessToken\x12 .images.CreateAccessTokenRequest\x1a\x1b.images.AccessTokenResponse\"\x00\x12H\n" +
+	"\x0eReprocessImage\x12\x1d.images.ReprocessImageRequest\x1a\x15.images.ImageResponse\"\x00\x12M\n" +
+	"\rBulkReprocess\x12\x1c.images.BulkReprocessRequest\x1a\x1c.images.ReprocessJobResponse\"\x00\x12Q\n" +
+	"\x0fGetReprocessJob\x12\x1e.images.GetReprocessJobRequest\x1a\x1c.images.ReprocessJobResponse\"\x00\x12<\n" +
+	"\bGetUsage\x12\x17.images.GetUsageRequest\x1a\x15.images.UsageResponse\"\x00B$Z\"./internal/delivery/grpc/pb;imagesb\x06proto3"
 
 var (
 	file_proto_image_proto_rawDescOnce sync.Once
@@ -293,24 +985,46 @@ func file_proto_image_proto_rawDescGZIP() []byte {
 	return file_proto_image_proto_rawDescData
 }
 
-var file_proto_image_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_proto_image_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_proto_image_proto_goTypes = []any{
-	(*UploadImageRequest)(nil), // 0: images.UploadImageRequest
-	(*GetImageRequest)(nil),    // 1: images.GetImageRequest
-	(*ThumbnailShort)(nil),     // 2: images.ThumbnailShort
-	(*ImageResponse)(nil),      // 3: images.ImageResponse
+	(*UploadImageRequest)(nil),       // 0: images.UploadImageRequest
+	(*GetImageRequest)(nil),          // 1: images.GetImageRequest
+	(*DeleteImageRequest)(nil),       // 2: images.DeleteImageRequest
+	(*DeleteImageResponse)(nil),      // 3: images.DeleteImageResponse
+	(*CreateAccessTokenRequest)(nil), // 4: images.CreateAccessTokenRequest
+	(*AccessTokenResponse)(nil),      // 5: images.AccessTokenResponse
+	(*ThumbnailShort)(nil),           // 6: images.ThumbnailShort
+	(*ImageResponse)(nil),            // 7: images.ImageResponse
+	(*ReprocessImageRequest)(nil),    // 8: images.ReprocessImageRequest
+	(*BulkReprocessRequest)(nil),     // 9: images.BulkReprocessRequest
+	(*GetReprocessJobRequest)(nil),   // 10: images.GetReprocessJobRequest
+	(*ReprocessJobResponse)(nil),     // 11: images.ReprocessJobResponse
+	(*GetUsageRequest)(nil),          // 12: images.GetUsageRequest
+	(*UsageResponse)(nil),            // 13: images.UsageResponse
 }
 var file_proto_image_proto_depIdxs = []int32{
-	2, // 0: images.ImageResponse.thumbnails:type_name -> images.ThumbnailShort
-	0, // 1: images.ImageService.UploadImage:input_type -> images.UploadImageRequest
-	1, // 2: images.ImageService.GetImage:input_type -> images.GetImageRequest
-	3, // 3: images.ImageService.UploadImage:output_type -> images.ImageResponse
-	3, // 4: images.ImageService.GetImage:output_type -> images.ImageResponse
-	3, // [3:5] is the sub-list for method output_type
-	1, // [1:3] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	6,  // 0: images.ImageResponse.thumbnails:type_name -> images.ThumbnailShort
+	0,  // 1: images.ImageService.UploadImage:input_type -> images.UploadImageRequest
+	1,  // 2: images.ImageService.GetImage:input_type -> images.GetImageRequest
+	2,  // 3: images.ImageService.DeleteImage:input_type -> images.DeleteImageRequest
+	4,  // 4: images.ImageService.CreateAccessToken:input_type -> images.CreateAccessTokenRequest
+	8,  // 5: images.ImageService.ReprocessImage:input_type -> images.ReprocessImageRequest
+	9,  // 6: images.ImageService.BulkReprocess:input_type -> images.BulkReprocessRequest
+	10, // 7: images.ImageService.GetReprocessJob:input_type -> images.GetReprocessJobRequest
+	12, // 8: images.ImageService.GetUsage:input_type -> images.GetUsageRequest
+	7,  // 9: images.ImageService.UploadImage:output_type -> images.ImageResponse
+	7,  // 10: images.ImageService.GetImage:output_type -> images.ImageResponse
+	3,  // 11: images.ImageService.DeleteImage:output_type -> images.DeleteImageResponse
+	5,  // 12: images.ImageService.CreateAccessToken:output_type -> images.AccessTokenResponse
+	7,  // 13: images.ImageService.ReprocessImage:output_type -> images.ImageResponse
+	11, // 14: images.ImageService.BulkReprocess:output_type -> images.ReprocessJobResponse
+	11, // 15: images.ImageService.GetReprocessJob:output_type -> images.ReprocessJobResponse
+	13, // 16: images.ImageService.GetUsage:output_type -> images.UsageResponse
+	9,  // [9:17] is the sub-list for method output_type
+	1,  // [1:9] is the sub-list for method input_type
+	1,  // [1:1] is the sub-list for extension type_name
+	1,  // [1:1] is the sub-list for extension extendee
+	0,  // [0:1] is the sub-list for field type_name
 }
 
 func init() { file_proto_image_proto_init() }
@@ -318,14 +1032,18 @@ func file_proto_image_proto_init() {
 	if File_proto_image_proto != nil {
 		return
 	}
-	file_proto_image_proto_msgTypes[3].OneofWrappers = []any{}
+	file_proto_image_proto_msgTypes[0].OneofWrappers = []any{}
+	file_proto_image_proto_msgTypes[4].OneofWrappers = []any{}
+	file_proto_image_proto_msgTypes[7].OneofWrappers = []any{}
+	file_proto_image_proto_msgTypes[9].OneofWrappers = []any{}
+	file_proto_image_proto_msgTypes[11].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_image_proto_rawDesc), len(file_proto_image_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
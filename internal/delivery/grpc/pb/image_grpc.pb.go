@@ -19,8 +19,14 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ImageService_UploadImage_FullMethodName = "/images.ImageService/UploadImage"
-	ImageService_GetImage_FullMethodName    = "/images.ImageService/GetImage"
+	ImageService_UploadImage_FullMethodName       = "/images.ImageService/UploadImage"
+	ImageService_GetImage_FullMethodName          = "/images.ImageService/GetImage"
+	ImageService_DeleteImage_FullMethodName       = "/images.ImageService/DeleteImage"
+	ImageService_CreateAccessToken_FullMethodName = "/images.ImageService/CreateAccessToken"
+	ImageService_ReprocessImage_FullMethodName    = "/images.ImageService/ReprocessImage"
+	ImageService_BulkReprocess_FullMethodName     = "/images.ImageService/BulkReprocess"
+	ImageService_GetReprocessJob_FullMethodName   = "/images.ImageService/GetReprocessJob"
+	ImageService_GetUsage_FullMethodName          = "/images.ImageService/GetUsage"
 )
 
 // ImageServiceClient is the client API for ImageService service.
@@ -29,6 +35,12 @@ const (
 type ImageServiceClient interface {
 	UploadImage(ctx context.Context, in *UploadImageRequest, opts ...grpc.CallOption) (*ImageResponse, error)
 	GetImage(ctx context.Context, in *GetImageRequest, opts ...grpc.CallOption) (*ImageResponse, error)
+	DeleteImage(ctx context.Context, in *DeleteImageRequest, opts ...grpc.CallOption) (*DeleteImageResponse, error)
+	CreateAccessToken(ctx context.Context, in *CreateAccessTokenRequest, opts ...grpc.CallOption) (*AccessTokenResponse, error)
+	ReprocessImage(ctx context.Context, in *ReprocessImageRequest, opts ...grpc.CallOption) (*ImageResponse, error)
+	BulkReprocess(ctx context.Context, in *BulkReprocessRequest, opts ...grpc.CallOption) (*ReprocessJobResponse, error)
+	GetReprocessJob(ctx context.Context, in *GetReprocessJobRequest, opts ...grpc.CallOption) (*ReprocessJobResponse, error)
+	GetUsage(ctx context.Context, in *GetUsageRequest, opts ...grpc.CallOption) (*UsageResponse, error)
 }
 
 type imageServiceClient struct {
@@ -59,12 +71,78 @@ func (c *imageServiceClient) GetImage(ctx context.Context, in *GetImageRequest,
 	return out, nil
 }
 
+func (c *imageServiceClient) DeleteImage(ctx context.Context, in *DeleteImageRequest, opts ...grpc.CallOption) (*DeleteImageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteImageResponse)
+	err := c.cc.Invoke(ctx, ImageService_DeleteImage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageServiceClient) CreateAccessToken(ctx context.Context, in *CreateAccessTokenRequest, opts ...grpc.CallOption) (*AccessTokenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AccessTokenResponse)
+	err := c.cc.Invoke(ctx, ImageService_CreateAccessToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageServiceClient) ReprocessImage(ctx context.Context, in *ReprocessImageRequest, opts ...grpc.CallOption) (*ImageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImageResponse)
+	err := c.cc.Invoke(ctx, ImageService_ReprocessImage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageServiceClient) BulkReprocess(ctx context.Context, in *BulkReprocessRequest, opts ...grpc.CallOption) (*ReprocessJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReprocessJobResponse)
+	err := c.cc.Invoke(ctx, ImageService_BulkReprocess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageServiceClient) GetReprocessJob(ctx context.Context, in *GetReprocessJobRequest, opts ...grpc.CallOption) (*ReprocessJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReprocessJobResponse)
+	err := c.cc.Invoke(ctx, ImageService_GetReprocessJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageServiceClient) GetUsage(ctx context.Context, in *GetUsageRequest, opts ...grpc.CallOption) (*UsageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UsageResponse)
+	err := c.cc.Invoke(ctx, ImageService_GetUsage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ImageServiceServer is the server API for ImageService service.
 // All implementations must embed UnimplementedImageServiceServer
 // for forward compatibility.
 type ImageServiceServer interface {
 	UploadImage(context.Context, *UploadImageRequest) (*ImageResponse, error)
 	GetImage(context.Context, *GetImageRequest) (*ImageResponse, error)
+	DeleteImage(context.Context, *DeleteImageRequest) (*DeleteImageResponse, error)
+	CreateAccessToken(context.Context, *CreateAccessTokenRequest) (*AccessTokenResponse, error)
+	ReprocessImage(context.Context, *ReprocessImageRequest) (*ImageResponse, error)
+	BulkReprocess(context.Context, *BulkReprocessRequest) (*ReprocessJobResponse, error)
+	GetReprocessJob(context.Context, *GetReprocessJobRequest) (*ReprocessJobResponse, error)
+	GetUsage(context.Context, *GetUsageRequest) (*UsageResponse, error)
 	mustEmbedUnimplementedImageServiceServer()
 }
 
@@ -81,6 +159,24 @@ func (UnimplementedImageServiceServer) UploadImage(context.Context, *UploadImage
 func (UnimplementedImageServiceServer) GetImage(context.Context, *GetImageRequest) (*ImageResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetImage not implemented")
 }
+func (UnimplementedImageServiceServer) DeleteImage(context.Context, *DeleteImageRequest) (*DeleteImageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteImage not implemented")
+}
+func (UnimplementedImageServiceServer) CreateAccessToken(context.Context, *CreateAccessTokenRequest) (*AccessTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAccessToken not implemented")
+}
+func (UnimplementedImageServiceServer) ReprocessImage(context.Context, *ReprocessImageRequest) (*ImageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReprocessImage not implemented")
+}
+func (UnimplementedImageServiceServer) BulkReprocess(context.Context, *BulkReprocessRequest) (*ReprocessJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BulkReprocess not implemented")
+}
+func (UnimplementedImageServiceServer) GetReprocessJob(context.Context, *GetReprocessJobRequest) (*ReprocessJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReprocessJob not implemented")
+}
+func (UnimplementedImageServiceServer) GetUsage(context.Context, *GetUsageRequest) (*UsageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsage not implemented")
+}
 func (UnimplementedImageServiceServer) mustEmbedUnimplementedImageServiceServer() {}
 func (UnimplementedImageServiceServer) testEmbeddedByValue()                      {}
 
@@ -138,6 +234,114 @@ func _ImageService_GetImage_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ImageService_DeleteImage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteImageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).DeleteImage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_DeleteImage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).DeleteImage(ctx, req.(*DeleteImageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageService_CreateAccessToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAccessTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).CreateAccessToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_CreateAccessToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).CreateAccessToken(ctx, req.(*CreateAccessTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageService_ReprocessImage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReprocessImageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).ReprocessImage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_ReprocessImage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).ReprocessImage(ctx, req.(*ReprocessImageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageService_BulkReprocess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BulkReprocessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).BulkReprocess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_BulkReprocess_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).BulkReprocess(ctx, req.(*BulkReprocessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageService_GetReprocessJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReprocessJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).GetReprocessJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_GetReprocessJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).GetReprocessJob(ctx, req.(*GetReprocessJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageService_GetUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).GetUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_GetUsage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).GetUsage(ctx, req.(*GetUsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ImageService_ServiceDesc is the grpc.ServiceDesc for ImageService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,6 +357,30 @@ var ImageService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetImage",
 			Handler:    _ImageService_GetImage_Handler,
 		},
+		{
+			MethodName: "DeleteImage",
+			Handler:    _ImageService_DeleteImage_Handler,
+		},
+		{
+			MethodName: "CreateAccessToken",
+			Handler:    _ImageService_CreateAccessToken_Handler,
+		},
+		{
+			MethodName: "ReprocessImage",
+			Handler:    _ImageService_ReprocessImage_Handler,
+		},
+		{
+			MethodName: "BulkReprocess",
+			Handler:    _ImageService_BulkReprocess_Handler,
+		},
+		{
+			MethodName: "GetReprocessJob",
+			Handler:    _ImageService_GetReprocessJob_Handler,
+		},
+		{
+			MethodName: "GetUsage",
+			Handler:    _ImageService_GetUsage_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/image.proto",
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/utils"
+	"net/http"
+	"strings"
+)
+
+// FileHandler streams stored files through the service, for clients that cannot reach the storage.
+type FileHandler struct {
+	fileUseCase ports.FileUseCase
+	disposition utils.Disposition
+	// cacheControl is the Cache-Control header per visibility; empty values send none.
+	cacheControl map[domain.Visibility]string
+}
+
+func NewFileHandler(fileUseCase ports.FileUseCase, disposition utils.Disposition, publicCacheControl, privateCacheControl string) *FileHandler {
+	return &FileHandler{
+		fileUseCase: fileUseCase,
+		disposition: disposition,
+		cacheControl: map[domain.Visibility]string{
+			domain.VisibilityPublic:  publicCacheControl,
+			domain.VisibilityPrivate: privateCacheControl,
+		},
+	}
+}
+
+func (h *FileHandler) GetFile(c *gin.Context) {
+	file, err := h.fileUseCase.Open(c.Request.Context(), strings.TrimPrefix(c.Param("key"), "/"))
+	if err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	h.serve(c, file)
+}
+
+func (h *FileHandler) GetOriginal(c *gin.Context) {
+	id := c.Param("id")
+	if uuid.Validate(id) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	file, err := h.fileUseCase.OpenOriginal(c.Request.Context(), id)
+	if err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	h.serve(c, file)
+}
+
+func (h *FileHandler) GetThumbnail(c *gin.Context) {
+	id := c.Param("id")
+	if uuid.Validate(id) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	file, err := h.fileUseCase.OpenThumbnail(c.Request.Context(), id, c.Param("label"))
+	if err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	h.serve(c, file)
+}
+
+// serve streams file with http.ServeContent, which answers Range, If-Range, If-None-Match and
+// If-Modified-Since requests from the ETag and Last-Modified of the object.
+func (h *FileHandler) serve(c *gin.Context, file *ports.File) {
+	defer file.Object.Close()
+
+	header := c.Writer.Header()
+	if file.Object.ContentType != "" {
+		header.Set("Content-Type", file.Object.ContentType)
+	}
+	header.Set("ETag", file.Object.ETag)
+	header.Set("Content-Disposition", h.disposition.Header(file.Key, file.Object.ContentType))
+	if cacheControl := h.cacheControl[file.Image.Visibility]; cacheControl != "" {
+		header.Set("Cache-Control", cacheControl)
+	}
+	// Files are served from the origin of the API, so an uploaded SVG must not run scripts here.
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Content-Security-Policy", "default-src 'none'; sandbox")
+
+	http.ServeContent(c.Writer, c.Request, "", file.Object.LastModified, file.Object)
+}
+
+func respondFileError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrFileNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
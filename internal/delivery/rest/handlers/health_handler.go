@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"image-resizing-service/internal/ports"
+	"net/http"
+)
+
+type HealthHandler struct {
+	healthUseCase ports.HealthUseCase
+}
+
+func NewHealthHandler(healthUseCase ports.HealthUseCase) *HealthHandler {
+	return &HealthHandler{
+		healthUseCase: healthUseCase,
+	}
+}
+
+// Liveness reports that the process is up and serving HTTP. It checks no dependencies, so a broken
+// database or storage connection makes the pod unready rather than restarting it.
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readiness runs every dependency check and answers 503 when any of them fails.
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.healthUseCase.Ready(c.Request.Context())
+
+	statusCode := http.StatusOK
+	if !report.Ready {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	c.JSON(statusCode, report)
+}
@@ -1,28 +1,34 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"image-resizing-service/internal/assembler"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/dto"
 	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/metrics"
 	"image-resizing-service/pkg/utils"
 	"net/http"
+	"net/url"
+	"time"
 )
 
-var allowedTypes = map[string]bool{
-	"image/jpeg": true,
-	"image/png":  true,
-	"image/webp": true,
-	"image/gif":  true,
-}
+// quotaWarningHeader carries the warning of an upload that reached a soft quota of the tenant.
+const quotaWarningHeader = "X-Quota-Warning"
 
 type ImageHandler struct {
 	imageUseCase       ports.ImageUseCase
+	authUseCase        ports.AuthUseCase
 	restImageAssembler *assembler.RestImageAssembler
 }
 
-func NewImageHandler(imageUseCase ports.ImageUseCase, restImageAssembler *assembler.RestImageAssembler) *ImageHandler {
+func NewImageHandler(imageUseCase ports.ImageUseCase, authUseCase ports.AuthUseCase, restImageAssembler *assembler.RestImageAssembler) *ImageHandler {
 	return &ImageHandler{
 		imageUseCase:       imageUseCase,
+		authUseCase:        authUseCase,
 		restImageAssembler: restImageAssembler,
 	}
 }
@@ -30,15 +36,15 @@ func NewImageHandler(imageUseCase ports.ImageUseCase, restImageAssembler *assemb
 func (h *ImageHandler) UploadImage(c *gin.Context) {
 	file, err := c.FormFile("file")
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondUploadError(c, err)
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
 		return
 	}
 
-	if !allowedTypes[file.Header.Get("Content-Type")] {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported content type"})
-		return
-	}
-
 	src, err := file.Open()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open uploaded file"})
@@ -46,59 +52,192 @@ func (h *ImageHandler) UploadImage(c *gin.Context) {
 	}
 	defer src.Close()
 
-	tempFilePath, _, err := utils.SaveTempFile(src)
+	tempFilePath, contentType, err := utils.SaveTempFile(src)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save temp file"})
 		return
 	}
 	defer utils.RemoveFile(tempFilePath)
 
-	result, err := h.imageUseCase.UploadOriginal(c.Request.Context(), tempFilePath, file.Header.Get("Content-Type"))
+	if !utils.IsSupportedContentType(contentType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported content type"})
+		return
+	}
+
+	visibility := domain.Visibility(c.PostForm("visibility"))
+	result, err := h.imageUseCase.UploadOriginal(metrics.WithTransport(c.Request.Context(), metrics.TransportREST), tempFilePath, contentType, visibility)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondUploadError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, h.restImageAssembler.BuildImage(result))
+	if result.QuotaWarning != "" {
+		c.Header(quotaWarningHeader, result.QuotaWarning)
+	}
+	c.JSON(http.StatusOK, h.restImageAssembler.BuildImage(c.Request.Context(), result))
 }
 
 func (h *ImageHandler) UploadImageBinary(c *gin.Context) {
-	contentType := c.GetHeader("Content-Type")
-	if contentType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing Content-Type header"})
-		return
-	}
-
-	tempFilePath, _, err := utils.SaveTempFile(c.Request.Body)
+	tempFilePath, contentType, err := utils.SaveTempFile(c.Request.Body)
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondUploadError(c, err)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save temp file"})
 		return
 	}
 	defer utils.RemoveFile(tempFilePath)
 
-	result, err := h.imageUseCase.UploadOriginal(c.Request.Context(), tempFilePath, contentType)
+	if !utils.IsSupportedContentType(contentType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported content type"})
+		return
+	}
+
+	visibility := domain.Visibility(c.Query("visibility"))
+	result, err := h.imageUseCase.UploadOriginal(metrics.WithTransport(c.Request.Context(), metrics.TransportREST), tempFilePath, contentType, visibility)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to upload image", "details": err.Error()})
+		respondUploadError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, h.restImageAssembler.BuildImage(result))
+	if result.QuotaWarning != "" {
+		c.Header(quotaWarningHeader, result.QuotaWarning)
+	}
+	c.JSON(http.StatusOK, h.restImageAssembler.BuildImage(c.Request.Context(), result))
 }
 
 func (h *ImageHandler) GetImage(c *gin.Context) {
 	id := c.Param("id")
+	if uuid.Validate(id) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if cached := h.restImageAssembler.CachedImage(c.Request.Context(), id); cached != nil {
+		c.JSON(http.StatusOK, cached)
+		return
+	}
 
 	image, err := h.imageUseCase.FindByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondProcessingError(c, err)
+		return
+	}
+
+	imageWithThumbnails := h.restImageAssembler.BuildImageLookup(c.Request.Context(), image)
+	c.JSON(http.StatusOK, imageWithThumbnails)
+}
+
+func (h *ImageHandler) DeleteImage(c *gin.Context) {
+	id := c.Param("id")
+	if uuid.Validate(id) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
 
-	if image == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "image not found"})
+	if err := h.imageUseCase.Delete(c.Request.Context(), id); err != nil {
+		respondProcessingError(c, err)
 		return
 	}
 
-	imageWithThumbnails := h.restImageAssembler.BuildImageWithThumbnails(image)
-	c.JSON(http.StatusOK, imageWithThumbnails)
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ImageHandler) CreateAccessToken(c *gin.Context) {
+	id := c.Param("id")
+	if uuid.Validate(id) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req dto.AccessTokenRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	image, err := h.imageUseCase.FindByID(c.Request.Context(), id)
+	if err != nil {
+		respondProcessingError(c, err)
+		return
+	}
+
+	accessToken, err := h.authUseCase.IssueAccessToken(c.Request.Context(), image, time.Duration(req.TTLSeconds)*time.Second)
+	if err != nil {
+		respondProcessingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.AccessToken{
+		Token:     accessToken.Token,
+		ExpiresAt: accessToken.ExpiresAt,
+		URL:       fmt.Sprintf("/image/%s?access_token=%s", image.ID, url.QueryEscape(accessToken.Token)),
+	})
+}
+
+func (h *ImageHandler) ReprocessImage(c *gin.Context) {
+	id := c.Param("id")
+	if uuid.Validate(id) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req dto.ReprocessImageRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	image, err := h.imageUseCase.Reprocess(c.Request.Context(), id, req.Presets)
+	if err != nil {
+		respondProcessingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, h.restImageAssembler.BuildImageWithThumbnails(c.Request.Context(), image))
+}
+
+func respondProcessingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrImageNotFound), errors.Is(err, domain.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrUnknownPreset):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrProcessingInProgress):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrShuttingDown):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrAccessTokenDisabled):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func respondUploadError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	var limitErr *utils.LimitError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("upload exceeds the maximum allowed size of %d bytes", maxBytesErr.Limit)})
+	case errors.As(err, &limitErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": limitErr.Error()})
+	case errors.Is(err, utils.ErrUnsupportedFormat):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported content type"})
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		c.JSON(http.StatusInsufficientStorage, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrInvalidImage), errors.Is(err, domain.ErrInvalidVisibility):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrShuttingDown):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to upload image", "details": err.Error()})
+	}
 }
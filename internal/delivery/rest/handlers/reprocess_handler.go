@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"image-resizing-service/internal/assembler"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/dto"
+	"image-resizing-service/internal/ports"
+	"net/http"
+)
+
+type ReprocessHandler struct {
+	reprocessUseCase   ports.ReprocessUseCase
+	restImageAssembler *assembler.RestImageAssembler
+}
+
+func NewReprocessHandler(reprocessUseCase ports.ReprocessUseCase, restImageAssembler *assembler.RestImageAssembler) *ReprocessHandler {
+	return &ReprocessHandler{
+		reprocessUseCase:   reprocessUseCase,
+		restImageAssembler: restImageAssembler,
+	}
+}
+
+func (h *ReprocessHandler) StartBulkReprocess(c *gin.Context) {
+	var req dto.BulkReprocessRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if req.CreatedFrom != nil && req.CreatedTo != nil && !req.CreatedFrom.Before(*req.CreatedTo) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "created_from must be before created_to"})
+		return
+	}
+
+	job, err := h.reprocessUseCase.StartBulk(c.Request.Context(), domain.ReprocessFilter{
+		Status:      domain.ImageStatus(req.Status),
+		CreatedFrom: req.CreatedFrom,
+		CreatedTo:   req.CreatedTo,
+		Presets:     req.Presets,
+	})
+	if err != nil {
+		respondProcessingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, h.restImageAssembler.BuildReprocessJob(job))
+}
+
+func (h *ReprocessHandler) GetReprocessJob(c *gin.Context) {
+	id := c.Param("id")
+	if uuid.Validate(id) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	job, err := h.reprocessUseCase.FindJob(c.Request.Context(), id)
+	if err != nil {
+		respondProcessingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.restImageAssembler.BuildReprocessJob(job))
+}
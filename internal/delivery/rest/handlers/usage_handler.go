@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"image-resizing-service/internal/assembler"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"net/http"
+)
+
+type UsageHandler struct {
+	usageUseCase       ports.UsageUseCase
+	restImageAssembler *assembler.RestImageAssembler
+}
+
+func NewUsageHandler(usageUseCase ports.UsageUseCase, restImageAssembler *assembler.RestImageAssembler) *UsageHandler {
+	return &UsageHandler{
+		usageUseCase:       usageUseCase,
+		restImageAssembler: restImageAssembler,
+	}
+}
+
+// GetUsage reports the storage used by the caller's tenant and its quota.
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	tenantID, ok := domain.TenantFromContext(c.Request.Context())
+	if !ok {
+		tenantID = domain.DefaultTenantID
+	}
+
+	usage, err := h.usageUseCase.Usage(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.restImageAssembler.BuildUsage(usage))
+}
@@ -1,37 +1,261 @@
 package rest
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.uber.org/zap"
 	"image-resizing-service/internal/assembler"
 	"image-resizing-service/internal/delivery/rest/handlers"
+	"image-resizing-service/internal/domain"
 	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/config"
+	"image-resizing-service/pkg/logging"
+	"image-resizing-service/pkg/metrics"
+	"image-resizing-service/pkg/utils"
 	"net/http"
-	"os"
+	"strings"
+	"time"
 )
 
-func InitRoutes(router *gin.Engine, imageUseCase ports.ImageUseCase, restImageAssembler *assembler.RestImageAssembler) {
-	imageHandler := handlers.NewImageHandler(imageUseCase, restImageAssembler)
+func InitRoutes(router *gin.Engine, cfg *config.Config, logger *logging.Logger, imageUseCase ports.ImageUseCase, reprocessUseCase ports.ReprocessUseCase, healthUseCase ports.HealthUseCase, authUseCase ports.AuthUseCase, rateLimitUseCase ports.RateLimitUseCase, usageUseCase ports.UsageUseCase, fileUseCase ports.FileUseCase, restImageAssembler *assembler.RestImageAssembler) {
+	imageHandler := handlers.NewImageHandler(imageUseCase, authUseCase, restImageAssembler)
+	reprocessHandler := handlers.NewReprocessHandler(reprocessUseCase, restImageAssembler)
+	healthHandler := handlers.NewHealthHandler(healthUseCase)
+	usageHandler := handlers.NewUsageHandler(usageUseCase, restImageAssembler)
+	fileHandler := handlers.NewFileHandler(
+		fileUseCase,
+		utils.Disposition{Type: cfg.Access.Disposition, Filename: cfg.Access.DownloadFilename},
+		cfg.Files.PublicCacheControl,
+		cfg.Files.PrivateCacheControl,
+	)
 
-	router.POST("/image/upload", UploadAuthMiddleware(), imageHandler.UploadImage)
-	router.POST("/image/upload-binary", UploadAuthMiddleware(), imageHandler.UploadImageBinary)
-	router.GET("/image/:id", imageHandler.GetImage)
+	// Probes and scrapes are neither traced nor logged at info level.
+	isProbe := func(path string) bool {
+		return path == "/healthz" || path == "/readyz" || path == cfg.Metrics.Path
+	}
+
+	router.Use(
+		otelgin.Middleware(cfg.Tracing.ServiceName, otelgin.WithFilter(func(r *http.Request) bool { return !isProbe(r.URL.Path) })),
+		RequestIDMiddleware(),
+		AccessLogMiddleware(logger, isProbe),
+		gin.CustomRecovery(func(c *gin.Context, recovered any) {
+			logger.Ctx(c.Request.Context()).Error("panic in http handler", zap.Any("recover", recovered))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}),
+	)
+
+	router.GET("/healthz", healthHandler.Liveness)
+	router.GET("/readyz", healthHandler.Readiness)
+	if cfg.Metrics.Enabled {
+		router.GET(cfg.Metrics.Path, gin.WrapH(metrics.Handler()))
+	}
+
+	authenticated := router.Group("", AuthMiddleware(authUseCase, cfg.HTTP.UploadToken))
+	uploadLimit := RateLimitMiddleware(rateLimitUseCase, domain.RateLimitUpload)
+	readLimit := RateLimitMiddleware(rateLimitUseCase, domain.RateLimitRead)
+
+	authenticated.POST("/image/upload", RequireScope(domain.ScopeImagesUpload), uploadLimit, UploadSizeLimitMiddleware(cfg.HTTP.MaxUploadBytes), imageHandler.UploadImage)
+	authenticated.POST("/image/upload-binary", RequireScope(domain.ScopeImagesUpload), uploadLimit, UploadSizeLimitMiddleware(cfg.HTTP.MaxUploadBytes), imageHandler.UploadImageBinary)
+	authenticated.GET("/image/:id", RequireScope(domain.ScopeImagesRead), readLimit, imageHandler.GetImage)
+	authenticated.DELETE("/image/:id", RequireScope(domain.ScopeImagesDelete), uploadLimit, imageHandler.DeleteImage)
+	authenticated.POST("/image/:id/access-token", RequireScope(domain.ScopeImagesRead), readLimit, imageHandler.CreateAccessToken)
+	authenticated.POST("/image/:id/reprocess", RequireScope(domain.ScopeImagesUpload), uploadLimit, imageHandler.ReprocessImage)
+
+	authenticated.GET("/usage", RequireScope(domain.ScopeImagesRead), readLimit, usageHandler.GetUsage)
+
+	files := router.Group("", FileAuthMiddleware(authUseCase, cfg.HTTP.UploadToken), readLimit)
+	files.GET("/files/*key", fileHandler.GetFile)
+	files.GET("/image/:id/original", fileHandler.GetOriginal)
+	files.GET("/image/:id/thumb/:label", fileHandler.GetThumbnail)
+
+	authenticated.POST("/reprocess-jobs", RequireScope(domain.ScopeAdmin), reprocessHandler.StartBulkReprocess)
+	authenticated.GET("/reprocess-jobs/:id", RequireScope(domain.ScopeAdmin), reprocessHandler.GetReprocessJob)
+}
+
+// RequestIDMiddleware attaches a request_id to the request context and echoes it in the X-Request-ID
+// response header. A well-formed X-Request-ID sent by the client, e.g. a proxy, is kept.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := logging.RequestIDFrom(c.GetHeader(logging.RequestIDHeader))
+
+		c.Header(logging.RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), requestID))
+		c.Next()
+	}
+}
+
+// AccessLogMiddleware writes one line per request once it has been handled. Requests matching
+// quiet are logged at debug level.
+func AccessLogMiddleware(logger *logging.Logger, quiet func(path string) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		startedAt := time.Now()
+		c.Next()
+
+		level := zap.InfoLevel
+		switch {
+		case c.Writer.Status() >= http.StatusInternalServerError:
+			level = zap.ErrorLevel
+		case quiet(c.Request.URL.Path):
+			level = zap.DebugLevel
+		}
+
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.Int("status", c.Writer.Status()),
+			zap.Duration("duration", time.Since(startedAt)),
+			zap.String("client_ip", c.ClientIP()),
+		}
+		if len(c.Errors) > 0 {
+			fields = append(fields, zap.String("errors", c.Errors.String()))
+		}
+
+		logger.Ctx(c.Request.Context()).Log(level, "http request", fields...)
+	}
+}
+
+// principalKey stores the domain.Principal of the request in the gin context.
+const principalKey = "principal"
+
+// AuthMiddleware authenticates the request from its "Authorization: Bearer" token or X-API-Key header
+// and scopes it to the tenant of the caller. uploadToken is the legacy X-API-Key of the default tenant.
+// GET requests may instead carry an image access token in the access_token query parameter; it only
+// opens the route whose :id is the image it was issued for.
+func AuthMiddleware(authUseCase ports.AuthUseCase, uploadToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := authUseCase.Authenticate(c.Request.Context(), requestCredentials(c, uploadToken))
+		if errors.Is(err, domain.ErrUnauthenticated) {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if principal.ImageID != "" && principal.ImageID != c.Param("id") {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "the access token is for another image"})
+			return
+		}
+
+		ctx := domain.WithTenant(c.Request.Context(), principal.TenantID)
+		ctx = domain.WithPrincipal(ctx, principal)
+		ctx = logging.With(ctx, zap.String("tenant_id", principal.TenantID), zap.String("subject", principal.Subject))
+		c.Request = c.Request.WithContext(ctx)
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// FileAuthMiddleware authenticates like AuthMiddleware, but lets requests without credentials through
+// as an anonymous principal without tenant or scopes, which can read the files of public images. The
+// request is not scoped to the tenant of the caller: public files of every tenant are served, and
+// ports.FileUseCase checks private ones against the principal.
+func FileAuthMiddleware(authUseCase ports.AuthUseCase, uploadToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		credentials := requestCredentials(c, uploadToken)
+		principal, err := authUseCase.Authenticate(c.Request.Context(), credentials)
+		if errors.Is(err, domain.ErrUnauthenticated) && credentials.BearerToken == "" && credentials.APIKey == "" && credentials.AccessToken == "" {
+			principal, err = &domain.Principal{Subject: domain.AnonymousSubject}, nil
+		}
+		if errors.Is(err, domain.ErrUnauthenticated) {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx := domain.WithPrincipal(c.Request.Context(), principal)
+		ctx = logging.With(ctx, zap.String("tenant_id", principal.TenantID), zap.String("subject", principal.Subject))
+		c.Request = c.Request.WithContext(ctx)
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// requestCredentials reads the credentials presented by the request.
+func requestCredentials(c *gin.Context, uploadToken string) ports.Credentials {
+	credentials := ports.Credentials{
+		APIKey:      c.GetHeader("X-API-Key"),
+		LegacyToken: uploadToken,
+	}
+	if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		credentials.BearerToken = strings.TrimSpace(token)
+	}
+	if c.Request.Method == http.MethodGet {
+		credentials.AccessToken = c.Query("access_token")
+	}
+
+	return credentials
+}
+
+// RequireScope rejects requests whose principal, set by AuthMiddleware, lacks scope.
+func RequireScope(scope domain.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := c.MustGet(principalKey).(*domain.Principal)
+		if !ok || !principal.Allows(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("%s: %s", domain.ErrForbidden, scope)})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RateLimitMiddleware counts the request against the rate limits of class for its principal, set by
+// AuthMiddleware, and client IP. Rejected requests get 429 with Retry-After. A nil rateLimitUseCase
+// disables rate limiting.
+func RateLimitMiddleware(rateLimitUseCase ports.RateLimitUseCase, class domain.RateLimitClass) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rateLimitUseCase == nil {
+			c.Next()
+			return
+		}
+
+		principal := c.MustGet(principalKey).(*domain.Principal)
+		rateLimit, err := rateLimitUseCase.Allow(c.Request.Context(), class, principal, c.ClientIP())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		if rateLimit == nil {
+			c.Next()
+			return
+		}
+
+		for name, value := range rateLimit.Headers() {
+			c.Header(name, value)
+		}
+		if !rateLimit.Allowed {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("%s rate limit per %s exceeded", class, rateLimit.Exhausted)})
+			return
+		}
+
+		c.Next()
+	}
 }
 
-func UploadAuthMiddleware() gin.HandlerFunc {
+// UploadSizeLimitMiddleware caps the request body at maxBytes (0 disables the cap).
+func UploadSizeLimitMiddleware(maxBytes int64) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		uploadToken := os.Getenv("REST_UPLOAD_TOKEN")
-		if uploadToken == "" {
+		if maxBytes <= 0 {
 			c.Next()
 			return
 		}
 
-		if c.GetHeader("X-API-Key") != uploadToken {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "unauthorized",
+		if c.Request.ContentLength > maxBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": fmt.Sprintf("upload exceeds the maximum allowed size of %d bytes", maxBytes),
 			})
 			return
 		}
 
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
 		c.Next()
 	}
 }
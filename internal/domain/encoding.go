@@ -0,0 +1,36 @@
+package domain
+
+type EncodingMode string
+
+const (
+	EncodingLossy        EncodingMode = "lossy"
+	EncodingLossless     EncodingMode = "lossless"
+	EncodingNearLossless EncodingMode = "near_lossless"
+	// EncodingAuto picks lossless for flat-colour or transparent images and lossy for photos.
+	EncodingAuto EncodingMode = "auto"
+)
+
+// EncodingSettings controls how a rendition is encoded.
+type EncodingSettings struct {
+	Mode EncodingMode
+	// Quality is the lossy quality factor, 0-100.
+	Quality float32
+	// NearLosslessLevel is the near-lossless preprocessing level, 0-100. Lower values discard more precision.
+	NearLosslessLevel int
+	// ExactAlpha preserves RGB values under fully transparent pixels in lossless output.
+	ExactAlpha bool
+	// TryLossless makes the auto mode also encode photos losslessly and keep that output when it is not
+	// larger. It doubles the encoding work for photos.
+	TryLossless bool
+	// KeepWideGamut keeps wide-gamut pixels and embeds the source ICC profile instead of converting to sRGB.
+	KeepWideGamut bool
+}
+
+var DefaultEncoding = EncodingSettings{
+	Mode:              EncodingAuto,
+	Quality:           80,
+	NearLosslessLevel: 60,
+}
+
+// CompressedEncoding is applied to the full-size compressed rendition.
+var CompressedEncoding = DefaultEncoding
@@ -0,0 +1,24 @@
+package domain
+
+import "errors"
+
+var (
+	ErrImageNotFound        = errors.New("image not found")
+	ErrUnknownPreset        = errors.New("unknown or disabled thumbnail preset")
+	ErrProcessingInProgress = errors.New("image is already being processed")
+	ErrJobNotFound          = errors.New("job not found")
+	ErrPresetExists         = errors.New("thumbnail preset already exists")
+	ErrInvalidPreset        = errors.New("invalid thumbnail preset")
+	ErrShuttingDown         = errors.New("service is shutting down")
+	ErrTenantNotFound       = errors.New("tenant not found")
+	ErrTenantExists         = errors.New("tenant already exists")
+	ErrInvalidTenant        = errors.New("invalid tenant")
+	ErrInvalidAPIKey        = errors.New("invalid api key")
+	ErrAPIKeyNotFound       = errors.New("api key not found")
+	ErrUnauthenticated      = errors.New("unauthenticated")
+	ErrForbidden            = errors.New("missing required scope")
+	ErrInvalidVisibility    = errors.New("invalid visibility")
+	ErrAccessTokenDisabled  = errors.New("access tokens are disabled, APP_SECRET_KEY is not set")
+	ErrQuotaExceeded        = errors.New("storage quota exceeded")
+	ErrFileNotFound         = errors.New("file not found")
+)
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"fmt"
 	"gorm.io/gorm"
 )
 
@@ -13,16 +14,81 @@ const (
 	StatusError      ImageStatus = "error"
 )
 
+// Visibility controls who can read the files of an image.
+type Visibility string
+
+const (
+	// VisibilityPrivate files are only handed out as short-lived presigned URLs, to readers of the
+	// tenant or holders of an access token for the image.
+	VisibilityPrivate Visibility = "private"
+	// VisibilityPublic files get long-lived or unsigned CDN URLs that can be shared freely.
+	VisibilityPublic Visibility = "public"
+)
+
+// ParseVisibility validates a visibility given by a client. An empty value selects fallback.
+func ParseVisibility(value string, fallback Visibility) (Visibility, error) {
+	switch visibility := Visibility(value); visibility {
+	case "":
+		return fallback, nil
+	case VisibilityPrivate, VisibilityPublic:
+		return visibility, nil
+	default:
+		return "", fmt.Errorf("%w %q, expected private or public", ErrInvalidVisibility, value)
+	}
+}
+
+// URLStrategy decides how the files of images are linked.
+type URLStrategy string
+
+const (
+	// URLPresigned links every file with a presigned storage URL.
+	URLPresigned URLStrategy = "presigned"
+	// URLCDN links the files of public images unsigned under a CDN base URL, and those of private
+	// images as presigned URLs.
+	URLCDN URLStrategy = "cdn"
+	// URLProxy links every file through the service, which streams it from storage. The URLs of
+	// private files carry an access token for their image.
+	URLProxy URLStrategy = "proxy"
+)
+
 type Image struct {
-	ID            string      `gorm:"type:uuid;primaryKey"`
+	ID string `gorm:"type:uuid;primaryKey"`
+	// TenantID is nullable in the schema only so the column can be added to existing tables; the
+	// migrations assign those rows to the default tenant.
+	TenantID      string      `gorm:"type:uuid;index"`
 	OriginalKey   string      `gorm:"not null"`
 	CompressedKey string      `gorm:""`
 	Status        ImageStatus `gorm:"type:varchar(20);not null;default:'pending'"`
-	ErrorMessage  *string     `gorm:""`
-	Thumbnails    []Thumbnail `gorm:"foreignKey:ImageID;constraint:OnDelete:CASCADE;"`
+	Visibility    Visibility  `gorm:"type:varchar(10);not null;default:'private'"`
+	// ContentType of the original, detected from its bytes. Empty for images stored before it was recorded.
+	ContentType  string  `gorm:"type:varchar(100);not null;default:''"`
+	ErrorMessage *string `gorm:""`
+	ProcessingMs int64   `gorm:"not null;default:0"`
+	// Attempts counts how often the reaper re-enqueued processing after it got stuck; reset once the image is ready.
+	Attempts int `gorm:"not null;default:0"`
+	// OriginalBytes and CompressedBytes are the sizes of the stored objects, counted in the tenant's usage.
+	OriginalBytes   int64       `gorm:"not null;default:0"`
+	CompressedBytes int64       `gorm:"not null;default:0"`
+	Thumbnails      []Thumbnail `gorm:"foreignKey:ImageID;constraint:OnDelete:CASCADE;"`
 	gorm.Model
 }
 
+// TenantKeyPrefix starts the storage keys of every tenant. Images stored before tenants existed
+// keep their keys under uploads/.
+const TenantKeyPrefix = "tenants/"
+
+func OriginalKey(tenantID, imageID string) string {
+	return fmt.Sprintf("%s%s/originals/%s", TenantKeyPrefix, tenantID, imageID)
+}
+
+func CompressedKey(tenantID, imageID string) string {
+	return fmt.Sprintf("%s%s/compressed/%s.webp", TenantKeyPrefix, tenantID, imageID)
+}
+
+func ThumbnailKey(tenantID, imageID, preset string) string {
+	return fmt.Sprintf("%s%s/thumbnails/%s_%s.webp", TenantKeyPrefix, tenantID, imageID, preset)
+}
+
 /*func (img *Image) BeforeCreate(tx *gorm.DB) (err error) {
 	img.ID = uuid.New().String()
 	img.Status = StatusPending
@@ -0,0 +1,11 @@
+package domain
+
+// RateLimitClass groups the requests that share rate limits.
+type RateLimitClass string
+
+const (
+	// RateLimitUpload covers uploads, reprocessing and deletion, which change stored images.
+	RateLimitUpload RateLimitClass = "upload"
+	// RateLimitRead covers image lookups, access tokens and usage reports.
+	RateLimitRead RateLimitClass = "read"
+)
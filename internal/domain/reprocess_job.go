@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+)
+
+// ReprocessFilter selects the images of a bulk reprocess. Empty fields match every image;
+// Presets limits which thumbnails are regenerated, and the compressed rendition is only
+// regenerated when no preset is given.
+type ReprocessFilter struct {
+	Status      ImageStatus `gorm:"type:varchar(20)"`
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Presets     []string `gorm:"serializer:json"`
+}
+
+// ReprocessJob is a throttled background run over every image matching Filter.
+type ReprocessJob struct {
+	ID string `gorm:"type:uuid;primaryKey"`
+	// TenantID limits the job to one tenant's images. It is nil for jobs an operator started
+	// across every tenant.
+	TenantID  *string         `gorm:"type:uuid;index"`
+	Status    JobStatus       `gorm:"type:varchar(20);not null;default:'pending'"`
+	Filter    ReprocessFilter `gorm:"embedded;embeddedPrefix:filter_"`
+	Total     int             `gorm:"not null;default:0"`
+	Processed int             `gorm:"not null;default:0"`
+	Failed    int             `gorm:"not null;default:0"`
+	Skipped   int             `gorm:"not null;default:0"`
+	// Cursor is the ID of the last image handled, so an interrupted job resumes after it.
+	Cursor       string  `gorm:"type:varchar(36);not null;default:''"`
+	ErrorMessage *string `gorm:""`
+	StartedAt    *time.Time
+	FinishedAt   *time.Time
+	gorm.Model
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"slices"
+)
+
+// Scope is a permission granted to a caller, by its access token or API key.
+type Scope string
+
+const (
+	ScopeImagesUpload Scope = "images:upload"
+	ScopeImagesRead   Scope = "images:read"
+	ScopeImagesDelete Scope = "images:delete"
+	// ScopeAdmin grants every other scope and the operator endpoints, such as bulk reprocessing.
+	ScopeAdmin Scope = "admin"
+)
+
+// Scopes lists every scope, in the order they are documented.
+var Scopes = []Scope{ScopeImagesUpload, ScopeImagesRead, ScopeImagesDelete, ScopeAdmin}
+
+// TenantScopes are granted to tenant API keys: everything but ScopeAdmin.
+var TenantScopes = []Scope{ScopeImagesUpload, ScopeImagesRead, ScopeImagesDelete}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// Subject identifies the caller in logs and rate limits: the token subject, the API key prefix,
+	// "access-token:<image id>", "legacy-token" or AnonymousSubject.
+	Subject  string
+	TenantID string
+	Scopes   []Scope
+	// ImageID restricts the principal to a single image. It is set for image access tokens.
+	ImageID string
+}
+
+// AnonymousSubject is the subject of requests served without credentials.
+const AnonymousSubject = "anonymous"
+
+// Allows reports whether the principal was granted scope, directly or through ScopeAdmin.
+func (p *Principal) Allows(scope Scope) bool {
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeAdmin)
+}
+
+func IsValidScope(scope Scope) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal set by WithPrincipal, or nil for unauthenticated work.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
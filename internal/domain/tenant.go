@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultTenantID identifies the tenant created by the migrations. Images stored before tenants
+// existed belong to it, and so do requests when authentication is disabled.
+const DefaultTenantID = "00000000-0000-0000-0000-000000000001"
+
+const DefaultTenantName = "default"
+
+// Tenant owns images and reprocess jobs. Tenants cannot see each other's data.
+type Tenant struct {
+	ID      string   `gorm:"type:uuid;primaryKey"`
+	Name    string   `gorm:"type:varchar(50);not null;uniqueIndex"`
+	APIKeys []APIKey `gorm:"foreignKey:TenantID;constraint:OnDelete:CASCADE;"`
+	// Quota overrides the configured quota for this tenant.
+	Quota QuotaOverride `gorm:"embedded;embeddedPrefix:quota_"`
+	gorm.Model
+}
+
+func (tenant *Tenant) BeforeCreate(tx *gorm.DB) (err error) {
+	if tenant.ID == "" {
+		tenant.ID = uuid.New().String()
+	}
+
+	return
+}
+
+// APIKey authenticates requests as its tenant. Only a SHA-256 hash of the key is stored; Prefix
+// is kept in clear to tell keys apart when listing or revoking them.
+type APIKey struct {
+	ID       string `gorm:"type:uuid;primaryKey"`
+	TenantID string `gorm:"type:uuid;not null;index"`
+	Prefix   string `gorm:"type:varchar(16);not null;uniqueIndex"`
+	Hash     string `gorm:"type:char(64);not null;uniqueIndex"`
+	Tenant   *Tenant
+	gorm.Model
+}
+
+func (key *APIKey) BeforeCreate(tx *gorm.DB) (err error) {
+	if key.ID == "" {
+		key.ID = uuid.New().String()
+	}
+
+	return
+}
+
+type tenantKey struct{}
+
+// WithTenant scopes ctx to tenantID. Repositories bound to ctx only see the data of that tenant.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant set by WithTenant. Background work such as the reaper runs
+// without a tenant and sees every tenant's data.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
@@ -5,12 +5,28 @@ import (
 	"gorm.io/gorm"
 )
 
+// RenditionStatus tracks a single derived rendition so that a retry only regenerates what is missing.
+type RenditionStatus string
+
+const (
+	RenditionPending RenditionStatus = "pending"
+	RenditionReady   RenditionStatus = "ready"
+	RenditionFailed  RenditionStatus = "failed"
+)
+
 type Thumbnail struct {
-	ID      string    `gorm:"type:uuid;primaryKey"`
-	ImageID uuid.UUID `gorm:"type:uuid;not null;index:idx_thumbnails_image_id_size,unique"`
-	Size    string    `gorm:"type:varchar(50);not null;index:idx_thumbnails_image_id_size,unique"`
-	Key     string    `gorm:"not null"`
-	Type    string    `gorm:"type:varchar(20);not null"`
+	ID      string          `gorm:"type:uuid;primaryKey"`
+	ImageID uuid.UUID       `gorm:"type:uuid;not null;index:idx_thumbnails_image_id_size,unique"`
+	Size    string          `gorm:"type:varchar(50);not null;index:idx_thumbnails_image_id_size,unique"`
+	Key     string          `gorm:"not null"`
+	Type    string          `gorm:"type:varchar(20);not null"`
+	Status  RenditionStatus `gorm:"type:varchar(20);not null;default:'ready'"`
+	// ErrorMessage is set when the last attempt to render this preset failed.
+	ErrorMessage *string `gorm:""`
+	// ProcessingMs is the time spent resizing, encoding and uploading this rendition.
+	ProcessingMs int64 `gorm:"not null;default:0"`
+	// Bytes is the size of the stored object. A failed render keeps the size of the previous one.
+	Bytes int64 `gorm:"not null;default:0"`
 	gorm.Model
 }
 
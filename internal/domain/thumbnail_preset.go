@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ThumbnailPreset is a stored thumbnail preset. Presets are disabled rather than deleted,
+// so thumbnails already rendered for them keep a meaningful label.
+type ThumbnailPreset struct {
+	ID       string           `gorm:"type:uuid;primaryKey"`
+	Label    string           `gorm:"type:varchar(50);not null;uniqueIndex"`
+	Width    int              `gorm:"not null"`
+	Height   int              `gorm:"not null"`
+	Type     ThumbnailType    `gorm:"type:varchar(20);not null"`
+	Encoding EncodingSettings `gorm:"embedded;embeddedPrefix:encoding_"`
+	Enabled  bool             `gorm:"not null;default:true"`
+	gorm.Model
+}
+
+func (preset *ThumbnailPreset) BeforeCreate(tx *gorm.DB) (err error) {
+	if preset.ID == "" {
+		preset.ID = uuid.New().String()
+	}
+
+	return
+}
+
+func (preset *ThumbnailPreset) Size() ThumbnailSize {
+	return ThumbnailSize{
+		Width:    preset.Width,
+		Height:   preset.Height,
+		Label:    preset.Label,
+		Type:     preset.Type,
+		Encoding: preset.Encoding,
+	}
+}
@@ -16,24 +16,27 @@ const (
 )
 
 type ThumbnailSize struct {
-	Width  int
-	Height int
-	Label  string
-	Type   ThumbnailType
+	Width    int
+	Height   int
+	Label    string
+	Type     ThumbnailType
+	Encoding EncodingSettings
 }
 
+// ThumbnailSizes are the built-in presets. They seed the thumbnail_presets table on migration;
+// at runtime the enabled rows of that table are authoritative.
 var ThumbnailSizes = []ThumbnailSize{
-	{Width: 100, Height: 100, Label: "100x100", Type: TypeTiny},
-	{Width: 150, Height: 150, Label: "150x150", Type: TypeSmall},
-	{Width: 300, Height: 300, Label: "300x300", Type: TypeMedium},
-	{Width: 600, Height: 400, Label: "600x400", Type: TypeLarge},
-	{Width: 400, Height: 600, Label: "400x600", Type: TypeLarge},
-	{Width: 800, Height: 600, Label: "800x600", Type: TypeSLarge},
-	{Width: 600, Height: 800, Label: "600x800", Type: TypeSLarge},
-	{Width: 1024, Height: 768, Label: "1024x768", Type: TypeXLarge},
-	{Width: 768, Height: 1024, Label: "768x1024", Type: TypeXLarge},
-	{Width: 200, Height: 200, Label: "square", Type: TypeSquare},
-	{Width: 400, Height: 200, Label: "wide", Type: TypeWide},
-	{Width: 200, Height: 400, Label: "tall", Type: TypeTall},
-	{Width: 100, Height: 100, Label: "preview", Type: TypePreview},
+	{Width: 100, Height: 100, Label: "100x100", Type: TypeTiny, Encoding: DefaultEncoding},
+	{Width: 150, Height: 150, Label: "150x150", Type: TypeSmall, Encoding: DefaultEncoding},
+	{Width: 300, Height: 300, Label: "300x300", Type: TypeMedium, Encoding: DefaultEncoding},
+	{Width: 600, Height: 400, Label: "600x400", Type: TypeLarge, Encoding: DefaultEncoding},
+	{Width: 400, Height: 600, Label: "400x600", Type: TypeLarge, Encoding: DefaultEncoding},
+	{Width: 800, Height: 600, Label: "800x600", Type: TypeSLarge, Encoding: DefaultEncoding},
+	{Width: 600, Height: 800, Label: "600x800", Type: TypeSLarge, Encoding: DefaultEncoding},
+	{Width: 1024, Height: 768, Label: "1024x768", Type: TypeXLarge, Encoding: DefaultEncoding},
+	{Width: 768, Height: 1024, Label: "768x1024", Type: TypeXLarge, Encoding: DefaultEncoding},
+	{Width: 200, Height: 200, Label: "square", Type: TypeSquare, Encoding: DefaultEncoding},
+	{Width: 400, Height: 200, Label: "wide", Type: TypeWide, Encoding: DefaultEncoding},
+	{Width: 200, Height: 400, Label: "tall", Type: TypeTall, Encoding: DefaultEncoding},
+	{Width: 100, Height: 100, Label: "preview", Type: TypePreview, Encoding: DefaultEncoding},
 }
@@ -0,0 +1,57 @@
+package domain
+
+import "time"
+
+// TenantUsage is what a tenant stores: the bytes of its originals and renditions, and its image
+// count. The counters are updated as images are stored, rendered and deleted, and rebuilt from
+// storage by `irsctl usage recalculate`.
+type TenantUsage struct {
+	TenantID  string `gorm:"type:uuid;primaryKey"`
+	Bytes     int64  `gorm:"not null;default:0"`
+	Images    int64  `gorm:"not null;default:0"`
+	UpdatedAt time.Time
+}
+
+// Quota limits what a tenant stores. Soft limits only warn; uploads that would go past a hard
+// limit are rejected. A zero limit is disabled.
+type Quota struct {
+	SoftBytes  int64 `json:"soft_bytes"`
+	HardBytes  int64 `json:"hard_bytes"`
+	SoftImages int64 `json:"soft_images"`
+	HardImages int64 `json:"hard_images"`
+}
+
+// QuotaOverride replaces parts of the configured quota for one tenant. Nil fields keep the configured value.
+type QuotaOverride struct {
+	SoftBytes  *int64
+	HardBytes  *int64
+	SoftImages *int64
+	HardImages *int64
+}
+
+// Apply returns defaults with the overridden limits replaced.
+func (o QuotaOverride) Apply(defaults Quota) Quota {
+	quota := defaults
+	if o.SoftBytes != nil {
+		quota.SoftBytes = *o.SoftBytes
+	}
+	if o.HardBytes != nil {
+		quota.HardBytes = *o.HardBytes
+	}
+	if o.SoftImages != nil {
+		quota.SoftImages = *o.SoftImages
+	}
+	if o.HardImages != nil {
+		quota.HardImages = *o.HardImages
+	}
+	return quota
+}
+
+// StoredBytes is the size of the original and every rendition of the image, as last recorded.
+func (img *Image) StoredBytes() int64 {
+	bytes := img.OriginalBytes + img.CompressedBytes
+	for _, thumbnail := range img.Thumbnails {
+		bytes += thumbnail.Bytes
+	}
+	return bytes
+}
@@ -1,10 +1,13 @@
 package dto
 
+import "time"
+
 type ImageWithThumbnails struct {
 	ID            string           `json:"id"`
 	OriginalUrl   string           `json:"original_url"`
 	CompressedUrl string           `json:"compressed_url"`
 	Status        string           `json:"status"`
+	Visibility    string           `json:"visibility"`
 	ErrorMessage  *string          `json:"error_message,omitempty"`
 	Thumbnails    []ThumbnailShort `json:"thumbnails"`
 }
@@ -14,3 +17,14 @@ type ThumbnailShort struct {
 	Url  string `json:"url"`
 	Type string `json:"type"`
 }
+
+type AccessTokenRequest struct {
+	TTLSeconds int `json:"ttl_seconds" binding:"omitempty,min=1"`
+}
+
+type AccessToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// URL reads the image with the token, relative to the service.
+	URL string `json:"url"`
+}
@@ -0,0 +1,27 @@
+package dto
+
+import "time"
+
+type ReprocessImageRequest struct {
+	Presets []string `json:"presets"`
+}
+
+type BulkReprocessRequest struct {
+	Status      string     `json:"status" binding:"omitempty,oneof=pending processing ready error"`
+	CreatedFrom *time.Time `json:"created_from"`
+	CreatedTo   *time.Time `json:"created_to"`
+	Presets     []string   `json:"presets"`
+}
+
+type ReprocessJob struct {
+	ID           string     `json:"id"`
+	Status       string     `json:"status"`
+	Total        int        `json:"total"`
+	Processed    int        `json:"processed"`
+	Failed       int        `json:"failed"`
+	Skipped      int        `json:"skipped"`
+	ErrorMessage *string    `json:"error_message,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+type Usage struct {
+	TenantID string `json:"tenant_id"`
+	// Bytes counts the originals and every rendition of the tenant's images.
+	Bytes     int64     `json:"bytes"`
+	Images    int64     `json:"images"`
+	Quota     Quota     `json:"quota"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Quota holds the limits of the tenant; 0 means unlimited.
+type Quota struct {
+	SoftBytes  int64 `json:"soft_bytes"`
+	HardBytes  int64 `json:"hard_bytes"`
+	SoftImages int64 `json:"soft_images"`
+	HardImages int64 `json:"hard_images"`
+}
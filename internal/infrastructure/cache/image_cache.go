@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/logging"
+	"image-resizing-service/pkg/metrics"
+	"image-resizing-service/pkg/utils"
+	"time"
+)
+
+// keyPrefix starts the Redis keys of the cache.
+const keyPrefix = "imagecache:"
+
+// tombstone replaces an invalidated lookup for tombstoneTTL. Lookups are only stored where no key
+// exists, so a lookup that read the image before it changed cannot overwrite the invalidation with
+// its stale response once it finishes, unless it took longer than tombstoneTTL to build.
+const (
+	tombstone    = "invalidated"
+	tombstoneTTL = 30 * time.Second
+)
+
+// ImageCacheImpl keeps entries in Redis, so every replica shares them and sees the invalidations of the others.
+type ImageCacheImpl struct {
+	redis    utils.RedisClient
+	imageTTL time.Duration
+	urlTTL   time.Duration
+	logger   *logging.Logger
+}
+
+func NewImageCache(redis utils.RedisClient, imageTTL, urlTTL time.Duration, logger *logging.Logger) ports.ImageCache {
+	return &ImageCacheImpl{redis: redis, imageTTL: imageTTL, urlTTL: urlTTL, logger: logger}
+}
+
+func (c *ImageCacheImpl) Image(ctx context.Context, tenantID, imageID string) ([]byte, bool) {
+	value, ok := c.get(ctx, "image", imageKey(tenantID, imageID))
+	return []byte(value), ok
+}
+
+func (c *ImageCacheImpl) StoreImage(ctx context.Context, tenantID, imageID string, response []byte) {
+	if _, err := c.redis.SetNX(ctx, imageKey(tenantID, imageID), response, c.imageTTL); err != nil {
+		c.logger.Ctx(ctx).Warn("failed to write to the cache", zap.String("cache", "image"), zap.Error(err))
+	}
+}
+
+func (c *ImageCacheImpl) Invalidate(ctx context.Context, tenantID, imageID string) {
+	if err := c.redis.Set(ctx, imageKey(tenantID, imageID), tombstone, tombstoneTTL); err != nil {
+		// The entry expires on its own, after at most the image TTL.
+		c.logger.Ctx(ctx).Warn("failed to invalidate cached image", zap.String("image_id", imageID), zap.Error(err))
+	}
+}
+
+func (c *ImageCacheImpl) URL(ctx context.Context, name string) (string, bool) {
+	return c.get(ctx, "url", keyPrefix+"url:"+name)
+}
+
+func (c *ImageCacheImpl) StoreURL(ctx context.Context, name, url string) {
+	c.set(ctx, "url", keyPrefix+"url:"+name, url, c.urlTTL)
+}
+
+func (c *ImageCacheImpl) get(ctx context.Context, cache, key string) (string, bool) {
+	value, err := c.redis.Get(ctx, key)
+	switch {
+	case err == nil && value == tombstone:
+		metrics.CacheLookups.WithLabelValues(cache, "miss").Inc()
+	case err == nil:
+		metrics.CacheLookups.WithLabelValues(cache, "hit").Inc()
+		return value, true
+	case errors.Is(err, redis.Nil):
+		metrics.CacheLookups.WithLabelValues(cache, "miss").Inc()
+	default:
+		metrics.CacheLookups.WithLabelValues(cache, "error").Inc()
+		c.logger.Ctx(ctx).Warn("failed to read from the cache", zap.String("cache", cache), zap.Error(err))
+	}
+	return "", false
+}
+
+func (c *ImageCacheImpl) set(ctx context.Context, cache, key string, value interface{}, ttl time.Duration) {
+	if err := c.redis.Set(ctx, key, value, ttl); err != nil {
+		c.logger.Ctx(ctx).Warn("failed to write to the cache", zap.String("cache", cache), zap.Error(err))
+	}
+}
+
+func imageKey(tenantID, imageID string) string {
+	return keyPrefix + "image:" + tenantID + ":" + imageID
+}
@@ -18,6 +18,10 @@ func NewImageRepository(db *gorm.DB) ports.ImageRepository {
 	return &ImageRepositoryImpl{db: db}
 }
 
+func (r *ImageRepositoryImpl) WithContext(ctx context.Context) ports.ImageRepository {
+	return &ImageRepositoryImpl{db: tenantScope(ctx, r.db.WithContext(ctx))}
+}
+
 func (r *ImageRepositoryImpl) WithTx(tx *gorm.DB) ports.ImageRepository {
 	return &ImageRepositoryImpl{db: tx}
 }
@@ -30,9 +34,21 @@ func (r *ImageRepositoryImpl) Update(image *domain.Image) error {
 	return r.db.Model(&domain.Image{}).
 		Where("id = ?", image.ID).
 		Updates(map[string]interface{}{
-			"compressed_key": image.CompressedKey,
-			"status":         image.Status,
-			"error_message":  image.ErrorMessage,
+			"compressed_key":   image.CompressedKey,
+			"status":           image.Status,
+			"error_message":    image.ErrorMessage,
+			"processing_ms":    image.ProcessingMs,
+			"attempts":         image.Attempts,
+			"compressed_bytes": image.CompressedBytes,
+		}).Error
+}
+
+func (r *ImageRepositoryImpl) UpdateStoredBytes(id string, originalBytes, compressedBytes int64) error {
+	return r.db.Unscoped().Model(&domain.Image{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"original_bytes":   originalBytes,
+			"compressed_bytes": compressedBytes,
 		}).Error
 }
 
@@ -45,6 +61,130 @@ func (r *ImageRepositoryImpl) FindByID(id string) (*domain.Image, error) {
 	return &img, nil
 }
 
+func (r *ImageRepositoryImpl) Delete(id string) (bool, error) {
+	result := r.db.Unscoped().Delete(&domain.Image{}, "id = ?", id)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *ImageRepositoryImpl) FindStale(before time.Time, limit int) ([]domain.Image, error) {
+	var images []domain.Image
+	err := r.staleQuery("", before).Order("updated_at").Limit(limit).Find(&images).Error
+	if err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+func (r *ImageRepositoryImpl) ClaimStale(id string, before time.Time) (bool, error) {
+	result := r.staleQuery(id, before).Updates(map[string]interface{}{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"updated_at": time.Now(),
+	})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *ImageRepositoryImpl) ReleaseClaim(id string) error {
+	return r.db.Model(&domain.Image{}).
+		Where("id = ? AND attempts > 0", id).
+		UpdateColumn("attempts", gorm.Expr("attempts - 1")).Error
+}
+
+func (r *ImageRepositoryImpl) Touch(id string) error {
+	return r.db.Model(&domain.Image{}).
+		Where("id = ?", id).
+		UpdateColumn("updated_at", time.Now()).Error
+}
+
+func (r *ImageRepositoryImpl) FailStale(id string, before time.Time, message string) (bool, error) {
+	result := r.staleQuery(id, before).Updates(map[string]interface{}{
+		"status":        domain.StatusError,
+		"error_message": message,
+		"updated_at":    time.Now(),
+	})
+	return result.RowsAffected == 1, result.Error
+}
+
+// staleQuery matches images still pending or processing that have not been updated since before.
+// The conditions are repeated on every update so only one of several competing instances wins.
+func (r *ImageRepositoryImpl) staleQuery(id string, before time.Time) *gorm.DB {
+	query := r.db.Model(&domain.Image{}).
+		Where("status IN ?", []domain.ImageStatus{domain.StatusPending, domain.StatusProcessing}).
+		Where("updated_at < ?", before)
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+	return query
+}
+
+func (r *ImageRepositoryImpl) CountByStatus() ([]ports.StatusCount, error) {
+	var counts []ports.StatusCount
+	err := r.db.Model(&domain.Image{}).
+		Select("status, count(*) as count, coalesce(avg(processing_ms), 0) as avg_processing_ms").
+		Group("status").
+		Order("status").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *ImageRepositoryImpl) ReferencedKeys(keys []string) (map[string]bool, error) {
+	var images []domain.Image
+	err := r.db.Unscoped().
+		Select("original_key", "compressed_key").
+		Where("original_key IN ? OR compressed_key IN ?", keys, keys).
+		Find(&images).Error
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[string]bool, len(images)*2)
+	for _, image := range images {
+		referenced[image.OriginalKey] = true
+		if image.CompressedKey != "" {
+			referenced[image.CompressedKey] = true
+		}
+	}
+	return referenced, nil
+}
+
+func (r *ImageRepositoryImpl) CountByFilter(filter domain.ReprocessFilter) (int64, error) {
+	var count int64
+	err := r.applyFilter(r.db.Model(&domain.Image{}), filter).Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *ImageRepositoryImpl) FindIDsByFilter(filter domain.ReprocessFilter, afterID string, limit int) ([]string, error) {
+	query := r.applyFilter(r.db.Model(&domain.Image{}), filter)
+	if afterID != "" {
+		query = query.Where("id > ?", afterID)
+	}
+
+	var ids []string
+	err := query.Order("id").Limit(limit).Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *ImageRepositoryImpl) applyFilter(query *gorm.DB, filter domain.ReprocessFilter) *gorm.DB {
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at < ?", *filter.CreatedTo)
+	}
+	return query
+}
+
 func (r *ImageRepositoryImpl) WaitForImage(ctx context.Context, id uuid.UUID, retries int, delay time.Duration) error {
 	for attempt := 0; attempt < retries; attempt++ {
 		var count int64
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+)
+
+type PresetRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPresetRepository(db *gorm.DB) ports.PresetRepository {
+	return &PresetRepositoryImpl{db: db}
+}
+
+func (r *PresetRepositoryImpl) WithContext(ctx context.Context) ports.PresetRepository {
+	return &PresetRepositoryImpl{db: r.db.WithContext(ctx)}
+}
+
+func (r *PresetRepositoryImpl) Save(preset *domain.ThumbnailPreset) error {
+	return r.db.Create(preset).Error
+}
+
+func (r *PresetRepositoryImpl) Update(preset *domain.ThumbnailPreset) error {
+	return r.db.Model(&domain.ThumbnailPreset{}).
+		Where("id = ?", preset.ID).
+		Updates(map[string]interface{}{
+			"enabled": preset.Enabled,
+		}).Error
+}
+
+func (r *PresetRepositoryImpl) FindAll() ([]domain.ThumbnailPreset, error) {
+	var presets []domain.ThumbnailPreset
+	err := r.db.Order("created_at, label").Find(&presets).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return presets, nil
+}
+
+func (r *PresetRepositoryImpl) FindByLabel(label string) (*domain.ThumbnailPreset, error) {
+	var preset domain.ThumbnailPreset
+	err := r.db.First(&preset, "label = ?", label).Error
+	if err != nil {
+		return nil, err
+	}
+	return &preset, nil
+}
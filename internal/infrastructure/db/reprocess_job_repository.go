@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"time"
+)
+
+type ReprocessJobRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewReprocessJobRepository(db *gorm.DB) ports.ReprocessJobRepository {
+	return &ReprocessJobRepositoryImpl{db: db}
+}
+
+func (r *ReprocessJobRepositoryImpl) WithContext(ctx context.Context) ports.ReprocessJobRepository {
+	return &ReprocessJobRepositoryImpl{db: tenantScope(ctx, r.db.WithContext(ctx))}
+}
+
+func (r *ReprocessJobRepositoryImpl) Save(job *domain.ReprocessJob) error {
+	return r.db.Create(job).Error
+}
+
+func (r *ReprocessJobRepositoryImpl) Update(job *domain.ReprocessJob) error {
+	return r.db.Model(&domain.ReprocessJob{}).
+		Where("id = ?", job.ID).
+		Updates(map[string]interface{}{
+			"status":        job.Status,
+			"total":         job.Total,
+			"processed":     job.Processed,
+			"failed":        job.Failed,
+			"skipped":       job.Skipped,
+			"cursor":        job.Cursor,
+			"error_message": job.ErrorMessage,
+			"started_at":    job.StartedAt,
+			"finished_at":   job.FinishedAt,
+		}).Error
+}
+
+func (r *ReprocessJobRepositoryImpl) FindStale(before time.Time, limit int) ([]domain.ReprocessJob, error) {
+	var jobs []domain.ReprocessJob
+	err := r.staleQuery("", before).Order("updated_at").Limit(limit).Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *ReprocessJobRepositoryImpl) ClaimStale(id string, before time.Time) (bool, error) {
+	result := r.staleQuery(id, before).Updates(map[string]interface{}{
+		"status":     domain.JobRunning,
+		"updated_at": time.Now(),
+	})
+	return result.RowsAffected == 1, result.Error
+}
+
+// staleQuery matches jobs still pending or running that have not recorded progress since before,
+// like ImageRepositoryImpl.staleQuery.
+func (r *ReprocessJobRepositoryImpl) staleQuery(id string, before time.Time) *gorm.DB {
+	query := r.db.Model(&domain.ReprocessJob{}).
+		Where("status IN ?", []domain.JobStatus{domain.JobPending, domain.JobRunning}).
+		Where("updated_at < ?", before)
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+	return query
+}
+
+func (r *ReprocessJobRepositoryImpl) CountByStatus() ([]ports.StatusCount, error) {
+	var counts []ports.StatusCount
+	err := r.db.Model(&domain.ReprocessJob{}).
+		Select("status, count(*) as count").
+		Group("status").
+		Order("status").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *ReprocessJobRepositoryImpl) CountFinishedBefore(before time.Time) (int64, error) {
+	var count int64
+	err := r.finishedBefore(before).Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *ReprocessJobRepositoryImpl) DeleteFinishedBefore(before time.Time) (int64, error) {
+	result := r.finishedBefore(before).Unscoped().Delete(&domain.ReprocessJob{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *ReprocessJobRepositoryImpl) finishedBefore(before time.Time) *gorm.DB {
+	return r.db.Model(&domain.ReprocessJob{}).
+		Where("status IN ?", []domain.JobStatus{domain.JobCompleted, domain.JobFailed}).
+		Where("finished_at < ?", before)
+}
+
+func (r *ReprocessJobRepositoryImpl) FindByID(id string) (*domain.ReprocessJob, error) {
+	var job domain.ReprocessJob
+	err := r.db.First(&job, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
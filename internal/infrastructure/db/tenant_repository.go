@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+)
+
+type TenantRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTenantRepository(db *gorm.DB) ports.TenantRepository {
+	return &TenantRepositoryImpl{db: db}
+}
+
+func (r *TenantRepositoryImpl) WithContext(ctx context.Context) ports.TenantRepository {
+	return &TenantRepositoryImpl{db: r.db.WithContext(ctx)}
+}
+
+func (r *TenantRepositoryImpl) Save(tenant *domain.Tenant) error {
+	return r.db.Create(tenant).Error
+}
+
+func (r *TenantRepositoryImpl) FindAll() ([]domain.Tenant, error) {
+	var tenants []domain.Tenant
+	err := r.db.Preload("APIKeys").Order("name").Find(&tenants).Error
+	if err != nil {
+		return nil, err
+	}
+	return tenants, nil
+}
+
+func (r *TenantRepositoryImpl) FindByName(name string) (*domain.Tenant, error) {
+	var tenant domain.Tenant
+	err := r.db.First(&tenant, "name = ?", name).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (r *TenantRepositoryImpl) FindByID(id string) (*domain.Tenant, error) {
+	var tenant domain.Tenant
+	err := r.db.First(&tenant, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (r *TenantRepositoryImpl) UpdateQuota(id string, quota domain.QuotaOverride) error {
+	return r.db.Model(&domain.Tenant{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"quota_soft_bytes":  quota.SoftBytes,
+			"quota_hard_bytes":  quota.HardBytes,
+			"quota_soft_images": quota.SoftImages,
+			"quota_hard_images": quota.HardImages,
+		}).Error
+}
+
+func (r *TenantRepositoryImpl) SaveAPIKey(key *domain.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *TenantRepositoryImpl) FindAPIKeyByHash(hash string) (*domain.APIKey, error) {
+	var key domain.APIKey
+	err := r.db.Preload("Tenant").First(&key, "hash = ?", hash).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *TenantRepositoryImpl) DeleteAPIKey(prefix string) (int64, error) {
+	result := r.db.Delete(&domain.APIKey{}, "prefix = ?", prefix)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,19 @@
+package db
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"image-resizing-service/internal/domain"
+)
+
+// tenantScope limits every query of db to the tenant of ctx, so another tenant's rows are simply
+// not found. Without a tenant, as for the reaper or the operator CLI, db is returned unchanged.
+func tenantScope(ctx context.Context, db *gorm.DB) *gorm.DB {
+	tenantID, ok := domain.TenantFromContext(ctx)
+	if !ok {
+		return db
+	}
+
+	// A new session keeps the condition for every statement built on db without stacking them up.
+	return db.Where("tenant_id = ?", tenantID).Session(&gorm.Session{})
+}
@@ -1,8 +1,10 @@
 package db
 
 import (
+	"context"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"image-resizing-service/internal/domain"
 	"image-resizing-service/internal/ports"
 )
@@ -15,6 +17,10 @@ func NewThumbnailRepository(db *gorm.DB) ports.ThumbnailRepository {
 	return &ThumbnailRepositoryImpl{db: db}
 }
 
+func (r *ThumbnailRepositoryImpl) WithContext(ctx context.Context) ports.ThumbnailRepository {
+	return &ThumbnailRepositoryImpl{db: r.db.WithContext(ctx)}
+}
+
 func (r *ThumbnailRepositoryImpl) WithTx(tx *gorm.DB) ports.ThumbnailRepository {
 	return &ThumbnailRepositoryImpl{db: tx}
 }
@@ -23,6 +29,34 @@ func (r *ThumbnailRepositoryImpl) Save(thumbnail *domain.Thumbnail) error {
 	return r.db.Create(&thumbnail).Error
 }
 
+func (r *ThumbnailRepositoryImpl) Upsert(thumbnail *domain.Thumbnail) error {
+	columns := []string{"key", "type", "status", "error_message", "processing_ms", "updated_at", "deleted_at"}
+	// A failed render leaves the previous object, and so its size, in storage.
+	if thumbnail.Status == domain.RenditionReady {
+		columns = append(columns, "bytes")
+	}
+
+	return r.db.Unscoped().Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "image_id"}, {Name: "size"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(thumbnail).Error
+}
+
+func (r *ThumbnailRepositoryImpl) StoredBytes(imageID uuid.UUID, sizeLabel string) (int64, error) {
+	var bytes []int64
+	err := r.db.Unscoped().Model(&domain.Thumbnail{}).
+		Where("image_id = ? AND size = ?", imageID, sizeLabel).
+		Pluck("bytes", &bytes).Error
+	if err != nil || len(bytes) == 0 {
+		return 0, err
+	}
+	return bytes[0], nil
+}
+
+func (r *ThumbnailRepositoryImpl) UpdateBytes(id string, bytes int64) error {
+	return r.db.Unscoped().Model(&domain.Thumbnail{}).Where("id = ?", id).UpdateColumn("bytes", bytes).Error
+}
+
 func (r *ThumbnailRepositoryImpl) FindByImageID(imageID uuid.UUID) ([]domain.Thumbnail, error) {
 	var thumbnails []domain.Thumbnail
 	err := r.db.Where("image_id = ?", imageID).Find(&thumbnails).Error
@@ -33,10 +67,71 @@ func (r *ThumbnailRepositoryImpl) FindByImageID(imageID uuid.UUID) ([]domain.Thu
 	return thumbnails, nil
 }
 
+func (r *ThumbnailRepositoryImpl) CountByStatus() ([]ports.StatusCount, error) {
+	var counts []ports.StatusCount
+	err := r.db.Model(&domain.Thumbnail{}).
+		Select("status, count(*) as count, coalesce(avg(processing_ms), 0) as avg_processing_ms").
+		Group("status").
+		Order("status").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *ThumbnailRepositoryImpl) ReferencedKeys(keys []string) (map[string]bool, error) {
+	var found []string
+	err := r.db.Unscoped().Model(&domain.Thumbnail{}).
+		Where("key IN ?", keys).
+		Pluck("key", &found).Error
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[string]bool, len(found))
+	for _, key := range found {
+		referenced[key] = true
+	}
+	return referenced, nil
+}
+
+func (r *ThumbnailRepositoryImpl) CountBySize() ([]ports.PresetCount, error) {
+	var counts []ports.PresetCount
+	err := r.db.Model(&domain.Thumbnail{}).
+		Select("size as preset, count(*) as count, coalesce(avg(processing_ms), 0) as avg_processing_ms").
+		Where("status = ?", domain.RenditionReady).
+		Group("size").
+		Order("size").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *ThumbnailRepositoryImpl) FindBySizes(sizes []string, afterID string, limit int) ([]domain.Thumbnail, error) {
+	query := r.db.Where("size IN ?", sizes)
+	if afterID != "" {
+		query = query.Where("id > ?", afterID)
+	}
+
+	var thumbnails []domain.Thumbnail
+	err := query.Order("id").Limit(limit).Find(&thumbnails).Error
+	if err != nil {
+		return nil, err
+	}
+	return thumbnails, nil
+}
+
+func (r *ThumbnailRepositoryImpl) Delete(id string) error {
+	return r.db.Unscoped().Delete(&domain.Thumbnail{}, "id = ?", id).Error
+}
+
 func (r *ThumbnailRepositoryImpl) Exists(imageID uuid.UUID, sizeLabel string) (bool, error) {
 	var count int64
 	err := r.db.Model(&domain.Thumbnail{}).
-		Where("image_id = ? AND size = ?", imageID, sizeLabel).
+		Where("image_id = ? AND size = ? AND status = ?", imageID, sizeLabel, domain.RenditionReady).
 		Count(&count).Error
 	if err != nil {
 		return false, err
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/ports"
+	"time"
+)
+
+type UsageRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewUsageRepository(db *gorm.DB) ports.UsageRepository {
+	return &UsageRepositoryImpl{db: db}
+}
+
+func (r *UsageRepositoryImpl) WithContext(ctx context.Context) ports.UsageRepository {
+	return &UsageRepositoryImpl{db: r.db.WithContext(ctx)}
+}
+
+func (r *UsageRepositoryImpl) WithTx(tx *gorm.DB) ports.UsageRepository {
+	return &UsageRepositoryImpl{db: tx}
+}
+
+func (r *UsageRepositoryImpl) Add(tenantID string, bytes, images int64) error {
+	if bytes == 0 && images == 0 {
+		return nil
+	}
+
+	usage := &domain.TenantUsage{TenantID: tenantID, Bytes: bytes, Images: images}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"bytes":      gorm.Expr("tenant_usages.bytes + ?", bytes),
+			"images":     gorm.Expr("tenant_usages.images + ?", images),
+			"updated_at": time.Now(),
+		}),
+	}).Create(usage).Error
+}
+
+func (r *UsageRepositoryImpl) AddWithinQuota(tenantID string, bytes, images int64, quota domain.Quota) (bool, error) {
+	if (quota.HardBytes > 0 && bytes > quota.HardBytes) || (quota.HardImages > 0 && images > quota.HardImages) {
+		return false, nil
+	}
+
+	// The update of an existing row only happens when its WHERE holds, evaluated on the locked row.
+	var within []clause.Expression
+	if quota.HardBytes > 0 {
+		within = append(within, gorm.Expr("tenant_usages.bytes + ? <= ?", bytes, quota.HardBytes))
+	}
+	if quota.HardImages > 0 {
+		within = append(within, gorm.Expr("tenant_usages.images + ? <= ?", images, quota.HardImages))
+	}
+
+	usage := &domain.TenantUsage{TenantID: tenantID, Bytes: bytes, Images: images}
+	result := r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"bytes":      gorm.Expr("tenant_usages.bytes + ?", bytes),
+			"images":     gorm.Expr("tenant_usages.images + ?", images),
+			"updated_at": time.Now(),
+		}),
+		Where: clause.Where{Exprs: within},
+	}).Create(usage)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *UsageRepositoryImpl) Find(tenantID string) (*domain.TenantUsage, error) {
+	var usage domain.TenantUsage
+	err := r.db.First(&usage, "tenant_id = ?", tenantID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &domain.TenantUsage{TenantID: tenantID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+func (r *UsageRepositoryImpl) Replace(usages []domain.TenantUsage) error {
+	if len(usages) == 0 {
+		return nil
+	}
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"bytes", "images", "updated_at"}),
+	}).Create(&usages).Error
+}
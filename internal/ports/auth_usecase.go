@@ -0,0 +1,32 @@
+package ports
+
+import (
+	"context"
+	"image-resizing-service/internal/domain"
+	"time"
+)
+
+// Credentials are what a request presented to authenticate, as read by a transport.
+type Credentials struct {
+	// BearerToken is a JWT sent as "Authorization: Bearer <token>".
+	BearerToken string
+	// APIKey is a tenant API key or the legacy token of the transport.
+	APIKey string
+	// LegacyToken is the shared token configured for the transport (REST_UPLOAD_TOKEN or GRPC_TOKEN).
+	LegacyToken string
+	// AccessToken is an image access token, which only reads the image it was issued for.
+	AccessToken string
+}
+
+type AccessToken struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+type AuthUseCase interface {
+	// Authenticate resolves credentials to a principal, or returns domain.ErrUnauthenticated.
+	Authenticate(ctx context.Context, credentials Credentials) (*domain.Principal, error)
+	// IssueAccessToken signs a token that reads the image without other credentials until it expires.
+	// A zero ttl selects the configured lifetime; longer lifetimes are capped at the configured maximum.
+	IssueAccessToken(ctx context.Context, image *domain.Image, ttl time.Duration) (*AccessToken, error)
+}
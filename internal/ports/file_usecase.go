@@ -0,0 +1,26 @@
+package ports
+
+import (
+	"context"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/pkg/utils"
+)
+
+// File is a stored file of an image, opened for streaming. The caller closes Object.
+type File struct {
+	Image  *domain.Image
+	Key    string
+	Object *utils.ObjectReader
+}
+
+// FileUseCase opens the files of images for the principal in the context. Public images are open to
+// anyone; private ones need images:read in their tenant or an access token for the image. Private
+// files of other tenants, or of other images than that of an access token, are reported as
+// domain.ErrFileNotFound.
+type FileUseCase interface {
+	// Open opens the original or a rendition of an image by its storage key.
+	Open(ctx context.Context, key string) (*File, error)
+	OpenOriginal(ctx context.Context, imageID string) (*File, error)
+	// OpenThumbnail opens the ready thumbnail of the image rendered for the preset with the given label.
+	OpenThumbnail(ctx context.Context, imageID, label string) (*File, error)
+}
@@ -0,0 +1,24 @@
+package ports
+
+import (
+	"context"
+)
+
+type HealthCheck struct {
+	Name       string         `json:"name"`
+	Healthy    bool           `json:"healthy"`
+	Error      string         `json:"error,omitempty"`
+	DurationMs int64          `json:"duration_ms"`
+	Details    map[string]any `json:"details,omitempty"`
+}
+
+type HealthReport struct {
+	Ready  bool          `json:"ready"`
+	Checks []HealthCheck `json:"checks"`
+}
+
+type HealthUseCase interface {
+	// Ready checks the database, Redis, the storage bucket and background processing.
+	// The service is ready only when every check passes.
+	Ready(ctx context.Context) *HealthReport
+}
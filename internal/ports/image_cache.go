@@ -0,0 +1,18 @@
+package ports
+
+import "context"
+
+// ImageCache keeps assembled image responses and presigned URLs, so repeated lookups skip the
+// database and URL signing. The cache never fails a request: errors are logged and count as misses.
+type ImageCache interface {
+	// Image returns the cached response of the image of the tenant.
+	Image(ctx context.Context, tenantID, imageID string) ([]byte, bool)
+	// StoreImage caches response unless an entry or a recent invalidation of the image is there already,
+	// so a response built from the image as it was before a change is not cached after it.
+	StoreImage(ctx context.Context, tenantID, imageID string, response []byte)
+	// Invalidate drops the cached response of the image; call it whenever the image changes.
+	Invalidate(ctx context.Context, tenantID, imageID string)
+	// URL returns the cached URL stored under name.
+	URL(ctx context.Context, name string) (string, bool)
+	StoreURL(ctx context.Context, name, url string)
+}
@@ -10,8 +10,31 @@ import (
 
 type ImageRepository interface {
 	WithTx(tx *gorm.DB) ImageRepository
+	// WithContext binds queries to ctx, so they are cancelled with it and traced under its span.
+	// When ctx carries a tenant, only images of that tenant are found or changed.
+	WithContext(ctx context.Context) ImageRepository
 	Save(image *domain.Image) error
 	Update(image *domain.Image) error
 	FindByID(id string) (*domain.Image, error)
+	// UpdateStoredBytes corrects the recorded object sizes of the image without touching its update time.
+	UpdateStoredBytes(id string, originalBytes, compressedBytes int64) error
+	// Delete removes the image and, through the foreign key, its thumbnails for good. false means it was not found.
+	Delete(id string) (bool, error)
+	// FindStale returns images still pending or processing that have not been updated since before.
+	FindStale(before time.Time, limit int) ([]domain.Image, error)
+	// ClaimStale increments the attempts of a stale image and refreshes it; false means it is no longer stale.
+	ClaimStale(id string, before time.Time) (bool, error)
+	// ReleaseClaim undoes the attempt counted by ClaimStale when the image could not be re-enqueued.
+	ReleaseClaim(id string) error
+	// Touch refreshes the update time of the image, so it does not look stale while it is still being processed.
+	Touch(id string) error
+	// FailStale marks a stale image as failed; false means it is no longer stale.
+	FailStale(id string, before time.Time, message string) (bool, error)
+	CountByStatus() ([]StatusCount, error)
+	// ReferencedKeys returns the subset of keys used as an original or compressed key, including by soft-deleted images.
+	ReferencedKeys(keys []string) (map[string]bool, error)
+	CountByFilter(filter domain.ReprocessFilter) (int64, error)
+	// FindIDsByFilter pages through matching image IDs in ID order, starting after afterID.
+	FindIDsByFilter(filter domain.ReprocessFilter, afterID string, limit int) ([]string, error)
 	WaitForImage(ctx context.Context, id uuid.UUID, retries int, delay time.Duration) error
 }
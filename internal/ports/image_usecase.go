@@ -7,11 +7,45 @@ import (
 
 type UploadResult struct {
 	ID          string
+	TenantID    string
 	OriginalKey string
+	ContentType string
 	Status      string
+	Visibility  domain.Visibility
+	// QuotaWarning is set when the upload reached a soft quota of the tenant.
+	QuotaWarning string
+}
+
+// ProcessOptions selects what ProcessImage renders. Without Force only missing or failed renditions
+// are produced. With Force the selected presets are rendered again even if they are stored, and so is
+// the compressed rendition when no preset is selected.
+type ProcessOptions struct {
+	Presets []string
+	Force   bool
+}
+
+// WorkerState describes background processing, as reported by the readiness check.
+type WorkerState struct {
+	Draining bool
+	InFlight int
 }
 
 type ImageUseCase interface {
-	UploadOriginal(ctx context.Context, filePath string, contentType string) (*UploadResult, error)
+	// UploadOriginal stores the file and processes it in the background. An empty visibility selects the
+	// configured default.
+	UploadOriginal(ctx context.Context, filePath string, contentType string, visibility domain.Visibility) (*UploadResult, error)
+	// ImportFile stores and processes the file synchronously and returns the processed image.
+	ImportFile(ctx context.Context, filePath string, contentType string, visibility domain.Visibility) (*domain.Image, error)
 	FindByID(ctx context.Context, id string) (*domain.Image, error)
+	// Delete removes the image with its renditions. Images being processed cannot be deleted.
+	Delete(ctx context.Context, id string) error
+	ProcessImage(ctx context.Context, id string, options ProcessOptions) error
+	// Requeue resumes processing of the image in the background.
+	Requeue(ctx context.Context, id string) error
+	// Drain stops accepting new processing and waits for background processing until ctx expires.
+	Drain(ctx context.Context) error
+	// State reports whether processing is draining and how many images are being processed.
+	State() WorkerState
+	// Reprocess regenerates the selected presets (all renditions when none are given) in the background.
+	Reprocess(ctx context.Context, id string, presets []string) (*domain.Image, error)
 }
@@ -0,0 +1,84 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+type StatusCount struct {
+	Status          string  `json:"status"`
+	Count           int64   `json:"count"`
+	AvgProcessingMs float64 `json:"avg_processing_ms,omitempty"`
+}
+
+type PresetCount struct {
+	Preset          string  `json:"preset"`
+	Count           int64   `json:"count"`
+	AvgProcessingMs float64 `json:"avg_processing_ms"`
+}
+
+type Stats struct {
+	Images         []StatusCount `json:"images"`
+	Thumbnails     []StatusCount `json:"thumbnails"`
+	Presets        []PresetCount `json:"presets"`
+	ReprocessJobs  []StatusCount `json:"reprocess_jobs"`
+	EnabledPresets int           `json:"enabled_presets"`
+}
+
+type VerifyIssue struct {
+	ImageID  string   `json:"image_id"`
+	Problems []string `json:"problems"`
+	Repaired bool     `json:"repaired"`
+	Error    string   `json:"error,omitempty"`
+}
+
+type VerifyReport struct {
+	Checked  int           `json:"checked"`
+	Broken   int           `json:"broken"`
+	Repaired int           `json:"repaired"`
+	Issues   []VerifyIssue `json:"issues"`
+}
+
+type GCOptions struct {
+	DryRun bool
+	// JobRetention is how long finished reprocess jobs are kept.
+	JobRetention time.Duration
+	// Orphans enables the storage reconciliation pass.
+	Orphans bool
+	// GracePeriod protects recently written objects whose database row may not be committed yet.
+	GracePeriod time.Duration
+}
+
+type OrphanObject struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+type OrphanReport struct {
+	DryRun  bool `json:"dry_run"`
+	Scanned int  `json:"scanned"`
+	// InGracePeriod counts unreferenced objects that are too recent to be deleted yet.
+	InGracePeriod int            `json:"in_grace_period"`
+	Orphans       []OrphanObject `json:"orphans"`
+	Bytes         int64          `json:"bytes"`
+}
+
+type GCReport struct {
+	DryRun bool `json:"dry_run"`
+	// DisabledPresetThumbnails are thumbnails of disabled presets, removed from storage and the database.
+	DisabledPresetThumbnails []string      `json:"disabled_preset_thumbnails"`
+	ReprocessJobs            int64         `json:"reprocess_jobs"`
+	Orphans                  *OrphanReport `json:"orphans,omitempty"`
+}
+
+type MaintenanceUseCase interface {
+	Stats(ctx context.Context) (*Stats, error)
+	// Verify checks that every rendition of every settled image is stored. With repair, broken
+	// images are processed again, which only regenerates what is missing.
+	Verify(ctx context.Context, repair bool) (*VerifyReport, error)
+	CollectGarbage(ctx context.Context, options GCOptions) (*GCReport, error)
+	// CollectOrphans deletes stored renditions and originals that no Image or Thumbnail row refers to
+	// and that are older than the grace period. In dry-run mode it only reports them.
+	CollectOrphans(ctx context.Context, dryRun bool, gracePeriod time.Duration) (*OrphanReport, error)
+}
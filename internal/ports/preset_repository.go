@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+	"image-resizing-service/internal/domain"
+)
+
+type PresetRepository interface {
+	WithContext(ctx context.Context) PresetRepository
+	Save(preset *domain.ThumbnailPreset) error
+	Update(preset *domain.ThumbnailPreset) error
+	FindAll() ([]domain.ThumbnailPreset, error)
+	FindByLabel(label string) (*domain.ThumbnailPreset, error)
+}
@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+	"image-resizing-service/internal/domain"
+)
+
+type PresetUseCase interface {
+	List(ctx context.Context) ([]domain.ThumbnailPreset, error)
+	Add(ctx context.Context, preset *domain.ThumbnailPreset) error
+	Disable(ctx context.Context, label string) error
+	// Resolve returns the enabled presets with the given labels, or every enabled preset when no label is given.
+	Resolve(ctx context.Context, labels []string) ([]domain.ThumbnailSize, error)
+}
@@ -0,0 +1,44 @@
+package ports
+
+import (
+	"context"
+	"image-resizing-service/internal/domain"
+	"math"
+	"strconv"
+	"time"
+)
+
+// RateLimit describes the most restrictive bucket a request was counted against, for the
+// RateLimit-* response headers.
+type RateLimit struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long the bucket takes to refill completely.
+	Reset time.Duration
+	// RetryAfter is how long a rejected request has to wait for the bucket to hold a token again.
+	RetryAfter time.Duration
+	// Exhausted names the limit that rejected the request: key, tenant or ip.
+	Exhausted string
+}
+
+// Headers returns the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers, plus
+// Retry-After when the request was rejected. Times are rounded up to whole seconds.
+func (r *RateLimit) Headers() map[string]string {
+	headers := map[string]string{
+		"RateLimit-Limit":     strconv.Itoa(r.Limit),
+		"RateLimit-Remaining": strconv.Itoa(r.Remaining),
+		"RateLimit-Reset":     strconv.Itoa(int(math.Ceil(r.Reset.Seconds()))),
+	}
+	if !r.Allowed {
+		headers["Retry-After"] = strconv.Itoa(max(1, int(math.Ceil(r.RetryAfter.Seconds()))))
+	}
+
+	return headers
+}
+
+type RateLimitUseCase interface {
+	// Allow takes a token from the key, tenant and client IP buckets of class, or from none of them when
+	// one is empty. It returns nil when no limit applies to the request.
+	Allow(ctx context.Context, class domain.RateLimitClass, principal *domain.Principal, clientIP string) (*RateLimit, error)
+}
@@ -0,0 +1,18 @@
+package ports
+
+import (
+	"context"
+)
+
+type ReapReport struct {
+	Requeued int
+	TimedOut int
+	// ResumedJobs is the number of interrupted reprocess jobs restarted.
+	ResumedJobs int
+}
+
+type ReaperUseCase interface {
+	// Reap requeues or fails images stuck in pending or processing for longer than the timeout,
+	// and resumes reprocess jobs that stopped recording progress for as long.
+	Reap(ctx context.Context) (*ReapReport, error)
+}
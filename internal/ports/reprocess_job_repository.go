@@ -0,0 +1,22 @@
+package ports
+
+import (
+	"context"
+	"image-resizing-service/internal/domain"
+	"time"
+)
+
+type ReprocessJobRepository interface {
+	// WithContext scopes the jobs to the tenant of ctx, like ImageRepository.WithContext.
+	WithContext(ctx context.Context) ReprocessJobRepository
+	Save(job *domain.ReprocessJob) error
+	Update(job *domain.ReprocessJob) error
+	FindByID(id string) (*domain.ReprocessJob, error)
+	// FindStale returns jobs still pending or running that have not recorded progress since before.
+	FindStale(before time.Time, limit int) ([]domain.ReprocessJob, error)
+	// ClaimStale marks a stale job as running and refreshes it; false means it is no longer stale.
+	ClaimStale(id string, before time.Time) (bool, error)
+	CountByStatus() ([]StatusCount, error)
+	CountFinishedBefore(before time.Time) (int64, error)
+	DeleteFinishedBefore(before time.Time) (int64, error)
+}
@@ -0,0 +1,18 @@
+package ports
+
+import (
+	"context"
+	"image-resizing-service/internal/domain"
+	"time"
+)
+
+type ReprocessUseCase interface {
+	// StartBulk creates a job for every image matching the filter and runs it in the background.
+	StartBulk(ctx context.Context, filter domain.ReprocessFilter) (*domain.ReprocessJob, error)
+	FindJob(ctx context.Context, id string) (*domain.ReprocessJob, error)
+	// ResumeStale restarts jobs left pending or running without progress since before, e.g. by a shutdown
+	// or a crash, from the last image they handled. It returns the number of jobs resumed.
+	ResumeStale(ctx context.Context, before time.Time) (int, error)
+	// Drain stops running jobs, returning them to pending, and waits until ctx expires.
+	Drain(ctx context.Context) error
+}
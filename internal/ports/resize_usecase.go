@@ -3,8 +3,15 @@ package ports
 import (
 	"context"
 	"github.com/google/uuid"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/pkg/utils"
 )
 
 type ResizeUseCase interface {
-	ResizeThumbnails(ctx context.Context, imageID uuid.UUID, originalKey string) error
+	// PendingPresets returns the given presets that have no stored and verified thumbnail for the image.
+	PendingPresets(ctx context.Context, imageID uuid.UUID, presets []domain.ThumbnailSize) ([]domain.ThumbnailSize, error)
+	// ResizeThumbnails renders the presets of the image and stores them under the keys of tenantID.
+	ResizeThumbnails(ctx context.Context, tenantID string, imageID uuid.UUID, source *utils.SourceImage, presets []domain.ThumbnailSize) error
+	// EncodeCompressed encodes the full-size compressed rendition of the source while holding a CPU slot.
+	EncodeCompressed(ctx context.Context, source *utils.SourceImage, encoding domain.EncodingSettings) ([]byte, error)
 }
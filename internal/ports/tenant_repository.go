@@ -0,0 +1,21 @@
+package ports
+
+import (
+	"context"
+	"image-resizing-service/internal/domain"
+)
+
+type TenantRepository interface {
+	WithContext(ctx context.Context) TenantRepository
+	Save(tenant *domain.Tenant) error
+	// FindAll returns every tenant with its API keys.
+	FindAll() ([]domain.Tenant, error)
+	FindByName(name string) (*domain.Tenant, error)
+	FindByID(id string) (*domain.Tenant, error)
+	UpdateQuota(id string, quota domain.QuotaOverride) error
+	SaveAPIKey(key *domain.APIKey) error
+	// FindAPIKeyByHash returns the key with its tenant.
+	FindAPIKeyByHash(hash string) (*domain.APIKey, error)
+	// DeleteAPIKey revokes the key with the given prefix and reports how many keys were revoked.
+	DeleteAPIKey(prefix string) (int64, error)
+}
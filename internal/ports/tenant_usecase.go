@@ -0,0 +1,26 @@
+package ports
+
+import (
+	"context"
+	"image-resizing-service/internal/domain"
+)
+
+// IssuedAPIKey is a newly created API key. Key is only available here; the service keeps a hash.
+type IssuedAPIKey struct {
+	Tenant string `json:"tenant"`
+	Prefix string `json:"prefix"`
+	Key    string `json:"key"`
+}
+
+type TenantUseCase interface {
+	// Authenticate returns the tenant of apiKey, or domain.ErrInvalidAPIKey.
+	Authenticate(ctx context.Context, apiKey string) (*domain.Tenant, error)
+	List(ctx context.Context) ([]domain.Tenant, error)
+	Create(ctx context.Context, name string) (*domain.Tenant, error)
+	// Resolve returns the tenant with the given name, or domain.ErrTenantNotFound.
+	Resolve(ctx context.Context, name string) (*domain.Tenant, error)
+	// SetQuota replaces the quota overrides of the tenant; nil limits fall back to the configured quota.
+	SetQuota(ctx context.Context, tenantName string, quota domain.QuotaOverride) (*domain.Tenant, error)
+	IssueAPIKey(ctx context.Context, tenantName string) (*IssuedAPIKey, error)
+	RevokeAPIKey(ctx context.Context, prefix string) error
+}
@@ -1,6 +1,7 @@
 package ports
 
 import (
+	"context"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"image-resizing-service/internal/domain"
@@ -8,7 +9,21 @@ import (
 
 type ThumbnailRepository interface {
 	WithTx(tx *gorm.DB) ThumbnailRepository
+	WithContext(ctx context.Context) ThumbnailRepository
 	Save(thumbnail *domain.Thumbnail) error
+	// Upsert creates the thumbnail or replaces the existing row for the same image and size.
+	Upsert(thumbnail *domain.Thumbnail) error
+	// StoredBytes returns the recorded size of the thumbnail of the image for the preset, 0 when there is none.
+	StoredBytes(imageID uuid.UUID, sizeLabel string) (int64, error)
+	// UpdateBytes corrects the recorded size of the thumbnail without touching its update time.
+	UpdateBytes(id string, bytes int64) error
 	FindByImageID(imageID uuid.UUID) ([]domain.Thumbnail, error)
 	Exists(imageID uuid.UUID, sizeLabel string) (bool, error)
+	CountByStatus() ([]StatusCount, error)
+	// ReferencedKeys returns the subset of keys used by a thumbnail, including soft-deleted ones.
+	ReferencedKeys(keys []string) (map[string]bool, error)
+	CountBySize() ([]PresetCount, error)
+	// FindBySizes pages through thumbnails of the given presets in ID order, starting after afterID.
+	FindBySizes(sizes []string, afterID string, limit int) ([]domain.Thumbnail, error)
+	Delete(id string) error
 }
@@ -0,0 +1,22 @@
+package ports
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"image-resizing-service/internal/domain"
+)
+
+type UsageRepository interface {
+	WithTx(tx *gorm.DB) UsageRepository
+	WithContext(ctx context.Context) UsageRepository
+	// Add changes the usage of the tenant by the given amounts, which may be negative.
+	Add(tenantID string, bytes, images int64) error
+	// AddWithinQuota is Add, unless the new usage would pass a hard limit of quota. The check and the
+	// change are one statement, so concurrent callers cannot both take the last of the quota. false
+	// means nothing was changed.
+	AddWithinQuota(tenantID string, bytes, images int64, quota domain.Quota) (bool, error)
+	// Find returns the usage of the tenant, zero when nothing was recorded yet.
+	Find(tenantID string) (*domain.TenantUsage, error)
+	// Replace overwrites the usage of the given tenants.
+	Replace(usages []domain.TenantUsage) error
+}
@@ -0,0 +1,45 @@
+package ports
+
+import (
+	"context"
+	"image-resizing-service/internal/domain"
+	"time"
+)
+
+// Usage is what a tenant stores, with the quota that applies to it.
+type Usage struct {
+	TenantID  string       `json:"tenant_id"`
+	Bytes     int64        `json:"bytes"`
+	Images    int64        `json:"images"`
+	Quota     domain.Quota `json:"quota"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+type UsageRecalculation struct {
+	// Objects is the number of stored objects listed.
+	Objects int `json:"objects"`
+	Images  int `json:"images"`
+	// Corrected counts the images whose recorded object sizes did not match storage.
+	Corrected int     `json:"corrected"`
+	Tenants   []Usage `json:"tenants"`
+}
+
+// UploadCheck is the outcome of UsageUseCase.CheckUpload.
+type UploadCheck struct {
+	// Quota applies to the tenant. The upload must still be recorded within its hard limits.
+	Quota domain.Quota
+	// Warning is set when the upload reaches a soft limit.
+	Warning string
+}
+
+type UsageUseCase interface {
+	// Usage returns the usage and quota of the tenant.
+	Usage(ctx context.Context, tenantID string) (*Usage, error)
+	// CheckUpload checks that the tenant can store one more image of the given size. It returns
+	// domain.ErrQuotaExceeded past a hard limit, and a warning when a soft limit is reached. Concurrent
+	// uploads can all pass the check, so the hard limits are enforced again when the upload is recorded.
+	CheckUpload(ctx context.Context, tenantID string, bytes int64) (*UploadCheck, error)
+	// Recalculate records the size of every stored object on its image or thumbnail and rebuilds the
+	// usage of every tenant from them. Uploads made while it runs may be miscounted.
+	Recalculate(ctx context.Context) (*UsageRecalculation, error)
+}
@@ -0,0 +1,393 @@
+// Package config holds the typed runtime configuration shared by the server and irsctl.
+//
+// Values are resolved in three layers: the defaults below, an optional YAML file named by
+// CONFIG_FILE, and environment variables (including a .env file in the working directory).
+// Every field documents the environment variable that overrides it in its env tag.
+package config
+
+import (
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/pkg/utils"
+	"time"
+)
+
+type Config struct {
+	HTTP       HTTPConfig       `yaml:"http"`
+	GRPC       GRPCConfig       `yaml:"grpc"`
+	DB         DBConfig         `yaml:"db"`
+	Redis      RedisConfig      `yaml:"redis"`
+	Minio      MinioConfig      `yaml:"minio"`
+	Images     ImageConfig      `yaml:"images"`
+	Compressed CompressedConfig `yaml:"compressed"`
+	Thumbnails ThumbnailConfig  `yaml:"thumbnails"`
+	Reprocess  ReprocessConfig  `yaml:"reprocess"`
+	Reaper     ReaperConfig     `yaml:"reaper"`
+	OrphanGC   OrphanGCConfig   `yaml:"orphan_gc"`
+	Log        LogConfig        `yaml:"log"`
+	Shutdown   ShutdownConfig   `yaml:"shutdown"`
+	Health     HealthConfig     `yaml:"health"`
+	Metrics    MetricsConfig    `yaml:"metrics"`
+	Tracing    TracingConfig    `yaml:"tracing"`
+	Auth       AuthConfig       `yaml:"auth"`
+	Access     AccessConfig     `yaml:"access"`
+	RateLimit  RateLimitConfig  `yaml:"rate_limit"`
+	Quota      QuotaConfig      `yaml:"quota"`
+	Cache      CacheConfig      `yaml:"cache"`
+	Files      FilesConfig      `yaml:"files"`
+	// SecretKey signs the tokens issued by the service and verifies HS256 bearer tokens.
+	SecretKey string `yaml:"secret_key" env:"APP_SECRET_KEY"`
+}
+
+type HTTPConfig struct {
+	Port int `yaml:"port" env:"SERVICE_PORT"`
+	// UploadToken is a legacy X-API-Key that stands for the default tenant. While it is empty and
+	// no bearer tokens are configured, requests without credentials are served as the default tenant.
+	UploadToken string `yaml:"upload_token" env:"REST_UPLOAD_TOKEN"`
+	// MaxUploadBytes caps the request body of uploads. 0 disables the cap.
+	MaxUploadBytes int64 `yaml:"max_upload_bytes" env:"REST_MAX_UPLOAD_BYTES"`
+}
+
+type GRPCConfig struct {
+	Port int `yaml:"port" env:"GRPC_PORT"`
+	// Token is the legacy authorization metadata of the default tenant, like HTTPConfig.UploadToken.
+	Token string `yaml:"token" env:"GRPC_TOKEN"`
+	// MaxUploadBytes caps the image bytes of an upload. 0 disables the cap.
+	MaxUploadBytes int `yaml:"max_upload_bytes" env:"GRPC_MAX_UPLOAD_BYTES"`
+}
+
+type DBConfig struct {
+	Host     string `yaml:"host" env:"DB_HOST"`
+	Port     int    `yaml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" env:"DB_USER"`
+	Password string `yaml:"password" env:"DB_PASS"`
+	Name     string `yaml:"name" env:"DB_NAME"`
+	// TimeZone is the session time zone of the connection, an IANA name such as UTC or Europe/Berlin.
+	TimeZone string `yaml:"timezone" env:"DB_TIMEZONE"`
+}
+
+type RedisConfig struct {
+	Host string `yaml:"host" env:"REDIS_HOST"`
+	Port int    `yaml:"port" env:"REDIS_PORT"`
+}
+
+type MinioConfig struct {
+	Endpoint  string `yaml:"endpoint" env:"MINIO_ENDPOINT"`
+	AccessKey string `yaml:"access_key" env:"MINIO_ROOT_USER"`
+	SecretKey string `yaml:"secret_key" env:"MINIO_ROOT_PASSWORD"`
+	Bucket    string `yaml:"bucket" env:"MINIO_BUCKET"`
+	Secure    bool   `yaml:"secure" env:"MINIO_SECURE"`
+	// PublicURL is the address clients reach the storage at, e.g. https://files.example.com.
+	// Presigned URLs are issued for it instead of Endpoint, which is often only reachable internally.
+	PublicURL string `yaml:"public_url" env:"MINIO_PUBLIC_URL"`
+}
+
+// ImageConfig limits the images accepted for processing. A zero value disables the corresponding check.
+type ImageConfig struct {
+	MaxWidth      int     `yaml:"max_width" env:"IMAGE_MAX_WIDTH"`
+	MaxHeight     int     `yaml:"max_height" env:"IMAGE_MAX_HEIGHT"`
+	MaxMegapixels float64 `yaml:"max_megapixels" env:"IMAGE_MAX_MEGAPIXELS"`
+	MaxFrames     int     `yaml:"max_frames" env:"IMAGE_MAX_FRAMES"`
+}
+
+// CompressedConfig overrides the encoding of the full-size compressed rendition.
+type CompressedConfig struct {
+	Mode         string  `yaml:"mode" env:"COMPRESSED_ENCODING_MODE"`
+	Quality      float32 `yaml:"quality" env:"COMPRESSED_QUALITY"`
+	NearLossless int     `yaml:"near_lossless" env:"COMPRESSED_NEAR_LOSSLESS"`
+	ExactAlpha   bool    `yaml:"exact_alpha" env:"COMPRESSED_EXACT_ALPHA"`
+	TryLossless  bool    `yaml:"try_lossless" env:"COMPRESSED_TRY_LOSSLESS"`
+}
+
+type ThumbnailConfig struct {
+	// Concurrency is the number of presets processed in parallel for one image.
+	Concurrency int `yaml:"concurrency" env:"THUMBNAIL_CONCURRENCY"`
+	// CPULimit is the number of CPU-bound slots shared by all images. 0 uses the number of CPUs.
+	CPULimit int `yaml:"cpu_limit" env:"THUMBNAIL_CPU_LIMIT"`
+}
+
+type ReprocessConfig struct {
+	// Rate is the number of images a bulk job processes per second.
+	Rate      float64 `yaml:"rate" env:"REPROCESS_RATE"`
+	BatchSize int     `yaml:"batch_size" env:"REPROCESS_BATCH_SIZE"`
+}
+
+type ReaperConfig struct {
+	// Interval between reaper runs. 0 disables the reaper.
+	Interval    time.Duration `yaml:"interval" env:"REAPER_INTERVAL"`
+	Timeout     time.Duration `yaml:"timeout" env:"REAPER_TIMEOUT"`
+	MaxAttempts int           `yaml:"max_attempts" env:"REAPER_MAX_ATTEMPTS"`
+}
+
+type OrphanGCConfig struct {
+	// Interval between orphaned object collections in the server. 0 disables them.
+	Interval    time.Duration `yaml:"interval" env:"ORPHAN_GC_INTERVAL"`
+	GracePeriod time.Duration `yaml:"grace_period" env:"ORPHAN_GC_GRACE_PERIOD"`
+	DryRun      bool          `yaml:"dry_run" env:"ORPHAN_GC_DRY_RUN"`
+}
+
+type LogConfig struct {
+	// Output lists the zap output paths, e.g. app.log, stdout or stderr.
+	Output []string `yaml:"output" env:"LOG_OUTPUT"`
+	// Level is the minimum level written: debug, info, warn or error.
+	Level string `yaml:"level" env:"LOG_LEVEL"`
+	// Format is json or console.
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+}
+
+type ShutdownConfig struct {
+	// Timeout bounds how long in-flight requests and background processing may take to finish.
+	Timeout time.Duration `yaml:"timeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+type HealthConfig struct {
+	// CheckTimeout bounds each readiness check (database, Redis, storage).
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT"`
+	// WatchInterval is how often grpc.health.v1 Watch streams re-run the checks.
+	WatchInterval time.Duration `yaml:"watch_interval" env:"HEALTH_WATCH_INTERVAL"`
+}
+
+type MetricsConfig struct {
+	// Enabled exposes Prometheus metrics on Path of the HTTP server.
+	Enabled bool   `yaml:"enabled" env:"METRICS_ENABLED"`
+	Path    string `yaml:"path" env:"METRICS_PATH"`
+}
+
+type TracingConfig struct {
+	// Endpoint is the OTLP/gRPC collector URL spans are exported to. Empty disables tracing.
+	Endpoint    string  `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	ServiceName string  `yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// AuthConfig configures the verification of JWT bearer tokens. HS256 tokens are verified with
+// APP_SECRET_KEY; RS256 and ES256 tokens with the keys of JWKS.
+type AuthConfig struct {
+	// JWKS is the path or http(s) URL of a JSON Web Key Set. Empty only accepts HS256 tokens.
+	JWKS string `yaml:"jwks" env:"AUTH_JWKS"`
+	// JWKSRefresh is how long fetched keys are cached before the set is read again.
+	JWKSRefresh time.Duration `yaml:"jwks_refresh" env:"AUTH_JWKS_REFRESH"`
+	// Issuer and Audience, when set, must match the iss and aud claims of every token.
+	Issuer   string `yaml:"issuer" env:"AUTH_JWT_ISSUER"`
+	Audience string `yaml:"audience" env:"AUTH_JWT_AUDIENCE"`
+}
+
+// AccessConfig controls how the files of private and public images are handed out.
+type AccessConfig struct {
+	// DefaultVisibility applies to uploads that do not choose a visibility: private or public.
+	DefaultVisibility string `yaml:"default_visibility" env:"ACCESS_DEFAULT_VISIBILITY"`
+	// PrivateURLTTL is how long the presigned URLs of private images stay valid.
+	PrivateURLTTL time.Duration `yaml:"private_url_ttl" env:"ACCESS_PRIVATE_URL_TTL"`
+	// PublicURLTTL is how long the presigned URLs of public images stay valid, at most 7 days.
+	PublicURLTTL time.Duration `yaml:"public_url_ttl" env:"ACCESS_PUBLIC_URL_TTL"`
+	// URLStrategy links files with presigned URLs, unsigned CDN URLs for public images or through the
+	// service: presigned, cdn or proxy. Empty selects cdn when PublicBaseURL is set, presigned otherwise.
+	URLStrategy string `yaml:"url_strategy" env:"ACCESS_URL_STRATEGY"`
+	// PublicBaseURL is the base of the unsigned <base>/<key> URLs of public images with the cdn
+	// strategy, typically a CDN in front of the bucket.
+	PublicBaseURL string `yaml:"public_base_url" env:"ACCESS_PUBLIC_BASE_URL"`
+	// ProxyBaseURL is the external URL of the service, under which the proxy strategy links files.
+	ProxyBaseURL string `yaml:"proxy_base_url" env:"ACCESS_PROXY_BASE_URL"`
+	// Disposition of presigned and proxied downloads: inline or attachment. DownloadFilename names
+	// them after the object key, its last segment or not at all: key, name or none.
+	Disposition      string `yaml:"disposition" env:"ACCESS_DISPOSITION"`
+	DownloadFilename string `yaml:"download_filename" env:"ACCESS_DOWNLOAD_FILENAME"`
+	// TokenTTL is the lifetime of image access tokens when the caller does not choose one;
+	// TokenMaxTTL caps the lifetime a caller can choose.
+	TokenTTL    time.Duration `yaml:"token_ttl" env:"ACCESS_TOKEN_TTL"`
+	TokenMaxTTL time.Duration `yaml:"token_max_ttl" env:"ACCESS_TOKEN_MAX_TTL"`
+}
+
+// RateLimitConfig limits requests with token buckets kept in Redis, so every replica shares them.
+// Each limit is the number of requests allowed per Period, which is also the burst a bucket allows;
+// 0 disables the limit.
+type RateLimitConfig struct {
+	Enabled bool          `yaml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	Period  time.Duration `yaml:"period" env:"RATE_LIMIT_PERIOD"`
+	// FailOpen lets requests through while Redis is unavailable; otherwise they are rejected.
+	FailOpen bool `yaml:"fail_open" env:"RATE_LIMIT_FAIL_OPEN"`
+	// Uploads and reprocessing, per API key or token subject, per tenant and per client IP.
+	UploadPerKey    int `yaml:"upload_per_key" env:"RATE_LIMIT_UPLOAD_PER_KEY"`
+	UploadPerTenant int `yaml:"upload_per_tenant" env:"RATE_LIMIT_UPLOAD_PER_TENANT"`
+	UploadPerIP     int `yaml:"upload_per_ip" env:"RATE_LIMIT_UPLOAD_PER_IP"`
+	// Image lookups and access tokens, with the same keys.
+	ReadPerKey    int `yaml:"read_per_key" env:"RATE_LIMIT_READ_PER_KEY"`
+	ReadPerTenant int `yaml:"read_per_tenant" env:"RATE_LIMIT_READ_PER_TENANT"`
+	ReadPerIP     int `yaml:"read_per_ip" env:"RATE_LIMIT_READ_PER_IP"`
+}
+
+// QuotaConfig is the storage quota of every tenant that has no quota of its own (irsctl tenants quota).
+// Bytes count the original and every rendition of an image. Soft limits only warn; uploads that would
+// go past a hard limit are rejected. 0 disables a limit.
+type QuotaConfig struct {
+	SoftBytes  int64 `yaml:"soft_bytes" env:"QUOTA_SOFT_BYTES"`
+	HardBytes  int64 `yaml:"hard_bytes" env:"QUOTA_HARD_BYTES"`
+	SoftImages int64 `yaml:"soft_images" env:"QUOTA_SOFT_IMAGES"`
+	HardImages int64 `yaml:"hard_images" env:"QUOTA_HARD_IMAGES"`
+}
+
+// CacheConfig keeps image lookups and presigned URLs in Redis, shared by every replica.
+type CacheConfig struct {
+	Enabled bool `yaml:"enabled" env:"CACHE_ENABLED"`
+	// ImageTTL bounds how long an image lookup is served from the cache. Changes made through the
+	// service invalidate it right away.
+	ImageTTL time.Duration `yaml:"image_ttl" env:"CACHE_IMAGE_TTL"`
+	// URLTTL bounds how long a presigned URL is reused.
+	URLTTL time.Duration `yaml:"url_ttl" env:"CACHE_URL_TTL"`
+}
+
+// FilesConfig controls the file proxy, which streams stored files through the service.
+type FilesConfig struct {
+	// PublicCacheControl and PrivateCacheControl are the Cache-Control headers of the files of public
+	// and private images. Empty values send none.
+	PublicCacheControl  string `yaml:"public_cache_control" env:"FILES_PUBLIC_CACHE_CONTROL"`
+	PrivateCacheControl string `yaml:"private_cache_control" env:"FILES_PRIVATE_CACHE_CONTROL"`
+}
+
+// Default returns the configuration used for every value that is neither in the file nor in the environment.
+func Default() *Config {
+	return &Config{
+		HTTP: HTTPConfig{
+			Port:           8000,
+			MaxUploadBytes: 50 << 20,
+		},
+		GRPC: GRPCConfig{
+			Port:           50051,
+			MaxUploadBytes: 50 << 20,
+		},
+		DB: DBConfig{
+			Host:     "localhost",
+			Port:     5432,
+			TimeZone: "UTC",
+		},
+		Redis: RedisConfig{
+			Host: "localhost",
+			Port: 6379,
+		},
+		Minio: MinioConfig{
+			Bucket: "images",
+		},
+		Images: ImageConfig{
+			MaxWidth:      16384,
+			MaxHeight:     16384,
+			MaxMegapixels: 100,
+			MaxFrames:     300,
+		},
+		Compressed: CompressedConfig{
+			Mode:         string(domain.CompressedEncoding.Mode),
+			Quality:      domain.CompressedEncoding.Quality,
+			NearLossless: domain.CompressedEncoding.NearLosslessLevel,
+			ExactAlpha:   domain.CompressedEncoding.ExactAlpha,
+			TryLossless:  domain.CompressedEncoding.TryLossless,
+		},
+		Thumbnails: ThumbnailConfig{
+			Concurrency: 4,
+		},
+		Reprocess: ReprocessConfig{
+			Rate:      2,
+			BatchSize: 100,
+		},
+		Reaper: ReaperConfig{
+			Interval:    time.Minute,
+			Timeout:     15 * time.Minute,
+			MaxAttempts: 3,
+		},
+		OrphanGC: OrphanGCConfig{
+			GracePeriod: 24 * time.Hour,
+		},
+		Log: LogConfig{
+			Output: []string{"app.log", "stdout"},
+			Level:  "info",
+			Format: "console",
+		},
+		Shutdown: ShutdownConfig{
+			Timeout: 30 * time.Second,
+		},
+		Health: HealthConfig{
+			CheckTimeout:  2 * time.Second,
+			WatchInterval: 5 * time.Second,
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Path:    "/metrics",
+		},
+		Tracing: TracingConfig{
+			ServiceName: "image-resizing-service",
+			SampleRatio: 1,
+		},
+		Auth: AuthConfig{
+			JWKSRefresh: 15 * time.Minute,
+		},
+		Access: AccessConfig{
+			DefaultVisibility: string(domain.VisibilityPrivate),
+			PrivateURLTTL:     15 * time.Minute,
+			PublicURLTTL:      7 * 24 * time.Hour,
+			Disposition:       utils.DispositionAttachment,
+			DownloadFilename:  utils.DownloadFilenameKey,
+			TokenTTL:          15 * time.Minute,
+			TokenMaxTTL:       time.Hour,
+		},
+		RateLimit: RateLimitConfig{
+			Period:          time.Minute,
+			FailOpen:        true,
+			UploadPerKey:    60,
+			UploadPerTenant: 300,
+			UploadPerIP:     120,
+			ReadPerKey:      600,
+			ReadPerTenant:   3000,
+			ReadPerIP:       1200,
+		},
+		Cache: CacheConfig{
+			Enabled:  true,
+			ImageTTL: time.Minute,
+			URLTTL:   5 * time.Minute,
+		},
+		Files: FilesConfig{
+			PublicCacheControl:  "public, max-age=3600",
+			PrivateCacheControl: "private, max-age=300",
+		},
+	}
+}
+
+// DefaultQuota returns the configured quota of tenants without their own.
+func (c *Config) DefaultQuota() domain.Quota {
+	return domain.Quota{
+		SoftBytes:  c.Quota.SoftBytes,
+		HardBytes:  c.Quota.HardBytes,
+		SoftImages: c.Quota.SoftImages,
+		HardImages: c.Quota.HardImages,
+	}
+}
+
+// URLStrategy returns the configured strategy, resolving an empty one.
+func (c *Config) URLStrategy() domain.URLStrategy {
+	if c.Access.URLStrategy != "" {
+		return domain.URLStrategy(c.Access.URLStrategy)
+	}
+	if c.Access.PublicBaseURL != "" {
+		return domain.URLCDN
+	}
+	return domain.URLPresigned
+}
+
+// CacheTTLs returns the configured cache TTLs, each capped at a quarter of the shortest presigned URL
+// lifetime. A cached response may hold a cached URL, so URLs are never served with less than half
+// their lifetime left.
+func (c *Config) CacheTTLs() (imageTTL, urlTTL time.Duration) {
+	shortest := c.Access.PrivateURLTTL
+	if c.URLStrategy() == domain.URLPresigned {
+		shortest = min(shortest, c.Access.PublicURLTTL)
+	}
+
+	return min(c.Cache.ImageTTL, shortest/4), min(c.Cache.URLTTL, shortest/4)
+}
+
+// CompressedEncoding returns domain.CompressedEncoding with the configured overrides applied.
+func (c *Config) CompressedEncoding() domain.EncodingSettings {
+	encoding := domain.CompressedEncoding
+	encoding.Mode = domain.EncodingMode(c.Compressed.Mode)
+	encoding.Quality = c.Compressed.Quality
+	encoding.NearLosslessLevel = c.Compressed.NearLossless
+	encoding.ExactAlpha = c.Compressed.ExactAlpha
+	encoding.TryLossless = c.Compressed.TryLossless
+	return encoding
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileEnv names the environment variable holding the path of the optional YAML file.
+const FileEnv = "CONFIG_FILE"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Load resolves the configuration from the defaults, the YAML file named by CONFIG_FILE and the
+// environment, in that order of precedence, and validates the result.
+func Load() (*Config, error) {
+	// A missing .env is fine: the variables may come from the container or the shell.
+	_ = godotenv.Load()
+
+	cfg := Default()
+
+	if path := os.Getenv(FileEnv); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// loadEnv overrides every field that has an env tag with the value of that variable. Empty
+// variables are treated as unset, so the blank entries of .env.example keep their defaults.
+func (c *Config) loadEnv() error {
+	var problems []string
+	walkEnvFields(reflect.ValueOf(c).Elem(), func(field reflect.Value, key string) {
+		value := strings.TrimSpace(os.Getenv(key))
+		if value == "" {
+			return
+		}
+		if err := setField(field, value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+		}
+	})
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+func walkEnvFields(value reflect.Value, fn func(field reflect.Value, key string)) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		if key := value.Type().Field(i).Tag.Get("env"); key != "" {
+			fn(field, key)
+			continue
+		}
+		if field.Kind() == reflect.Struct {
+			walkEnvFields(field, fn)
+		}
+	}
+}
+
+func setField(field reflect.Value, value string) error {
+	if field.Type() == durationType {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q, expected a value such as 90s, 15m or 24h", value)
+		}
+		field.SetInt(int64(duration))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int64:
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		field.SetInt(number)
+	case reflect.Float32, reflect.Float64:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		field.SetFloat(number)
+	case reflect.Bool:
+		flag, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q, expected true or false", value)
+		}
+		field.SetBool(flag)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
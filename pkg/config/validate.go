@@ -0,0 +1,220 @@
+package config
+
+import (
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/pkg/utils"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	// Embeds the time zone database so DB_TIMEZONE can be validated on images without tzdata.
+	_ "time/tzdata"
+)
+
+// ValidationError lists every problem found in the configuration, so they can be fixed in one go.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) check(ok bool, path, env, format string, args ...any) {
+	if !ok {
+		v.problems = append(v.problems, fmt.Sprintf("%s (%s): %s", path, env, fmt.Sprintf(format, args...)))
+	}
+}
+
+func (v *validator) required(value, path, env string) {
+	v.check(strings.TrimSpace(value) != "", path, env, "is required")
+}
+
+func (v *validator) port(value int, path, env string) {
+	v.check(value > 0 && value <= 65535, path, env, "must be between 1 and 65535, got %d", value)
+}
+
+// Validate reports every missing or out-of-range value.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.port(c.HTTP.Port, "http.port", "SERVICE_PORT")
+	v.check(c.HTTP.MaxUploadBytes >= 0, "http.max_upload_bytes", "REST_MAX_UPLOAD_BYTES", "must not be negative, use 0 to disable the cap")
+	v.port(c.GRPC.Port, "grpc.port", "GRPC_PORT")
+	v.check(c.GRPC.MaxUploadBytes >= 0, "grpc.max_upload_bytes", "GRPC_MAX_UPLOAD_BYTES", "must not be negative, use 0 to disable the cap")
+	v.check(c.HTTP.Port != c.GRPC.Port, "grpc.port", "GRPC_PORT", "must differ from http.port, both are %d", c.GRPC.Port)
+
+	v.required(c.DB.Host, "db.host", "DB_HOST")
+	v.port(c.DB.Port, "db.port", "DB_PORT")
+	v.required(c.DB.User, "db.user", "DB_USER")
+	v.required(c.DB.Name, "db.name", "DB_NAME")
+	if _, err := time.LoadLocation(c.DB.TimeZone); err != nil || c.DB.TimeZone == "" {
+		v.check(false, "db.timezone", "DB_TIMEZONE", "unknown time zone %q, expected an IANA name such as UTC or Europe/Berlin", c.DB.TimeZone)
+	}
+
+	v.required(c.Redis.Host, "redis.host", "REDIS_HOST")
+	v.port(c.Redis.Port, "redis.port", "REDIS_PORT")
+
+	v.required(c.Minio.Endpoint, "minio.endpoint", "MINIO_ENDPOINT")
+	v.required(c.Minio.AccessKey, "minio.access_key", "MINIO_ROOT_USER")
+	v.required(c.Minio.SecretKey, "minio.secret_key", "MINIO_ROOT_PASSWORD")
+	v.required(c.Minio.Bucket, "minio.bucket", "MINIO_BUCKET")
+	if c.Minio.PublicURL != "" {
+		public, err := url.Parse(c.Minio.PublicURL)
+		v.check(err == nil && (public.Scheme == "http" || public.Scheme == "https") && public.Host != "" && strings.Trim(public.Path, "/") == "", "minio.public_url", "MINIO_PUBLIC_URL", "must be an http(s) URL without a path, got %q", c.Minio.PublicURL)
+	}
+
+	v.check(c.Images.MaxWidth >= 0, "images.max_width", "IMAGE_MAX_WIDTH", "must not be negative, use 0 to disable the limit")
+	v.check(c.Images.MaxHeight >= 0, "images.max_height", "IMAGE_MAX_HEIGHT", "must not be negative, use 0 to disable the limit")
+	v.check(c.Images.MaxMegapixels >= 0, "images.max_megapixels", "IMAGE_MAX_MEGAPIXELS", "must not be negative, use 0 to disable the limit")
+	v.check(c.Images.MaxFrames >= 0, "images.max_frames", "IMAGE_MAX_FRAMES", "must not be negative, use 0 to disable the limit")
+
+	switch domain.EncodingMode(c.Compressed.Mode) {
+	case domain.EncodingAuto, domain.EncodingLossy, domain.EncodingLossless, domain.EncodingNearLossless:
+	default:
+		v.check(false, "compressed.mode", "COMPRESSED_ENCODING_MODE", "unknown mode %q, expected auto, lossy, lossless or near_lossless", c.Compressed.Mode)
+	}
+	v.check(c.Compressed.Quality >= 0 && c.Compressed.Quality <= 100, "compressed.quality", "COMPRESSED_QUALITY", "must be between 0 and 100, got %g", c.Compressed.Quality)
+	v.check(c.Compressed.NearLossless >= 0 && c.Compressed.NearLossless <= 100, "compressed.near_lossless", "COMPRESSED_NEAR_LOSSLESS", "must be between 0 and 100, got %d", c.Compressed.NearLossless)
+	v.check(!c.Compressed.ExactAlpha || domain.EncodingMode(c.Compressed.Mode) != domain.EncodingLossy, "compressed.exact_alpha", "COMPRESSED_EXACT_ALPHA", "has no effect in lossy mode, only lossless output keeps transparent pixels exact")
+
+	v.check(c.Thumbnails.Concurrency > 0, "thumbnails.concurrency", "THUMBNAIL_CONCURRENCY", "must be at least 1, got %d", c.Thumbnails.Concurrency)
+	v.check(c.Thumbnails.CPULimit >= 0, "thumbnails.cpu_limit", "THUMBNAIL_CPU_LIMIT", "must not be negative, use 0 for the number of CPUs")
+
+	v.check(c.Reprocess.Rate > 0, "reprocess.rate", "REPROCESS_RATE", "must be greater than 0 images per second, got %g", c.Reprocess.Rate)
+	v.check(c.Reprocess.BatchSize > 0, "reprocess.batch_size", "REPROCESS_BATCH_SIZE", "must be at least 1, got %d", c.Reprocess.BatchSize)
+
+	v.check(c.Reaper.Interval >= 0, "reaper.interval", "REAPER_INTERVAL", "must not be negative, use 0 to disable the reaper")
+	v.check(c.Reaper.Timeout > 0, "reaper.timeout", "REAPER_TIMEOUT", "must be greater than 0, got %s", c.Reaper.Timeout)
+	v.check(c.Reaper.MaxAttempts >= 0, "reaper.max_attempts", "REAPER_MAX_ATTEMPTS", "must not be negative, got %d", c.Reaper.MaxAttempts)
+
+	v.check(c.OrphanGC.Interval >= 0, "orphan_gc.interval", "ORPHAN_GC_INTERVAL", "must not be negative, use 0 to disable periodic collection")
+	v.check(c.OrphanGC.GracePeriod >= 0, "orphan_gc.grace_period", "ORPHAN_GC_GRACE_PERIOD", "must not be negative, got %s", c.OrphanGC.GracePeriod)
+
+	v.check(len(c.Log.Output) > 0, "log.output", "LOG_OUTPUT", "needs at least one output path")
+	if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+		v.check(false, "log.level", "LOG_LEVEL", "must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+	v.check(c.Log.Format == "json" || c.Log.Format == "console", "log.format", "LOG_FORMAT", "must be json or console, got %q", c.Log.Format)
+
+	v.check(c.Shutdown.Timeout > 0, "shutdown.timeout", "SHUTDOWN_TIMEOUT", "must be greater than 0, got %s", c.Shutdown.Timeout)
+
+	v.check(c.Health.CheckTimeout > 0, "health.check_timeout", "HEALTH_CHECK_TIMEOUT", "must be greater than 0, got %s", c.Health.CheckTimeout)
+	v.check(c.Health.WatchInterval > 0, "health.watch_interval", "HEALTH_WATCH_INTERVAL", "must be greater than 0, got %s", c.Health.WatchInterval)
+
+	if c.Metrics.Enabled {
+		v.check(strings.HasPrefix(c.Metrics.Path, "/"), "metrics.path", "METRICS_PATH", "must start with /, got %q", c.Metrics.Path)
+	}
+
+	if c.Tracing.Endpoint != "" {
+		endpoint, err := url.Parse(c.Tracing.Endpoint)
+		v.check(err == nil && (endpoint.Scheme == "http" || endpoint.Scheme == "https") && endpoint.Host != "",
+			"tracing.endpoint", "OTEL_EXPORTER_OTLP_ENDPOINT", "must be an http(s) URL such as http://otel-collector:4317, got %q", c.Tracing.Endpoint)
+		v.required(c.Tracing.ServiceName, "tracing.service_name", "OTEL_SERVICE_NAME")
+	}
+	v.check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "TRACING_SAMPLE_RATIO", "must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+
+	if c.Auth.JWKS != "" {
+		if jwks, err := url.Parse(c.Auth.JWKS); err == nil && (jwks.Scheme == "http" || jwks.Scheme == "https") {
+			v.check(jwks.Host != "", "auth.jwks", "AUTH_JWKS", "must be a file path or an http(s) URL, got %q", c.Auth.JWKS)
+		} else if _, err := os.Stat(c.Auth.JWKS); err != nil {
+			v.check(false, "auth.jwks", "AUTH_JWKS", "cannot read the key set file: %v", err)
+		}
+		v.check(c.Auth.JWKSRefresh > 0, "auth.jwks_refresh", "AUTH_JWKS_REFRESH", "must be greater than 0, got %s", c.Auth.JWKSRefresh)
+	}
+
+	switch domain.Visibility(c.Access.DefaultVisibility) {
+	case domain.VisibilityPrivate, domain.VisibilityPublic:
+	default:
+		v.check(false, "access.default_visibility", "ACCESS_DEFAULT_VISIBILITY", "must be private or public, got %q", c.Access.DefaultVisibility)
+	}
+	// S3 rejects presigned URLs valid for more than 7 days.
+	v.check(c.Access.PrivateURLTTL > 0 && c.Access.PrivateURLTTL <= 7*24*time.Hour, "access.private_url_ttl", "ACCESS_PRIVATE_URL_TTL", "must be between 1s and 168h, got %s", c.Access.PrivateURLTTL)
+	v.check(c.Access.PublicURLTTL > 0 && c.Access.PublicURLTTL <= 7*24*time.Hour, "access.public_url_ttl", "ACCESS_PUBLIC_URL_TTL", "must be between 1s and 168h, got %s", c.Access.PublicURLTTL)
+	if c.Access.PublicBaseURL != "" {
+		base, err := url.Parse(c.Access.PublicBaseURL)
+		v.check(err == nil && (base.Scheme == "http" || base.Scheme == "https") && base.Host != "", "access.public_base_url", "ACCESS_PUBLIC_BASE_URL", "must be an http(s) URL, got %q", c.Access.PublicBaseURL)
+	}
+	if c.Access.ProxyBaseURL != "" {
+		base, err := url.Parse(c.Access.ProxyBaseURL)
+		v.check(err == nil && (base.Scheme == "http" || base.Scheme == "https") && base.Host != "", "access.proxy_base_url", "ACCESS_PROXY_BASE_URL", "must be an http(s) URL, got %q", c.Access.ProxyBaseURL)
+	}
+	switch strategy := c.URLStrategy(); strategy {
+	case domain.URLPresigned:
+	case domain.URLCDN:
+		v.check(c.Access.PublicBaseURL != "", "access.public_base_url", "ACCESS_PUBLIC_BASE_URL", "is required by the cdn URL strategy")
+	case domain.URLProxy:
+		v.check(c.Access.ProxyBaseURL != "", "access.proxy_base_url", "ACCESS_PROXY_BASE_URL", "is required by the proxy URL strategy")
+		// The proxy URLs of private files carry access tokens.
+		v.check(c.SecretKey != "", "secret_key", "APP_SECRET_KEY", "is required by the proxy URL strategy")
+	default:
+		v.check(false, "access.url_strategy", "ACCESS_URL_STRATEGY", "must be presigned, cdn or proxy, got %q", strategy)
+	}
+	if c.Access.PublicBaseURL != "" {
+		v.check(c.URLStrategy() == domain.URLCDN, "access.public_base_url", "ACCESS_PUBLIC_BASE_URL", "is only used by the cdn URL strategy, got %s", c.URLStrategy())
+	}
+	switch c.Access.Disposition {
+	case utils.DispositionInline, utils.DispositionAttachment:
+	default:
+		v.check(false, "access.disposition", "ACCESS_DISPOSITION", "must be inline or attachment, got %q", c.Access.Disposition)
+	}
+	switch c.Access.DownloadFilename {
+	case utils.DownloadFilenameKey, utils.DownloadFilenameName, utils.DownloadFilenameNone:
+	default:
+		v.check(false, "access.download_filename", "ACCESS_DOWNLOAD_FILENAME", "must be key, name or none, got %q", c.Access.DownloadFilename)
+	}
+	v.check(c.Access.TokenTTL > 0, "access.token_ttl", "ACCESS_TOKEN_TTL", "must be greater than 0, got %s", c.Access.TokenTTL)
+	v.check(c.Access.TokenMaxTTL >= c.Access.TokenTTL, "access.token_max_ttl", "ACCESS_TOKEN_MAX_TTL", "must not be shorter than access.token_ttl, got %s", c.Access.TokenMaxTTL)
+
+	if c.RateLimit.Enabled {
+		v.check(c.RateLimit.Period > 0, "rate_limit.period", "RATE_LIMIT_PERIOD", "must be greater than 0, got %s", c.RateLimit.Period)
+		for _, limit := range []struct {
+			value     int
+			path, env string
+		}{
+			{c.RateLimit.UploadPerKey, "rate_limit.upload_per_key", "RATE_LIMIT_UPLOAD_PER_KEY"},
+			{c.RateLimit.UploadPerTenant, "rate_limit.upload_per_tenant", "RATE_LIMIT_UPLOAD_PER_TENANT"},
+			{c.RateLimit.UploadPerIP, "rate_limit.upload_per_ip", "RATE_LIMIT_UPLOAD_PER_IP"},
+			{c.RateLimit.ReadPerKey, "rate_limit.read_per_key", "RATE_LIMIT_READ_PER_KEY"},
+			{c.RateLimit.ReadPerTenant, "rate_limit.read_per_tenant", "RATE_LIMIT_READ_PER_TENANT"},
+			{c.RateLimit.ReadPerIP, "rate_limit.read_per_ip", "RATE_LIMIT_READ_PER_IP"},
+		} {
+			v.check(limit.value >= 0, limit.path, limit.env, "must not be negative, use 0 to disable the limit")
+		}
+	}
+
+	for _, limit := range []struct {
+		value     int64
+		path, env string
+	}{
+		{c.Quota.SoftBytes, "quota.soft_bytes", "QUOTA_SOFT_BYTES"},
+		{c.Quota.HardBytes, "quota.hard_bytes", "QUOTA_HARD_BYTES"},
+		{c.Quota.SoftImages, "quota.soft_images", "QUOTA_SOFT_IMAGES"},
+		{c.Quota.HardImages, "quota.hard_images", "QUOTA_HARD_IMAGES"},
+	} {
+		v.check(limit.value >= 0, limit.path, limit.env, "must not be negative, use 0 to disable the limit")
+	}
+	if c.Quota.HardBytes > 0 {
+		v.check(c.Quota.SoftBytes <= c.Quota.HardBytes, "quota.soft_bytes", "QUOTA_SOFT_BYTES", "must not be greater than quota.hard_bytes (%d), got %d", c.Quota.HardBytes, c.Quota.SoftBytes)
+	}
+	if c.Quota.HardImages > 0 {
+		v.check(c.Quota.SoftImages <= c.Quota.HardImages, "quota.soft_images", "QUOTA_SOFT_IMAGES", "must not be greater than quota.hard_images (%d), got %d", c.Quota.HardImages, c.Quota.SoftImages)
+	}
+
+	if c.Cache.Enabled {
+		v.check(c.Cache.ImageTTL > 0, "cache.image_ttl", "CACHE_IMAGE_TTL", "must be greater than 0, got %s", c.Cache.ImageTTL)
+		v.check(c.Cache.URLTTL > 0, "cache.url_ttl", "CACHE_URL_TTL", "must be greater than 0, got %s", c.Cache.URLTTL)
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+
+	return nil
+}
@@ -1,71 +1,217 @@
 package di
 
 import (
+	"context"
 	"github.com/go-playground/validator/v10"
 	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
 	"gorm.io/gorm"
 	"image-resizing-service/internal/app"
 	"image-resizing-service/internal/assembler"
+	"image-resizing-service/internal/domain"
+	"image-resizing-service/internal/infrastructure/cache"
 	"image-resizing-service/internal/infrastructure/db"
 	"image-resizing-service/internal/ports"
+	"image-resizing-service/pkg/config"
+	"image-resizing-service/pkg/logging"
+	"image-resizing-service/pkg/metrics"
+	"image-resizing-service/pkg/tracing"
 	"image-resizing-service/pkg/utils"
-	"os"
+	"runtime"
+	"time"
 )
 
 type Dependencies struct {
-	Logger      *zap.Logger
+	Config      *config.Config
+	Logger      *logging.Logger
 	Redis       *redis.Client
 	DB          *gorm.DB
 	Validator   *validator.Validate
 	MinioClient *utils.MinioClient
+	// ShutdownTracing flushes the spans that have not been exported yet.
+	ShutdownTracing func(context.Context) error
 	// Repositories
-	ImageRepo     ports.ImageRepository
-	ThumbnailRepo ports.ThumbnailRepository
+	ImageRepo        ports.ImageRepository
+	ThumbnailRepo    ports.ThumbnailRepository
+	ReprocessJobRepo ports.ReprocessJobRepository
+	PresetRepo       ports.PresetRepository
+	TenantRepo       ports.TenantRepository
 	// Usecases
-	ImageUsecase  ports.ImageUseCase
-	ResizeUsecase ports.ResizeUseCase
+	ImageUsecase       ports.ImageUseCase
+	ResizeUsecase      ports.ResizeUseCase
+	ReprocessUsecase   ports.ReprocessUseCase
+	PresetUsecase      ports.PresetUseCase
+	MaintenanceUsecase ports.MaintenanceUseCase
+	ReaperUsecase      ports.ReaperUseCase
+	HealthUsecase      ports.HealthUseCase
+	TenantUsecase      ports.TenantUseCase
+	AuthUsecase        ports.AuthUseCase
+	UsageUsecase       ports.UsageUseCase
+	FileUsecase        ports.FileUseCase
+	// RateLimitUsecase is nil while rate limiting is disabled.
+	RateLimitUsecase ports.RateLimitUseCase
 
 	// Builders
 	RestImageAssembler *assembler.RestImageAssembler
 	GRPCImageAssembler *assembler.GRPCImageAssembler
 }
 
-func InitDependencies() *Dependencies {
+// InitDependencies wires every component from cfg. No component reads the environment itself.
+func InitDependencies(cfg *config.Config) *Dependencies {
 	// Infrastructure
-	logger := utils.InitLogs()
-	utils.LoadEnv()
-	redisConn := utils.CreateRedisConn(os.Getenv("REDIS_HOST"), os.Getenv("REDIS_PORT"))
-	dbConn := utils.InitDBConnection(os.Getenv("DB_HOST"), os.Getenv("DB_USER"), os.Getenv("DB_PASS"), os.Getenv("DB_NAME"), os.Getenv("DB_PORT"))
+	logger := logging.New(utils.InitLogs(cfg.Log.Output, cfg.Log.Level, cfg.Log.Format))
+	redisConn := utils.CreateRedisConn(cfg.Redis.Host, cfg.Redis.Port)
+	dbConn := utils.InitDBConnection(cfg.DB.Host, cfg.DB.User, cfg.DB.Password, cfg.DB.Name, cfg.DB.Port, cfg.DB.TimeZone)
+	dbConn.Logger = logging.NewGORMLogger(logger, 200*time.Millisecond)
 
 	utils.InitMigrations(dbConn)
 
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Settings{
+		Endpoint:    cfg.Tracing.Endpoint,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logger.Fatal("failed to initialize tracing", zap.Error(err))
+	}
+	if err := dbConn.Use(tracing.GORMPlugin{}); err != nil {
+		logger.Fatal("failed to register the gorm tracing plugin", zap.Error(err))
+	}
+
 	validate := utils.InitValidator()
 
-	minioClient := utils.NewMinioClient(os.Getenv("MINIO_ENDPOINT"), os.Getenv("MINIO_ROOT_USER"), os.Getenv("MINIO_ROOT_PASSWORD"), utils.BucketName, os.Getenv("MINIO_SECURE") == "true")
+	imageLimits := utils.ImageLimits{
+		MaxWidth:      cfg.Images.MaxWidth,
+		MaxHeight:     cfg.Images.MaxHeight,
+		MaxMegapixels: cfg.Images.MaxMegapixels,
+		MaxFrames:     cfg.Images.MaxFrames,
+	}
+
+	cpuLimit := cfg.Thumbnails.CPULimit
+	if cpuLimit <= 0 {
+		cpuLimit = runtime.NumCPU()
+	}
+
+	resizeConcurrency := app.ResizeConcurrency{
+		PerImage: cfg.Thumbnails.Concurrency,
+		CPUSlots: semaphore.NewWeighted(int64(cpuLimit)),
+	}
+
+	reprocessThrottle := app.ReprocessThrottle{
+		Interval:  time.Duration(float64(time.Second) / cfg.Reprocess.Rate),
+		BatchSize: cfg.Reprocess.BatchSize,
+	}
+
+	reaperSettings := app.ReaperSettings{
+		Timeout:     cfg.Reaper.Timeout,
+		MaxAttempts: cfg.Reaper.MaxAttempts,
+	}
+
+	var jwks *utils.JWKS
+	if cfg.Auth.JWKS != "" {
+		jwks = utils.NewJWKS(cfg.Auth.JWKS, cfg.Auth.JWKSRefresh)
+		// An unreachable key server should not keep the service down; tokens are retried against it.
+		if err := jwks.Load(context.Background()); err != nil {
+			logger.Warn("failed to load the JWKS", zap.Error(err))
+		}
+	}
+	tokenVerifier := utils.NewTokenVerifier([]byte(cfg.SecretKey), jwks, cfg.Auth.Issuer, cfg.Auth.Audience)
+
+	minioClient := utils.NewMinioClient(cfg.Minio.Endpoint, cfg.Minio.AccessKey, cfg.Minio.SecretKey, cfg.Minio.Bucket, cfg.Minio.Secure, cfg.Minio.PublicURL)
 
 	// Repositories
 	imageRepo := db.NewImageRepository(dbConn)
 	thumbnailRepo := db.NewThumbnailRepository(dbConn)
+	reprocessJobRepo := db.NewReprocessJobRepository(dbConn)
+	presetRepo := db.NewPresetRepository(dbConn)
+	tenantRepo := db.NewTenantRepository(dbConn)
+	usageRepo := db.NewUsageRepository(dbConn)
+
+	// Cache, nil while disabled
+	var imageCache ports.ImageCache
+	if cfg.Cache.Enabled {
+		imageTTL, urlTTL := cfg.CacheTTLs()
+		imageCache = cache.NewImageCache(utils.NewRedisAdapter(redisConn), imageTTL, urlTTL, logger)
+	}
 
 	// Usecases
-	resizeUsecase := app.NewResizeService(dbConn, minioClient, thumbnailRepo, imageRepo)
-	imageUsecase := app.NewImageService(dbConn, imageRepo, resizeUsecase, minioClient, logger)
+	presetUsecase := app.NewPresetService(presetRepo, logger)
+	usageUsecase := app.NewUsageService(dbConn, usageRepo, tenantRepo, imageRepo, thumbnailRepo, minioClient, cfg.DefaultQuota(), logger)
+	resizeUsecase := app.NewResizeService(dbConn, minioClient, thumbnailRepo, imageRepo, usageRepo, resizeConcurrency, logger)
+	imageUsecase := app.NewImageService(dbConn, imageRepo, usageRepo, resizeUsecase, presetUsecase, usageUsecase, imageCache, minioClient, logger, imageLimits, cfg.CompressedEncoding(), domain.Visibility(cfg.Access.DefaultVisibility))
+	reprocessUsecase := app.NewReprocessService(reprocessJobRepo, imageRepo, imageUsecase, presetUsecase, reprocessThrottle, logger)
+	reaperUsecase := app.NewReaperService(imageRepo, imageUsecase, reprocessUsecase, imageCache, reaperSettings, logger)
+	healthUsecase := app.NewHealthService(dbConn, redisConn, minioClient, imageUsecase, cfg.Health.CheckTimeout, logger)
+	maintenanceUsecase := app.NewMaintenanceService(imageRepo, thumbnailRepo, reprocessJobRepo, usageRepo, imageCache, imageUsecase, resizeUsecase, presetUsecase, minioClient, logger)
+	tenantUsecase := app.NewTenantService(tenantRepo, logger)
+	fileUsecase := app.NewFileService(imageRepo, minioClient, logger)
+	var rateLimitUsecase ports.RateLimitUseCase
+	if cfg.RateLimit.Enabled {
+		rateLimitUsecase = app.NewRateLimitService(utils.NewRedisAdapter(redisConn), app.RateLimitSettings{
+			Period:   cfg.RateLimit.Period,
+			FailOpen: cfg.RateLimit.FailOpen,
+			Limits: map[domain.RateLimitClass]app.RateLimits{
+				domain.RateLimitUpload: {PerKey: cfg.RateLimit.UploadPerKey, PerTenant: cfg.RateLimit.UploadPerTenant, PerIP: cfg.RateLimit.UploadPerIP},
+				domain.RateLimitRead:   {PerKey: cfg.RateLimit.ReadPerKey, PerTenant: cfg.RateLimit.ReadPerTenant, PerIP: cfg.RateLimit.ReadPerIP},
+			},
+		}, logger)
+	}
+	authUsecase := app.NewAuthService(tokenVerifier, tenantUsecase, logger, []byte(cfg.SecretKey), cfg.Access.TokenTTL, cfg.Access.TokenMaxTTL)
+
+	metrics.RegisterImageStatusCollector(
+		[]string{string(domain.StatusPending), string(domain.StatusProcessing), string(domain.StatusReady), string(domain.StatusError)},
+		func() (map[string]int64, error) {
+			counts, err := imageRepo.CountByStatus()
+			if err != nil {
+				return nil, err
+			}
+			byStatus := make(map[string]int64, len(counts))
+			for _, count := range counts {
+				byStatus[count.Status] = count.Count
+			}
+			return byStatus, nil
+		},
+	)
 
 	// Assemblers
-	restImageAssembler := assembler.NewRestImageAssembler(minioClient)
-	grpcImageAssembler := assembler.NewGRPCImageAssembler(minioClient)
+	urlPolicy := assembler.URLPolicy{
+		Strategy:      cfg.URLStrategy(),
+		PrivateTTL:    cfg.Access.PrivateURLTTL,
+		PublicTTL:     cfg.Access.PublicURLTTL,
+		PublicBaseURL: cfg.Access.PublicBaseURL,
+		ProxyBaseURL:  cfg.Access.ProxyBaseURL,
+		AccessSecret:  []byte(cfg.SecretKey),
+		Disposition:   utils.Disposition{Type: cfg.Access.Disposition, Filename: cfg.Access.DownloadFilename},
+	}
+	restImageAssembler := assembler.NewRestImageAssembler(minioClient, urlPolicy, imageCache)
+	grpcImageAssembler := assembler.NewGRPCImageAssembler(minioClient, urlPolicy, imageCache)
 
 	return &Dependencies{
+		Config:             cfg,
 		Logger:             logger,
 		Redis:              redisConn,
 		DB:                 dbConn,
 		Validator:          validate,
 		MinioClient:        minioClient,
+		ShutdownTracing:    shutdownTracing,
 		ImageRepo:          imageRepo,
 		ThumbnailRepo:      thumbnailRepo,
+		ReprocessJobRepo:   reprocessJobRepo,
+		PresetRepo:         presetRepo,
+		TenantRepo:         tenantRepo,
 		ImageUsecase:       imageUsecase,
 		ResizeUsecase:      resizeUsecase,
+		ReprocessUsecase:   reprocessUsecase,
+		PresetUsecase:      presetUsecase,
+		MaintenanceUsecase: maintenanceUsecase,
+		ReaperUsecase:      reaperUsecase,
+		HealthUsecase:      healthUsecase,
+		TenantUsecase:      tenantUsecase,
+		AuthUsecase:        authUsecase,
+		UsageUsecase:       usageUsecase,
+		FileUsecase:        fileUsecase,
+		RateLimitUsecase:   rateLimitUsecase,
 		RestImageAssembler: restImageAssembler,
 		GRPCImageAssembler: grpcImageAssembler,
 	}
@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	gormutils "gorm.io/gorm/utils"
+	"time"
+)
+
+// GORMLogger writes the log of GORM through Logger, so failing and slow queries made by the
+// repositories carry the fields of the context they were bound to with WithContext.
+type GORMLogger struct {
+	logger        *Logger
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+}
+
+// NewGORMLogger logs failing queries as errors and queries slower than slowThreshold as warnings.
+// Every other query is logged at debug level.
+func NewGORMLogger(logger *Logger, slowThreshold time.Duration) *GORMLogger {
+	return &GORMLogger{
+		logger:        New(logger.WithOptions(zap.WithCaller(false))),
+		level:         gormlogger.Info,
+		slowThreshold: slowThreshold,
+	}
+}
+
+func (l *GORMLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+func (l *GORMLogger) Info(ctx context.Context, message string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.logger.Ctx(ctx).Info(fmt.Sprintf(message, args...))
+	}
+}
+
+func (l *GORMLogger) Warn(ctx context.Context, message string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.logger.Ctx(ctx).Warn(fmt.Sprintf(message, args...))
+	}
+}
+
+func (l *GORMLogger) Error(ctx context.Context, message string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.logger.Ctx(ctx).Error(fmt.Sprintf(message, args...))
+	}
+}
+
+func (l *GORMLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		l.logger.Ctx(ctx).Error("query failed", append(queryFields(fc, elapsed, gormutils.FileWithLineNum()), zap.Error(err))...)
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		l.logger.Ctx(ctx).Warn("slow query", queryFields(fc, elapsed, gormutils.FileWithLineNum())...)
+	case l.level >= gormlogger.Info && l.logger.Core().Enabled(zap.DebugLevel):
+		l.logger.Ctx(ctx).Debug("query", queryFields(fc, elapsed, gormutils.FileWithLineNum())...)
+	}
+}
+
+// queryFields describes a query. source must come from gormutils.FileWithLineNum called in Trace
+// itself, as it counts stack frames: zap would report GORM as the caller, not the repository.
+func queryFields(fc func() (string, int64), elapsed time.Duration, source string) []zap.Field {
+	sql, rows := fc()
+	return []zap.Field{
+		zap.String("sql", sql),
+		zap.Int64("rows", rows),
+		zap.Duration("duration", elapsed),
+		zap.String("source", source),
+	}
+}
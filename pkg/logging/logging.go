@@ -0,0 +1,65 @@
+// Package logging carries log fields through context.Context, so a line written deep inside
+// processing still names the request, image and stage it belongs to.
+package logging
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type fieldsKey struct{}
+
+// Logger is a zap.Logger that can pick up the fields attached to a context with With.
+type Logger struct {
+	*zap.Logger
+}
+
+func New(logger *zap.Logger) *Logger {
+	return &Logger{Logger: logger}
+}
+
+// Ctx returns the logger with the fields carried by ctx and, inside a trace, its trace_id.
+func (l *Logger) Ctx(ctx context.Context) *zap.Logger {
+	fields := fieldsFrom(ctx)
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		fields = append(fields, zap.String("trace_id", spanContext.TraceID().String()))
+	}
+	if len(fields) == 0 {
+		return l.Logger
+	}
+
+	return l.Logger.With(fields...)
+}
+
+// With attaches fields to ctx. A field replaces one with the same key that is already attached.
+func With(ctx context.Context, fields ...zap.Field) context.Context {
+	merged := fieldsFrom(ctx)
+	for _, field := range fields {
+		replaced := false
+		for i := range merged {
+			if merged[i].Key == field.Key {
+				merged[i] = field
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, field)
+		}
+	}
+
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// Inherit attaches the fields of origin to ctx. Background work uses it to keep logging the
+// request_id of the request that started it, without inheriting that request's cancellation.
+func Inherit(ctx context.Context, origin context.Context) context.Context {
+	return With(ctx, fieldsFrom(origin)...)
+}
+
+// fieldsFrom returns a copy of the fields attached to ctx, safe to append to.
+func fieldsFrom(ctx context.Context) []zap.Field {
+	fields, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+	return append([]zap.Field(nil), fields...)
+}
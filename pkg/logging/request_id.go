@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// RequestIDHeader carries the request ID on HTTP requests and responses. gRPC uses it lowercased as metadata key.
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// RequestIDFrom returns incoming if it is usable as a request ID, e.g. one set by a proxy, or a new one.
+// IDs are limited to printable ASCII, so a client cannot forge log lines through them.
+func RequestIDFrom(incoming string) string {
+	if incoming == "" || len(incoming) > maxRequestIDLength {
+		return uuid.NewString()
+	}
+	for _, c := range incoming {
+		if c < 0x21 || c > 0x7e {
+			return uuid.NewString()
+		}
+	}
+
+	return incoming
+}
+
+// WithRequestID attaches requestID to ctx as the request_id log field.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return With(ctx, zap.String("request_id", requestID))
+}
@@ -0,0 +1,139 @@
+// Package metrics defines the Prometheus metrics of the service. They are registered on Registry,
+// which cmd/start exposes on /metrics.
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"time"
+)
+
+const namespace = "irs"
+
+// Transports label uploads with where they came from.
+const (
+	TransportREST = "rest"
+	TransportGRPC = "grpc"
+	TransportCLI  = "cli"
+)
+
+// Registry holds every metric of the service together with the Go runtime and process collectors.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	Uploads = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploads_total",
+		Help:      "Originals stored, by transport and detected content type.",
+	}, []string{"transport", "content_type"})
+
+	UploadBytes = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upload_bytes_total",
+		Help:      "Bytes of stored originals, by transport and detected content type.",
+	}, []string{"transport", "content_type"})
+
+	// StageDuration is labelled with the preset a stage worked on: "original" for download and
+	// decode of the source, "compressed" for the full-size rendition, or the thumbnail preset label.
+	StageDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "processing_stage_duration_seconds",
+		Help:      "Duration of the download, decode, resize, encode and upload processing stages.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+	}, []string{"stage", "preset"})
+
+	ProcessingDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "processing_duration_seconds",
+		Help:      "Duration of processing one image end to end, by outcome.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12),
+	}, []string{"outcome"})
+
+	StatusTransitions = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "image_status_transitions_total",
+		Help:      "Image status changes. Newly stored images transition from \"new\".",
+	}, []string{"from", "to"})
+
+	Errors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "errors_total",
+		Help:      "Failed uploads and processing steps, by reason.",
+	}, []string{"reason"})
+
+	StorageDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "storage_operation_duration_seconds",
+		Help:      "Latency of object storage operations, by operation and result.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+	}, []string{"operation", "result"})
+
+	ReaperImages = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reaper_images_total",
+		Help:      "Stuck images handled by the reaper, by action (requeued or timed_out).",
+	}, []string{"action"})
+
+	RateLimited = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by rate limits, by class (upload or read) and exhausted limit (key, tenant or ip).",
+	}, []string{"class", "limit"})
+
+	CacheLookups = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Cache lookups by cache (image or url) and result (hit, miss or error).",
+	}, []string{"cache", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// ObserveStage records the duration of a processing stage that started at startedAt.
+func ObserveStage(stage, preset string, startedAt time.Time) {
+	StageDuration.WithLabelValues(stage, preset).Observe(time.Since(startedAt).Seconds())
+}
+
+// ObserveStorage records a storage operation that started at startedAt. It is meant to be deferred
+// with a pointer to the named error result of the operation.
+func ObserveStorage(operation string, startedAt time.Time, err *error) {
+	result := "ok"
+	if err != nil && *err != nil {
+		result = "error"
+	}
+
+	StorageDuration.WithLabelValues(operation, result).Observe(time.Since(startedAt).Seconds())
+}
+
+type transportKey struct{}
+
+// WithTransport tags ctx with the transport an upload arrived on.
+func WithTransport(ctx context.Context, transport string) context.Context {
+	return context.WithValue(ctx, transportKey{}, transport)
+}
+
+// TransportFromContext returns the transport set by WithTransport, or "unknown".
+func TransportFromContext(ctx context.Context) string {
+	if transport, ok := ctx.Value(transportKey{}).(string); ok {
+		return transport
+	}
+
+	return "unknown"
+}
+
+// Handler serves Registry in the Prometheus exposition format. A failing collector, such as the
+// image counts while the database is down, is left out instead of failing the whole scrape.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var imagesDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "images"),
+	"Images currently in each status.",
+	[]string{"status"}, nil,
+)
+
+// statusCollector reads the image counts from the database on every scrape, so the gauge is
+// correct across restarts and replicas instead of being derived from this process' transitions.
+type statusCollector struct {
+	count func() (map[string]int64, error)
+}
+
+// RegisterImageStatusCollector exports the images gauge from count. Statuses missing from its
+// result are reported as 0 when they are listed in statuses.
+func RegisterImageStatusCollector(statuses []string, count func() (map[string]int64, error)) {
+	Registry.MustRegister(&statusCollector{count: func() (map[string]int64, error) {
+		counts, err := count()
+		if err != nil {
+			return nil, err
+		}
+		for _, status := range statuses {
+			if _, ok := counts[status]; !ok {
+				counts[status] = 0
+			}
+		}
+		return counts, nil
+	}})
+}
+
+func (c *statusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- imagesDesc
+}
+
+func (c *statusCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := c.count()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(imagesDesc, err)
+		return
+	}
+
+	for status, count := range counts {
+		ch <- prometheus.MustNewConstMetric(imagesDesc, prometheus.GaugeValue, float64(count), status)
+	}
+}
@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GORMPlugin creates a span for every query whose context carries a span, e.g. through a repository's
+// WithContext. Queries outside of a trace are left alone rather than each becoming a root span.
+type GORMPlugin struct{}
+
+func (GORMPlugin) Name() string {
+	return "tracing"
+}
+
+func (GORMPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+
+	return errors.Join(
+		callback.Create().Before("gorm:create").Register("tracing:before_create", startQuerySpan("create")),
+		callback.Create().After("gorm:create").Register("tracing:after_create", endQuerySpan),
+		callback.Query().Before("gorm:query").Register("tracing:before_query", startQuerySpan("query")),
+		callback.Query().After("gorm:query").Register("tracing:after_query", endQuerySpan),
+		callback.Update().Before("gorm:update").Register("tracing:before_update", startQuerySpan("update")),
+		callback.Update().After("gorm:update").Register("tracing:after_update", endQuerySpan),
+		callback.Delete().Before("gorm:delete").Register("tracing:before_delete", startQuerySpan("delete")),
+		callback.Delete().After("gorm:delete").Register("tracing:after_delete", endQuerySpan),
+		callback.Row().Before("gorm:row").Register("tracing:before_row", startQuerySpan("row")),
+		callback.Row().After("gorm:row").Register("tracing:after_row", endQuerySpan),
+		callback.Raw().Before("gorm:raw").Register("tracing:before_raw", startQuerySpan("raw")),
+		callback.Raw().After("gorm:raw").Register("tracing:after_raw", endQuerySpan),
+	)
+}
+
+func startQuerySpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement.Context == nil {
+			return
+		}
+
+		ctx, span := StartChild(db.Statement.Context, "gorm."+operation,
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation.name", operation),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func endQuerySpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	if !span.IsRecording() {
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("db.collection.name", db.Statement.Table),
+		attribute.String("db.query.text", db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	End(span, err)
+}
@@ -0,0 +1,93 @@
+// Package tracing sets up OpenTelemetry tracing and holds the helpers used to create spans.
+// Without an OTLP endpoint the global no-op provider stays in place and spans cost next to nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "image-resizing-service"
+
+// Settings configures the exporter. An empty Endpoint disables tracing.
+type Settings struct {
+	// Endpoint is the OTLP/gRPC collector URL, e.g. http://otel-collector:4317.
+	Endpoint    string
+	ServiceName string
+	// SampleRatio is the fraction of new traces that are recorded, 0-1.
+	SampleRatio float64
+}
+
+// Init installs the global tracer provider and the W3C trace context propagator. The returned
+// function flushes buffered spans and must be called before the process exits.
+func Init(ctx context.Context, settings Settings) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if settings.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(settings.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(settings.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(settings.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span named name as a child of the span in ctx.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// StartChild is Start for spans that are only worth recording as part of a larger operation, such
+// as single queries or storage calls. Without a span in ctx it returns ctx and a no-op span.
+func StartChild(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+
+	return Start(ctx, name, attributes...)
+}
+
+// StartLinked starts a new root span for work that outlives the request that triggered it, such as
+// background processing after an upload. It links back to the span in origin, so the request and
+// the work it caused can be navigated in both directions without sharing one long trace.
+func StartLinked(ctx context.Context, origin context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	options := []trace.SpanStartOption{trace.WithNewRoot(), trace.WithAttributes(attributes...)}
+	if link := trace.LinkFromContext(origin); link.SpanContext.IsValid() {
+		options = append(options, trace.WithLinks(link))
+	}
+
+	return otel.Tracer(instrumentationName).Start(ctx, name, options...)
+}
+
+// End records err on the span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
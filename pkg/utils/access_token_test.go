@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyImageAccess(t *testing.T) {
+	secret := []byte("access-secret")
+	access := ImageAccess{
+		TenantID:  "2f0c8f4e-2d4b-4c1e-9a57-8a1f3c5e7d90",
+		ImageID:   "7b1d3f5a-9c2e-4e6f-8a0b-1c3d5e7f9a2b",
+		ExpiresAt: time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+
+	token := signAccess(t, secret, access)
+	payload, mac, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name    string
+		secret  []byte
+		token   string
+		wantErr bool
+	}{
+		{name: "valid", secret: secret, token: token},
+		{name: "wrong secret", secret: []byte("other-secret"), token: token, wantErr: true},
+		{name: "expired", secret: secret, token: signAccess(t, secret, ImageAccess{TenantID: access.TenantID, ImageID: access.ImageID, ExpiresAt: time.Now().Add(-time.Second)}), wantErr: true},
+		{name: "tampered image id", secret: secret, token: tamper(payload, 20) + "." + mac, wantErr: true},
+		{name: "tampered expiry", secret: secret, token: tamper(payload, 39) + "." + mac, wantErr: true},
+		{name: "tampered mac", secret: secret, token: payload + "." + tamper(mac, 0), wantErr: true},
+		{name: "truncated mac", secret: secret, token: payload + "." + mac[:len(mac)-4], wantErr: true},
+		{name: "payload from another token", secret: secret, token: strings.Split(signAccess(t, secret, ImageAccess{TenantID: access.TenantID, ImageID: "0b6d1a7e-3f2c-4d8b-9e1a-5c7f9b2d4e6a", ExpiresAt: access.ExpiresAt}), ".")[0] + "." + mac, wantErr: true},
+		{name: "missing separator", secret: secret, token: payload + mac, wantErr: true},
+		{name: "short payload", secret: secret, token: payload[:20] + "." + mac, wantErr: true},
+		{name: "not base64", secret: secret, token: "!!!." + mac, wantErr: true},
+		{name: "empty", secret: secret, token: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyImageAccess(tt.secret, tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAccessToken) {
+					t.Fatalf("VerifyImageAccess error = %v, want ErrInvalidAccessToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyImageAccess: %v", err)
+			}
+			if *got != access {
+				t.Errorf("VerifyImageAccess = %+v, want %+v", *got, access)
+			}
+		})
+	}
+}
+
+func TestSignImageAccessRejectsInvalidIDs(t *testing.T) {
+	tests := []struct {
+		name   string
+		access ImageAccess
+	}{
+		{name: "invalid tenant id", access: ImageAccess{TenantID: "tenant", ImageID: "7b1d3f5a-9c2e-4e6f-8a0b-1c3d5e7f9a2b"}},
+		{name: "invalid image id", access: ImageAccess{TenantID: "2f0c8f4e-2d4b-4c1e-9a57-8a1f3c5e7d90", ImageID: "image"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := SignImageAccess([]byte("secret"), tt.access); err == nil {
+				t.Errorf("SignImageAccess succeeded, want an error")
+			}
+		})
+	}
+}
+
+func signAccess(t *testing.T, secret []byte, access ImageAccess) string {
+	t.Helper()
+
+	token, err := SignImageAccess(secret, access)
+	if err != nil {
+		t.Fatalf("SignImageAccess: %v", err)
+	}
+	return token
+}
+
+// tamper flips the lowest bit of the decoded byte at index of a base64url value.
+func tamper(encoded string, index int) string {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		panic(err)
+	}
+	data[index] ^= 1
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -10,12 +10,11 @@ var dbConnect *gorm.DB
 
 // InitDBConnection initializes the database connection.
 //
-// It retrieves the necessary environment variables for the database connection: DB_USER, DB_PASS, DB_NAME, DB_HOST, and DB_PORT.
-// Then it creates a DSN (Data Source Name) string using the retrieved environment variables.
+// It creates a DSN (Data Source Name) string from the connection settings, using timeZone as the session time zone.
 // Next, it opens a connection to the database using the gorm package and the created DSN string.
 // If there is an error during the connection process, it panics with the message "failed to connect to database".
-func InitDBConnection(dbHost string, dbUser string, dbPass string, dbName string, dbPort string) *gorm.DB {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Europe/Minsk", dbHost, dbUser, dbPass, dbName, dbPort)
+func InitDBConnection(dbHost string, dbUser string, dbPass string, dbName string, dbPort int, timeZone string) *gorm.DB {
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable TimeZone=%s", dbHost, dbUser, dbPass, dbName, dbPort, timeZone)
 
 	var err error
 	dbConnect, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
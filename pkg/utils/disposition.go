@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"mime"
+	"path"
+)
+
+const (
+	DispositionInline     = "inline"
+	DispositionAttachment = "attachment"
+)
+
+// Names given to downloaded objects by Disposition.
+const (
+	// DownloadFilenameKey names the download after the whole object key.
+	DownloadFilenameKey = "key"
+	// DownloadFilenameName names the download after the last segment of the key, e.g. 1a2b3c_150x150.webp.
+	DownloadFilenameName = "name"
+	// DownloadFilenameNone leaves the name to the client.
+	DownloadFilenameNone = "none"
+)
+
+// Disposition builds the Content-Disposition header of downloaded objects.
+type Disposition struct {
+	// Type is DispositionInline or DispositionAttachment.
+	Type string
+	// Filename is one of the DownloadFilename values.
+	Filename string
+}
+
+// Header returns the Content-Disposition of the object with the given key and content type. SVG
+// documents, and objects whose type is unknown, are always attachments: displayed inline, an SVG
+// could run scripts on the origin serving it.
+func (d Disposition) Header(key, contentType string) string {
+	dispositionType := d.Type
+	if contentType == "" || contentType == SVGMimeType {
+		dispositionType = DispositionAttachment
+	}
+
+	params := make(map[string]string, 1)
+	switch d.Filename {
+	case DownloadFilenameKey:
+		params["filename"] = key
+	case DownloadFilenameName:
+		params["filename"] = path.Base(key)
+	}
+
+	return mime.FormatMediaType(dispositionType, params)
+}
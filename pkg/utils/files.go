@@ -3,17 +3,16 @@ package utils
 import (
 	"bytes"
 	"io"
-	"net/http"
 	"os"
 )
 
 func SaveTempFile(file io.Reader) (string, string, error) {
 	buf := make([]byte, 512)
-	n, err := file.Read(buf)
-	if err != nil && err != io.EOF {
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
 		return "", "", err
 	}
-	contentType := http.DetectContentType(buf[:n])
+	contentType := DetectContentType(buf[:n])
 
 	reader := io.MultiReader(bytes.NewReader(buf[:n]), file)
 
@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/chai2010/webp"
+	"github.com/gen2brain/heic"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// ImageFormat describes an input format accepted by the service. Formats are
+// always detected from the leading magic bytes of the payload, never from the
+// Content-Type supplied by the client.
+type ImageFormat struct {
+	Name         string
+	MimeType     string
+	Match        func(header []byte) bool
+	Decode       func(r io.Reader) (image.Image, error)
+	DecodeConfig func(r io.Reader) (image.Config, error)
+}
+
+// SupportedFormats is the single registry of input formats shared by the REST
+// and gRPC transports and by the processing pipeline.
+var SupportedFormats = []*ImageFormat{
+	{
+		Name:         "jpeg",
+		MimeType:     "image/jpeg",
+		Match:        func(h []byte) bool { return bytes.HasPrefix(h, []byte{0xFF, 0xD8, 0xFF}) },
+		Decode:       jpeg.Decode,
+		DecodeConfig: jpeg.DecodeConfig,
+	},
+	{
+		Name:         "png",
+		MimeType:     "image/png",
+		Match:        func(h []byte) bool { return bytes.HasPrefix(h, []byte("\x89PNG\r\n\x1a\n")) },
+		Decode:       png.Decode,
+		DecodeConfig: png.DecodeConfig,
+	},
+	{
+		Name:     "gif",
+		MimeType: "image/gif",
+		Match: func(h []byte) bool {
+			return bytes.HasPrefix(h, []byte("GIF87a")) || bytes.HasPrefix(h, []byte("GIF89a"))
+		},
+		Decode:       gif.Decode,
+		DecodeConfig: gif.DecodeConfig,
+	},
+	{
+		Name:         "webp",
+		MimeType:     "image/webp",
+		Match:        func(h []byte) bool { return len(h) >= 15 && string(h[0:4]) == "RIFF" && string(h[8:15]) == "WEBPVP8" },
+		Decode:       webp.Decode,
+		DecodeConfig: webp.DecodeConfig,
+	},
+	{
+		Name:         "heic",
+		MimeType:     "image/heic",
+		Match:        func(h []byte) bool { return matchFtypBrand(h, "heic", "heix", "hevc", "hevx", "heim", "heis") },
+		Decode:       heic.Decode,
+		DecodeConfig: heic.DecodeConfig,
+	},
+	{
+		Name:         "heif",
+		MimeType:     "image/heif",
+		Match:        func(h []byte) bool { return matchFtypBrand(h, "mif1", "msf1") },
+		Decode:       heic.Decode,
+		DecodeConfig: heic.DecodeConfig,
+	},
+	{
+		Name:     "tiff",
+		MimeType: "image/tiff",
+		Match: func(h []byte) bool {
+			return bytes.HasPrefix(h, []byte("II*\x00")) || bytes.HasPrefix(h, []byte("MM\x00*"))
+		},
+		Decode:       tiff.Decode,
+		DecodeConfig: tiff.DecodeConfig,
+	},
+	{
+		Name:         "bmp",
+		MimeType:     "image/bmp",
+		Match:        func(h []byte) bool { return len(h) >= 14 && bytes.HasPrefix(h, []byte("BM")) },
+		Decode:       bmp.Decode,
+		DecodeConfig: bmp.DecodeConfig,
+	},
+	{
+		Name:         "svg",
+		MimeType:     SVGMimeType,
+		Match:        isSVG,
+		Decode:       DecodeSVG,
+		DecodeConfig: DecodeSVGConfig,
+	},
+}
+
+// DetectFormat returns the registered format matching the given file header.
+func DetectFormat(header []byte) (*ImageFormat, error) {
+	for _, format := range SupportedFormats {
+		if format.Match(header) {
+			return format, nil
+		}
+	}
+
+	return nil, ErrUnsupportedFormat
+}
+
+// DetectContentType returns the MIME type of a supported format, falling back
+// to http.DetectContentType for anything the registry does not know about.
+func DetectContentType(header []byte) string {
+	if format, err := DetectFormat(header); err == nil {
+		return format.MimeType
+	}
+
+	return http.DetectContentType(header)
+}
+
+func IsSupportedContentType(contentType string) bool {
+	for _, format := range SupportedFormats {
+		if format.MimeType == contentType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DecodeImage detects the format of data and decodes it with the matching decoder.
+func DecodeImage(data []byte) (image.Image, *ImageFormat, error) {
+	format, err := DetectFormat(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	img, err := format.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, format, fmt.Errorf("failed to decode %s image: %w", format.Name, err)
+	}
+
+	return img, format, nil
+}
+
+// DecodeImageConfig returns the dimensions of data without decoding the pixels.
+func DecodeImageConfig(data []byte) (image.Config, *ImageFormat, error) {
+	format, err := DetectFormat(data)
+	if err != nil {
+		return image.Config{}, nil, err
+	}
+
+	config, err := format.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return image.Config{}, format, fmt.Errorf("failed to read %s image header: %w", format.Name, err)
+	}
+
+	return config, format, nil
+}
+
+func matchFtypBrand(h []byte, brands ...string) bool {
+	if len(h) < 12 || string(h[4:8]) != "ftyp" {
+		return false
+	}
+
+	for _, brand := range brands {
+		if string(h[8:12]) == brand {
+			return true
+		}
+	}
+
+	return false
+}
@@ -3,12 +3,10 @@ package utils
 import (
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
-	"os"
+	"strings"
 	"time"
 )
 
-var jwtSecret = []byte(os.Getenv("APP_SECRET_KEY"))
-
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
 	return string(bytes), err
@@ -19,35 +17,39 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
-func GenerateToken(userID string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
-
-	claims := &jwt.MapClaims{
-		"user_id": userID,
-		"exp":     expirationTime.Unix(),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+// TokenClaims are the claims of the bearer tokens accepted by the service.
+type TokenClaims struct {
+	// TenantID is the tenant the token acts for. Tokens without it act for the default tenant.
+	TenantID string `json:"tenant_id,omitempty"`
+	// Scope is the space-separated list of granted scopes, as in OAuth 2.0.
+	Scope string `json:"scope,omitempty"`
+	jwt.RegisteredClaims
 }
 
-func ValidateToken(tokenStr string) (string, error) {
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return jwtSecret, nil
-	})
+func (c *TokenClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
 
-	if err != nil {
-		return "", err
+// GenerateToken issues an HS256 token for subject, granting scopes within tenantID, that expires after ttl.
+// issuer and audience, when set, become the iss and aud claims a TokenVerifier configured with them requires.
+func GenerateToken(secret []byte, issuer, audience, subject, tenantID string, scopes []string, ttl time.Duration) (string, error) {
+	now := time.Now()
+
+	claims := &TokenClaims{
+		TenantID: tenantID,
+		Scope:    strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		if userID, ok := claims["user_id"].(string); ok {
-			return userID, nil
-		}
+	if audience != "" {
+		claims.Audience = jwt.ClaimStrings{audience}
 	}
 
-	return "", jwt.ErrTokenNotValidYet
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(secret)
 }
@@ -0,0 +1,397 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/disintegration/imaging"
+	"image"
+	"io"
+	"math"
+	"sort"
+)
+
+var ErrUnsupportedICCProfile = errors.New("unsupported icc profile")
+
+// xyzD50ToLinearSRGB converts PCS (D50) XYZ values to linear sRGB using the Bradford-adapted sRGB primaries.
+var xyzD50ToLinearSRGB = [3][3]float64{
+	{3.1338561, -1.6168667, -0.4906146},
+	{-0.9787684, 1.9161415, 0.0334540},
+	{0.0719453, -0.2289914, 1.4052427},
+}
+
+// ICCProfile is a parsed matrix/TRC RGB profile, the kind embedded by cameras and editors
+// for Adobe RGB, Display P3, ProPhoto and sRGB. LUT-based profiles are not supported.
+type ICCProfile struct {
+	Data   []byte
+	toSRGB [3][3]float64
+	curves [3]func(float64) float64
+	isSRGB bool
+}
+
+// ExtractICCProfile returns the raw ICC profile embedded in a JPEG, PNG or WebP file, or nil if there is none.
+func ExtractICCProfile(data []byte, format *ImageFormat) []byte {
+	if format == nil {
+		return nil
+	}
+
+	switch format.Name {
+	case "jpeg":
+		return extractJPEGICCProfile(data)
+	case "png":
+		return extractPNGICCProfile(data)
+	case "webp":
+		return extractWebPICCProfile(data)
+	default:
+		return nil
+	}
+}
+
+// ParseICCProfile parses the header and the colorant and tone curve tags of an RGB profile.
+func ParseICCProfile(data []byte) (*ICCProfile, error) {
+	if len(data) < 132 {
+		return nil, fmt.Errorf("%w: profile too short", ErrUnsupportedICCProfile)
+	}
+	if space := iccColourSpace(data); space != "RGB " {
+		return nil, fmt.Errorf("%w: colour space %q", ErrUnsupportedICCProfile, space)
+	}
+	if string(data[20:24]) != "XYZ " {
+		return nil, fmt.Errorf("%w: connection space %q", ErrUnsupportedICCProfile, string(data[20:24]))
+	}
+
+	tags := make(map[string][]byte)
+	count := int(binary.BigEndian.Uint32(data[128:132]))
+	for i := 0; i < count; i++ {
+		entry := 132 + i*12
+		if entry+12 > len(data) {
+			break
+		}
+		offset := int(binary.BigEndian.Uint32(data[entry+4 : entry+8]))
+		size := int(binary.BigEndian.Uint32(data[entry+8 : entry+12]))
+		if offset < 0 || size < 0 || offset+size > len(data) {
+			continue
+		}
+		tags[string(data[entry:entry+4])] = data[offset : offset+size]
+	}
+
+	var colorants [3][3]float64
+	for i, sig := range []string{"rXYZ", "gXYZ", "bXYZ"} {
+		xyz, err := parseXYZTag(tags[sig])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrUnsupportedICCProfile, sig, err)
+		}
+		for row := 0; row < 3; row++ {
+			colorants[row][i] = xyz[row]
+		}
+	}
+
+	profile := &ICCProfile{Data: data}
+	for i, sig := range []string{"rTRC", "gTRC", "bTRC"} {
+		curve, err := parseCurveTag(tags[sig])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrUnsupportedICCProfile, sig, err)
+		}
+		profile.curves[i] = curve
+	}
+
+	profile.toSRGB = multiply3x3(xyzD50ToLinearSRGB, colorants)
+	profile.isSRGB = profile.matchesSRGB()
+
+	return profile, nil
+}
+
+// iccColourSpace returns the data colour space signature from the profile header, or "" if the header is truncated.
+func iccColourSpace(data []byte) string {
+	if len(data) < 20 {
+		return ""
+	}
+
+	return string(data[16:20])
+}
+
+// IsSRGB reports whether the profile is close enough to sRGB that converting would not change any 8-bit value.
+func (p *ICCProfile) IsSRGB() bool {
+	return p.isSRGB
+}
+
+// ConvertToSRGB converts img from the colour space described by the profile to sRGB.
+func (p *ICCProfile) ConvertToSRGB(img image.Image) *image.NRGBA {
+	var linear [3][256]float64
+	for c := 0; c < 3; c++ {
+		for v := 0; v < 256; v++ {
+			linear[c][v] = p.curves[c](float64(v) / 255)
+		}
+	}
+
+	const encodeSteps = 4096
+	var encode [encodeSteps + 1]uint8
+	for i := range encode {
+		encode[i] = uint8(math.Round(srgbEncode(float64(i)/encodeSteps) * 255))
+	}
+
+	out := imaging.Clone(img)
+	m := p.toSRGB
+	for i := 0; i+3 < len(out.Pix); i += 4 {
+		r := linear[0][out.Pix[i]]
+		g := linear[1][out.Pix[i+1]]
+		b := linear[2][out.Pix[i+2]]
+
+		out.Pix[i] = encode[int(clamp01(m[0][0]*r+m[0][1]*g+m[0][2]*b)*encodeSteps)]
+		out.Pix[i+1] = encode[int(clamp01(m[1][0]*r+m[1][1]*g+m[1][2]*b)*encodeSteps)]
+		out.Pix[i+2] = encode[int(clamp01(m[2][0]*r+m[2][1]*g+m[2][2]*b)*encodeSteps)]
+	}
+
+	return out
+}
+
+func (p *ICCProfile) matchesSRGB() bool {
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			expected := 0.0
+			if row == col {
+				expected = 1
+			}
+			if math.Abs(p.toSRGB[row][col]-expected) > 0.01 {
+				return false
+			}
+		}
+	}
+
+	for c := 0; c < 3; c++ {
+		for _, v := range []float64{0.04, 0.2, 0.5, 0.8} {
+			if math.Abs(p.curves[c](v)-srgbDecode(v)) > 0.004 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func parseXYZTag(tag []byte) ([3]float64, error) {
+	if len(tag) < 20 || string(tag[0:4]) != "XYZ " {
+		return [3]float64{}, errors.New("missing XYZ tag")
+	}
+
+	return [3]float64{
+		s15Fixed16(tag[8:12]),
+		s15Fixed16(tag[12:16]),
+		s15Fixed16(tag[16:20]),
+	}, nil
+}
+
+func parseCurveTag(tag []byte) (func(float64) float64, error) {
+	if len(tag) < 12 {
+		return nil, errors.New("missing curve tag")
+	}
+
+	switch string(tag[0:4]) {
+	case "curv":
+		count := int(binary.BigEndian.Uint32(tag[8:12]))
+		if count == 0 {
+			return func(v float64) float64 { return v }, nil
+		}
+		if len(tag) < 12+count*2 {
+			return nil, errors.New("truncated curv tag")
+		}
+		if count == 1 {
+			gamma := float64(binary.BigEndian.Uint16(tag[12:14])) / 256
+			return func(v float64) float64 { return math.Pow(v, gamma) }, nil
+		}
+		table := make([]float64, count)
+		for i := range table {
+			table[i] = float64(binary.BigEndian.Uint16(tag[12+i*2:14+i*2])) / 65535
+		}
+		return func(v float64) float64 {
+			pos := clamp01(v) * float64(count-1)
+			lo := int(pos)
+			if lo >= count-1 {
+				return table[count-1]
+			}
+			frac := pos - float64(lo)
+			return table[lo]*(1-frac) + table[lo+1]*frac
+		}, nil
+	case "para":
+		functionType := binary.BigEndian.Uint16(tag[8:10])
+		paramCount := map[uint16]int{0: 1, 1: 3, 2: 4, 3: 5, 4: 7}[functionType]
+		if paramCount == 0 || len(tag) < 12+paramCount*4 {
+			return nil, fmt.Errorf("unsupported parametric curve type %d", functionType)
+		}
+		var p [7]float64
+		for i := 0; i < paramCount; i++ {
+			p[i] = s15Fixed16(tag[12+i*4 : 16+i*4])
+		}
+		g, a, b, c, d, e, f := p[0], p[1], p[2], p[3], p[4], p[5], p[6]
+		return func(v float64) float64 {
+			switch functionType {
+			case 0:
+				return math.Pow(v, g)
+			case 1:
+				if v >= -b/a {
+					return math.Pow(a*v+b, g)
+				}
+				return 0
+			case 2:
+				if v >= -b/a {
+					return math.Pow(a*v+b, g) + c
+				}
+				return c
+			case 3:
+				if v >= d {
+					return math.Pow(a*v+b, g)
+				}
+				return c * v
+			default:
+				if v >= d {
+					return math.Pow(a*v+b, g) + e
+				}
+				return c*v + f
+			}
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported curve type %q", string(tag[0:4]))
+	}
+}
+
+func extractJPEGICCProfile(data []byte) []byte {
+	chunks := make(map[int][]byte)
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil
+		}
+		marker := data[pos+1]
+		if marker == 0xD8 || marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			pos += 2
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			break
+		}
+
+		segment := data[pos+4 : end]
+		if marker == 0xE2 && len(segment) > 14 && string(segment[:12]) == "ICC_PROFILE\x00" {
+			chunks[int(segment[12])] = segment[14:]
+		}
+
+		pos = end
+	}
+
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	sequence := make([]int, 0, len(chunks))
+	for seq := range chunks {
+		sequence = append(sequence, seq)
+	}
+	sort.Ints(sequence)
+
+	var profile []byte
+	for _, seq := range sequence {
+		profile = append(profile, chunks[seq]...)
+	}
+
+	return profile
+}
+
+func extractPNGICCProfile(data []byte) []byte {
+	pos := 8
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		chunkType := string(data[pos+4 : pos+8])
+		end := pos + 8 + length
+		if end > len(data) || chunkType == "IDAT" {
+			return nil
+		}
+
+		if chunkType == "iCCP" {
+			chunk := data[pos+8 : end]
+			nameEnd := bytes.IndexByte(chunk, 0)
+			if nameEnd < 0 || nameEnd+2 > len(chunk) {
+				return nil
+			}
+			reader, err := zlib.NewReader(bytes.NewReader(chunk[nameEnd+2:]))
+			if err != nil {
+				return nil
+			}
+			defer reader.Close()
+			profile, err := io.ReadAll(io.LimitReader(reader, 4<<20))
+			if err != nil {
+				return nil
+			}
+			return profile
+		}
+
+		pos = end + 4
+	}
+
+	return nil
+}
+
+func extractWebPICCProfile(data []byte) []byte {
+	pos := 12
+	for pos+8 <= len(data) {
+		chunkType := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		if pos+8+size > len(data) {
+			return nil
+		}
+
+		if chunkType == "ICCP" {
+			return data[pos+8 : pos+8+size]
+		}
+
+		pos += 8 + size + size%2
+	}
+
+	return nil
+}
+
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536
+}
+
+func multiply3x3(a, b [3][3]float64) [3][3]float64 {
+	var out [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				out[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return out
+}
+
+func srgbDecode(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func srgbEncode(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+func clamp01(v float64) float64 {
+	if v < 0 || math.IsNaN(v) {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
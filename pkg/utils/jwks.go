@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwksRetryInterval limits how often an unknown key ID makes the set be read again, so tokens
+// with made-up key IDs cannot hammer the key server.
+const jwksRetryInterval = 30 * time.Second
+
+// JWKS holds the public keys of a JSON Web Key Set, read from a file or fetched from an http(s)
+// URL. Keys are cached for refresh and read again early when a token names an unknown key, which
+// is how rotated keys are picked up.
+type JWKS struct {
+	source  string
+	refresh time.Duration
+	client  *http.Client
+
+	mu       sync.Mutex
+	keys     map[string]crypto.PublicKey
+	loadedAt time.Time
+}
+
+func NewJWKS(source string, refresh time.Duration) *JWKS {
+	return &JWKS{
+		source:  source,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Key returns the key with the given ID. A token without a key ID is accepted when the set holds a single key.
+func (s *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, found := s.lookup(kid)
+	age := time.Since(s.loadedAt)
+	if s.keys == nil || age > s.refresh || (!found && age > jwksRetryInterval) {
+		if err := s.load(ctx); err != nil {
+			// Keys that were already loaded stay usable while the source is unavailable.
+			if !found {
+				return nil, err
+			}
+			GetLogger().Warn("failed to refresh the JWKS, keeping the cached keys", zap.Error(err))
+		} else {
+			key, found = s.lookup(kid)
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	return key, nil
+}
+
+// Load reads the key set now, so a broken source is reported at startup rather than on the first request.
+func (s *JWKS) Load(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.load(ctx)
+}
+
+func (s *JWKS) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (s *JWKS) load(ctx context.Context) error {
+	// Failed attempts count as loads too, which spaces out the retries.
+	s.loadedAt = time.Now()
+
+	data, err := s.read(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the JWKS from %s: %w", s.source, err)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("failed to parse the JWKS from %s: %w", s.source, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			return fmt.Errorf("invalid key %q in the JWKS from %s: %w", jwk.Kid, s.source, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("the JWKS from %s holds no signing keys", s.source)
+	}
+
+	s.keys = keys
+	return nil
+}
+
+func (s *JWKS) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(s.source, "http://") && !strings.HasPrefix(s.source, "https://") {
+		return os.ReadFile(s.source)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", response.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(response.Body, 1<<20))
+}
+
+// jsonWebKey is the subset of RFC 7517 needed for RS256 and ES256 public keys.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeKeyParameter(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("n: %w", err)
+		}
+		e, err := decodeKeyParameter(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("e: %w", err)
+		}
+		if len(e) > 4 {
+			return nil, fmt.Errorf("e is too large")
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q, only P-256 is used by ES256", jwk.Crv)
+		}
+		x, err := decodeKeyParameter(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeKeyParameter(jwk.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("x and y must be 32 bytes long")
+		}
+
+		// ecdh rejects points that are not on the curve.
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
+
+func decodeKeyParameter(value string) ([]byte, error) {
+	if value == "" {
+		return nil, fmt.Errorf("is missing")
+	}
+
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
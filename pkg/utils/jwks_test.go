@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"go.uber.org/zap"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJWKSKey(t *testing.T) {
+	rsaKey := generateRSAKey(t)
+	ecKey := generateECKey(t)
+
+	rsaJWK := jwkOf("rsa", &rsaKey.PublicKey)
+	ecJWK := jwkOf("ec", &ecKey.PublicKey)
+	encJWK := jwkOf("enc", &rsaKey.PublicKey)
+	encJWK["use"] = "enc"
+	p384JWK := jwkOf("p384", &ecKey.PublicKey)
+	p384JWK["crv"] = "P-384"
+	offCurveJWK := jwkOf("off-curve", &ecKey.PublicKey)
+	offCurveJWK["y"] = base64.RawURLEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	missingExponentJWK := jwkOf("no-e", &rsaKey.PublicKey)
+	delete(missingExponentJWK, "e")
+
+	tests := []struct {
+		name    string
+		set     []map[string]string
+		kid     string
+		want    any
+		wantErr bool
+	}{
+		{name: "rsa key by kid", set: []map[string]string{rsaJWK, ecJWK}, kid: "rsa", want: &rsaKey.PublicKey},
+		{name: "ec key by kid", set: []map[string]string{rsaJWK, ecJWK}, kid: "ec", want: &ecKey.PublicKey},
+		{name: "unknown kid", set: []map[string]string{rsaJWK, ecJWK}, kid: "other", wantErr: true},
+		{name: "no kid with a single key", set: []map[string]string{ecJWK}, kid: "", want: &ecKey.PublicKey},
+		{name: "no kid with several keys", set: []map[string]string{rsaJWK, ecJWK}, kid: "", wantErr: true},
+		{name: "encryption keys are skipped", set: []map[string]string{encJWK, ecJWK}, kid: "", want: &ecKey.PublicKey},
+		{name: "only encryption keys", set: []map[string]string{encJWK}, kid: "enc", wantErr: true},
+		{name: "unsupported curve", set: []map[string]string{p384JWK}, kid: "p384", wantErr: true},
+		{name: "point not on the curve", set: []map[string]string{offCurveJWK}, kid: "off-curve", wantErr: true},
+		{name: "missing rsa exponent", set: []map[string]string{missingExponentJWK}, kid: "no-e", wantErr: true},
+		{name: "unsupported key type", set: []map[string]string{{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"}}, kid: "hmac", wantErr: true},
+		{name: "empty set", set: []map[string]string{}, kid: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := NewJWKS(writeJWKSFile(t, tt.set), time.Hour)
+
+			key, err := keys.Key(context.Background(), tt.kid)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Key(%q) succeeded, want an error", tt.kid)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Key(%q): %v", tt.kid, err)
+			}
+			if !samePublicKey(key, tt.want) {
+				t.Errorf("Key(%q) returned a different key", tt.kid)
+			}
+		})
+	}
+}
+
+func TestJWKSPicksUpRotatedKeys(t *testing.T) {
+	oldKey, newKey := generateECKey(t), generateECKey(t)
+
+	path := writeJWKSFile(t, []map[string]string{jwkOf("old", &oldKey.PublicKey)})
+	keys := NewJWKS(path, time.Hour)
+	if _, err := keys.Key(context.Background(), "old"); err != nil {
+		t.Fatalf("Key(old): %v", err)
+	}
+
+	writeJSON(t, path, []map[string]string{jwkOf("new", &newKey.PublicKey)})
+
+	// Unknown key IDs only make the set be read again once per retry interval.
+	if _, err := keys.Key(context.Background(), "new"); err == nil {
+		t.Fatalf("Key(new) succeeded before the retry interval passed")
+	}
+
+	keys.loadedAt = time.Now().Add(-jwksRetryInterval - time.Second)
+	key, err := keys.Key(context.Background(), "new")
+	if err != nil {
+		t.Fatalf("Key(new) after the retry interval: %v", err)
+	}
+	if !samePublicKey(key, &newKey.PublicKey) {
+		t.Errorf("Key(new) returned a different key")
+	}
+}
+
+func TestJWKSKeepsCachedKeysWhenTheSourceFails(t *testing.T) {
+	previous := logger
+	logger = zap.NewNop()
+	t.Cleanup(func() { logger = previous })
+
+	key := generateECKey(t)
+	path := writeJWKSFile(t, []map[string]string{jwkOf("cached", &key.PublicKey)})
+	keys := NewJWKS(path, time.Minute)
+	if _, err := keys.Key(context.Background(), "cached"); err != nil {
+		t.Fatalf("Key(cached): %v", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	keys.loadedAt = time.Now().Add(-time.Hour)
+
+	if _, err := keys.Key(context.Background(), "cached"); err != nil {
+		t.Errorf("Key(cached) with the source gone: %v", err)
+	}
+	if _, err := keys.Key(context.Background(), "missing"); err == nil {
+		t.Errorf("Key(missing) succeeded with the source gone")
+	}
+}
+
+// writeJWKS writes a key set with the given public keys and returns it loaded from that file.
+func writeJWKS(t *testing.T, keys map[string]any) *JWKS {
+	t.Helper()
+
+	set := make([]map[string]string, 0, len(keys))
+	for kid, key := range keys {
+		set = append(set, jwkOf(kid, key))
+	}
+
+	return NewJWKS(writeJWKSFile(t, set), time.Hour)
+}
+
+func writeJWKSFile(t *testing.T, set []map[string]string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJSON(t, path, set)
+	return path
+}
+
+func writeJSON(t *testing.T, path string, set []map[string]string) {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]any{"keys": set})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func jwkOf(kid string, key any) map[string]string {
+	encode := func(value *big.Int, size int) string {
+		return base64.RawURLEncoding.EncodeToString(value.FillBytes(make([]byte, size)))
+	}
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		return map[string]string{
+			"kty": "EC",
+			"kid": kid,
+			"crv": "P-256",
+			"x":   encode(key.X, 32),
+			"y":   encode(key.Y, 32),
+		}
+	default:
+		panic("unsupported key type")
+	}
+}
+
+func samePublicKey(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
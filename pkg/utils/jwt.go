@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"time"
+)
+
+// tokenLeeway tolerates clock skew between the service and the token issuer.
+const tokenLeeway = 30 * time.Second
+
+// TokenVerifier verifies bearer tokens: HS256 tokens with the shared secret, RS256 and ES256 tokens
+// with the keys of a JWKS. Either may be missing, in which case those tokens are rejected.
+type TokenVerifier struct {
+	secret []byte
+	keys   *JWKS
+	parser *jwt.Parser
+}
+
+// NewTokenVerifier creates a verifier. Non-empty issuer and audience must match the iss and aud claims.
+func NewTokenVerifier(secret []byte, keys *JWKS, issuer, audience string) *TokenVerifier {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(tokenLeeway),
+	}
+	if issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+
+	return &TokenVerifier{secret: secret, keys: keys, parser: jwt.NewParser(options...)}
+}
+
+// Enabled reports whether any token can be verified at all.
+func (v *TokenVerifier) Enabled() bool {
+	return len(v.secret) > 0 || v.keys != nil
+}
+
+// Verify checks the signature and the time, issuer and audience claims of tokenStr.
+func (v *TokenVerifier) Verify(ctx context.Context, tokenStr string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+
+	_, err := v.parser.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			if len(v.secret) == 0 {
+				return nil, errors.New("hs256 tokens are not accepted without APP_SECRET_KEY")
+			}
+			return v.secret, nil
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+			if v.keys == nil {
+				return nil, fmt.Errorf("%s tokens are not accepted without AUTH_JWKS", token.Method.Alg())
+			}
+			kid, _ := token.Header["kid"].(string)
+			return v.keys.Key(ctx, kid)
+		default:
+			return nil, jwt.ErrTokenSignatureInvalid
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/golang-jwt/jwt/v5"
+	"testing"
+	"time"
+)
+
+func TestTokenVerifierVerify(t *testing.T) {
+	secret := []byte("test-secret")
+	rsaKey := generateRSAKey(t)
+	ecKey := generateECKey(t)
+	otherRSAKey := generateRSAKey(t)
+
+	keys := writeJWKS(t, map[string]any{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey})
+	singleKey := writeJWKS(t, map[string]any{"only": &rsaKey.PublicKey})
+
+	verifier := NewTokenVerifier(secret, keys, "", "")
+	secretOnly := NewTokenVerifier(secret, nil, "", "")
+	keysOnly := NewTokenVerifier(nil, keys, "", "")
+	singleKeyVerifier := NewTokenVerifier(nil, singleKey, "", "")
+	scoped := NewTokenVerifier(secret, nil, "https://issuer.example.com", "image-service")
+
+	now := time.Now()
+	valid := func(c *TokenClaims) {}
+	expiresIn := func(d time.Duration) func(*TokenClaims) {
+		return func(c *TokenClaims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(d)) }
+	}
+
+	tests := []struct {
+		name     string
+		verifier *TokenVerifier
+		token    string
+		wantErr  bool
+	}{
+		{name: "hs256", verifier: verifier, token: signToken(t, jwt.SigningMethodHS256, secret, "", valid)},
+		{name: "hs256 with the wrong secret", verifier: verifier, token: signToken(t, jwt.SigningMethodHS256, []byte("other"), "", valid), wantErr: true},
+		{name: "hs256 without a secret", verifier: keysOnly, token: signToken(t, jwt.SigningMethodHS256, secret, "", valid), wantErr: true},
+		{name: "rs256", verifier: verifier, token: signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa", valid)},
+		{name: "es256", verifier: verifier, token: signToken(t, jwt.SigningMethodES256, ecKey, "ec", valid)},
+		{name: "rs256 without a JWKS", verifier: secretOnly, token: signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa", valid), wantErr: true},
+		{name: "rs256 signed by another key", verifier: verifier, token: signToken(t, jwt.SigningMethodRS256, otherRSAKey, "rsa", valid), wantErr: true},
+		{name: "rs256 naming an ec key", verifier: verifier, token: signToken(t, jwt.SigningMethodRS256, rsaKey, "ec", valid), wantErr: true},
+		{name: "es256 naming an rsa key", verifier: verifier, token: signToken(t, jwt.SigningMethodES256, ecKey, "rsa", valid), wantErr: true},
+		{name: "unknown kid", verifier: verifier, token: signToken(t, jwt.SigningMethodRS256, rsaKey, "rotated", valid), wantErr: true},
+		{name: "no kid with several keys", verifier: verifier, token: signToken(t, jwt.SigningMethodRS256, rsaKey, "", valid), wantErr: true},
+		{name: "no kid with a single key", verifier: singleKeyVerifier, token: signToken(t, jwt.SigningMethodRS256, rsaKey, "", valid)},
+		{name: "alg none", verifier: verifier, token: signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", valid), wantErr: true},
+		{name: "hs512 is not accepted", verifier: verifier, token: signToken(t, jwt.SigningMethodHS512, secret, "", valid), wantErr: true},
+		{name: "expired within the leeway", verifier: verifier, token: signToken(t, jwt.SigningMethodHS256, secret, "", expiresIn(-tokenLeeway/2))},
+		{name: "expired beyond the leeway", verifier: verifier, token: signToken(t, jwt.SigningMethodHS256, secret, "", expiresIn(-2*tokenLeeway)), wantErr: true},
+		{name: "without expiry", verifier: verifier, token: signToken(t, jwt.SigningMethodHS256, secret, "", func(c *TokenClaims) { c.ExpiresAt = nil }), wantErr: true},
+		{name: "not yet valid within the leeway", verifier: verifier, token: signToken(t, jwt.SigningMethodHS256, secret, "", func(c *TokenClaims) { c.NotBefore = jwt.NewNumericDate(now.Add(tokenLeeway / 2)) })},
+		{name: "not yet valid beyond the leeway", verifier: verifier, token: signToken(t, jwt.SigningMethodHS256, secret, "", func(c *TokenClaims) { c.NotBefore = jwt.NewNumericDate(now.Add(2 * tokenLeeway)) }), wantErr: true},
+		{name: "issuer and audience", verifier: scoped, token: signToken(t, jwt.SigningMethodHS256, secret, "", func(c *TokenClaims) {
+			c.Issuer = "https://issuer.example.com"
+			c.Audience = jwt.ClaimStrings{"image-service"}
+		})},
+		{name: "wrong issuer", verifier: scoped, token: signToken(t, jwt.SigningMethodHS256, secret, "", func(c *TokenClaims) {
+			c.Issuer = "https://other.example.com"
+			c.Audience = jwt.ClaimStrings{"image-service"}
+		}), wantErr: true},
+		{name: "missing audience", verifier: scoped, token: signToken(t, jwt.SigningMethodHS256, secret, "", func(c *TokenClaims) {
+			c.Issuer = "https://issuer.example.com"
+		}), wantErr: true},
+		{name: "malformed", verifier: verifier, token: "not.a.token", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.verifier.Verify(context.Background(), tt.token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Verify succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if claims.Subject != "subject" || claims.TenantID != "tenant" || claims.Scope != "images:read" {
+				t.Errorf("claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestTokenVerifierEnabled(t *testing.T) {
+	keys := NewJWKS("unused.json", time.Hour)
+
+	tests := []struct {
+		name     string
+		verifier *TokenVerifier
+		want     bool
+	}{
+		{name: "nothing configured", verifier: NewTokenVerifier(nil, nil, "", ""), want: false},
+		{name: "secret", verifier: NewTokenVerifier([]byte("secret"), nil, "", ""), want: true},
+		{name: "jwks", verifier: NewTokenVerifier(nil, keys, "", ""), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.verifier.Enabled(); got != tt.want {
+				t.Errorf("Enabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateTokenMatchesTheVerifier(t *testing.T) {
+	secret := []byte("test-secret")
+
+	tests := []struct {
+		name     string
+		issuer   string
+		audience string
+		verifier *TokenVerifier
+		wantErr  bool
+	}{
+		{name: "no issuer or audience", verifier: NewTokenVerifier(secret, nil, "", "")},
+		{name: "issuer and audience", issuer: "irs", audience: "image-service", verifier: NewTokenVerifier(secret, nil, "irs", "image-service")},
+		{name: "issued without the required issuer", audience: "image-service", verifier: NewTokenVerifier(secret, nil, "irs", "image-service"), wantErr: true},
+		{name: "issued without the required audience", issuer: "irs", verifier: NewTokenVerifier(secret, nil, "irs", "image-service"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := GenerateToken(secret, tt.issuer, tt.audience, "subject", "tenant", []string{"images:read"}, time.Hour)
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+
+			claims, err := tt.verifier.Verify(context.Background(), token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Verify succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if claims.Subject != "subject" || claims.TenantID != "tenant" || claims.Scope != "images:read" {
+				t.Errorf("claims = %+v", claims)
+			}
+		})
+	}
+}
+
+// signToken signs claims valid for an hour, changed by modify, with method and key.
+func signToken(t *testing.T, method jwt.SigningMethod, key any, kid string, modify func(*TokenClaims)) string {
+	t.Helper()
+
+	now := time.Now()
+	claims := &TokenClaims{
+		TenantID: "tenant",
+		Scope:    "images:read",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "subject",
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+	modify(claims)
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func generateRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate rsa key: %v", err)
+	}
+	return key
+}
+
+func generateECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ec key: %v", err)
+	}
+	return key
+}
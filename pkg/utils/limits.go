@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+)
+
+var ErrInvalidImage = errors.New("invalid image")
+
+// ImageLimits guards the decoder against decompression bombs. A zero value disables the corresponding check.
+type ImageLimits struct {
+	MaxWidth      int
+	MaxHeight     int
+	MaxMegapixels float64
+	MaxFrames     int
+}
+
+// LimitError is returned when an image header describes an image larger than ImageLimits allow.
+type LimitError struct {
+	Reason string
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("image exceeds limits: %s", e.Reason)
+}
+
+// Check reads only the image header and frame table of data and verifies them against the limits.
+func (l ImageLimits) Check(data []byte) error {
+	config, format, err := DecodeImageConfig(data)
+	if err != nil {
+		if errors.Is(err, ErrUnsupportedFormat) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	if l.MaxWidth > 0 && config.Width > l.MaxWidth {
+		return &LimitError{Reason: fmt.Sprintf("width %d exceeds the limit of %d pixels", config.Width, l.MaxWidth)}
+	}
+
+	if l.MaxHeight > 0 && config.Height > l.MaxHeight {
+		return &LimitError{Reason: fmt.Sprintf("height %d exceeds the limit of %d pixels", config.Height, l.MaxHeight)}
+	}
+
+	megapixels := float64(config.Width) * float64(config.Height) / 1_000_000
+	if l.MaxMegapixels > 0 && megapixels > l.MaxMegapixels {
+		return &LimitError{Reason: fmt.Sprintf("%.1f megapixels exceeds the limit of %.1f", megapixels, l.MaxMegapixels)}
+	}
+
+	if l.MaxFrames > 0 {
+		if frames := CountFrames(data, format); frames > l.MaxFrames {
+			return &LimitError{Reason: fmt.Sprintf("%d frames exceeds the limit of %d", frames, l.MaxFrames)}
+		}
+	}
+
+	return nil
+}
+
+// DecodeImageWithLimits checks data against the limits before running the full decoder.
+func DecodeImageWithLimits(data []byte, limits ImageLimits) (image.Image, *ImageFormat, error) {
+	if err := limits.Check(data); err != nil {
+		return nil, nil, err
+	}
+
+	return DecodeImage(data)
+}
+
+// CountFrames walks the container structure of animated or multi-page formats without decoding pixel data.
+// Formats that cannot hold more than one frame report 1.
+func CountFrames(data []byte, format *ImageFormat) int {
+	if format == nil {
+		return 1
+	}
+
+	switch format.Name {
+	case "gif":
+		return countGIFFrames(data)
+	case "png":
+		return countPNGFrames(data)
+	case "webp":
+		return countWebPFrames(data)
+	case "tiff":
+		return countTIFFPages(data)
+	default:
+		return 1
+	}
+}
+
+func countGIFFrames(data []byte) int {
+	if len(data) < 13 {
+		return 1
+	}
+
+	pos := 13
+	if data[10]&0x80 != 0 {
+		pos += 3 << (int(data[10]&0x07) + 1)
+	}
+
+	frames := 0
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x21:
+			pos = skipGIFSubBlocks(data, pos+2)
+		case 0x2C:
+			frames++
+			if pos+10 > len(data) {
+				return frames
+			}
+			flags := data[pos+9]
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << (int(flags&0x07) + 1)
+			}
+			pos = skipGIFSubBlocks(data, pos+1)
+		default:
+			return max(frames, 1)
+		}
+	}
+
+	return max(frames, 1)
+}
+
+func skipGIFSubBlocks(data []byte, pos int) int {
+	for pos < len(data) {
+		size := int(data[pos])
+		pos++
+		if size == 0 {
+			return pos
+		}
+		pos += size
+	}
+
+	return pos
+}
+
+func countPNGFrames(data []byte) int {
+	pos := 8
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		chunkType := string(data[pos+4 : pos+8])
+
+		if chunkType == "acTL" && pos+12 <= len(data) {
+			return max(int(binary.BigEndian.Uint32(data[pos+8:pos+12])), 1)
+		}
+		if chunkType == "IDAT" {
+			return 1
+		}
+
+		pos += 12 + length
+	}
+
+	return 1
+}
+
+func countWebPFrames(data []byte) int {
+	frames := 0
+	pos := 12
+	for pos+8 <= len(data) {
+		chunkType := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+
+		if chunkType == "ANMF" {
+			frames++
+		}
+
+		pos += 8 + size + size%2
+	}
+
+	return max(frames, 1)
+}
+
+func countTIFFPages(data []byte) int {
+	if len(data) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if bytes.HasPrefix(data, []byte("MM")) {
+		order = binary.BigEndian
+	}
+
+	pages := 0
+	visited := make(map[uint32]bool)
+	offset := order.Uint32(data[4:8])
+	for offset != 0 && !visited[offset] && int(offset)+2 <= len(data) {
+		visited[offset] = true
+		pages++
+
+		entries := int(order.Uint16(data[offset : offset+2]))
+		next := int(offset) + 2 + entries*12
+		if next+4 > len(data) {
+			break
+		}
+		offset = order.Uint32(data[next : next+4])
+	}
+
+	return max(pages, 1)
+}
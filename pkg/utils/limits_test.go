@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+)
+
+func TestCountFrames(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		format string
+		want   int
+	}{
+		{name: "gif single frame", data: encodeGIF(t, 1), format: "gif", want: 1},
+		{name: "gif animation", data: encodeGIF(t, 5), format: "gif", want: 5},
+		{name: "gif truncated in image descriptor", data: truncate(encodeGIF(t, 3), 0.5), format: "gif", want: 1},
+		{name: "gif header only", data: []byte("GIF89a"), format: "gif", want: 1},
+		{name: "png still", data: encodePNG(t), format: "png", want: 1},
+		{name: "apng", data: pngChunks(pngChunk("IHDR", make([]byte, 13)), pngChunk("acTL", u32be(7, 0)), pngChunk("IDAT", nil)), format: "png", want: 7},
+		{name: "apng with acTL after IDAT is still", data: pngChunks(pngChunk("IHDR", make([]byte, 13)), pngChunk("IDAT", nil), pngChunk("acTL", u32be(7, 0))), format: "png", want: 1},
+		{name: "png chunk length past the end", data: pngChunks(pngChunk("IHDR", make([]byte, 13)))[:20], format: "png", want: 1},
+		{name: "webp still", data: riff(webpChunk("VP8 ", make([]byte, 10))), format: "webp", want: 1},
+		{name: "webp animation", data: riff(webpChunk("VP8X", make([]byte, 10)), webpChunk("ANIM", make([]byte, 6)), webpChunk("ANMF", make([]byte, 17)), webpChunk("ANMF", make([]byte, 16)), webpChunk("ANMF", make([]byte, 16))), format: "webp", want: 3},
+		{name: "webp chunk size past the end", data: riff(webpChunk("ANMF", nil))[:16], format: "webp", want: 1},
+		{name: "tiff single page", data: tiffPages(binary.LittleEndian, 1, false), format: "tiff", want: 1},
+		{name: "tiff little endian pages", data: tiffPages(binary.LittleEndian, 4, false), format: "tiff", want: 4},
+		{name: "tiff big endian pages", data: tiffPages(binary.BigEndian, 3, false), format: "tiff", want: 3},
+		{name: "tiff with an IFD loop", data: tiffPages(binary.LittleEndian, 3, true), format: "tiff", want: 3},
+		{name: "tiff offset past the end", data: []byte("II*\x00\xff\x00\x00\x00"), format: "tiff", want: 1},
+		{name: "single-frame format", data: []byte{0xFF, 0xD8, 0xFF}, format: "jpeg", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CountFrames(tt.data, formatNamed(t, tt.format)); got != tt.want {
+				t.Errorf("CountFrames() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountFramesWithoutFormat(t *testing.T) {
+	if got := CountFrames(encodeGIF(t, 4), nil); got != 1 {
+		t.Errorf("CountFrames() = %d, want 1", got)
+	}
+}
+
+func formatNamed(t *testing.T, name string) *ImageFormat {
+	t.Helper()
+	for _, format := range SupportedFormats {
+		if format.Name == name {
+			return format
+		}
+	}
+	t.Fatalf("no format named %s", name)
+	return nil
+}
+
+func encodeGIF(t *testing.T, frames int) []byte {
+	t.Helper()
+	palette := color.Palette{color.Black, color.White}
+	animation := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		animation.Image = append(animation.Image, image.NewPaletted(image.Rect(0, 0, 4, 4), palette))
+		animation.Delay = append(animation.Delay, 10)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, animation); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func truncate(data []byte, fraction float64) []byte {
+	return data[:int(float64(len(data))*fraction)]
+}
+
+func u32be(values ...uint32) []byte {
+	var out []byte
+	for _, value := range values {
+		out = binary.BigEndian.AppendUint32(out, value)
+	}
+	return out
+}
+
+// pngChunk builds a chunk with a zero CRC, which CountFrames does not check.
+func pngChunk(chunkType string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, data...)
+	return append(chunk, 0, 0, 0, 0)
+}
+
+func pngChunks(chunks ...[]byte) []byte {
+	return append([]byte("\x89PNG\r\n\x1a\n"), bytes.Join(chunks, nil)...)
+}
+
+func webpChunk(chunkType string, data []byte) []byte {
+	chunk := append([]byte(chunkType), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...)
+	chunk = append(chunk, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+func riff(chunks ...[]byte) []byte {
+	body := append([]byte("WEBP"), bytes.Join(chunks, nil)...)
+	return append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...), body...)
+}
+
+// tiffPages builds a TIFF with empty IFDs chained one after another. With loop, the last IFD points
+// back at the first.
+func tiffPages(order binary.AppendByteOrder, pages int, loop bool) []byte {
+	data := []byte("II*\x00")
+	if order == binary.BigEndian {
+		data = []byte("MM\x00*")
+	}
+	data = order.AppendUint32(data, 8)
+
+	for i := 0; i < pages; i++ {
+		next := uint32(len(data) + 6)
+		if i == pages-1 {
+			next = 0
+			if loop {
+				next = 8
+			}
+		}
+		data = order.AppendUint16(data, 0)
+		data = order.AppendUint32(data, next)
+	}
+	return data
+}
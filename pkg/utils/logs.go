@@ -8,17 +8,25 @@ import (
 
 var logger *zap.Logger
 
-func InitLogs() *zap.Logger {
+// InitLogs builds the global logger writing to the given output paths, e.g. app.log, stdout or stderr.
+// level is a zap level name and format is json or console; both are validated with the configuration.
+func InitLogs(outputs []string, level, format string) *zap.Logger {
 	config := zap.NewProductionConfig()
-	config.OutputPaths = []string{
-		"app.log",
-		"stdout",
+	config.OutputPaths = outputs
+
+	atomicLevel, err := zap.ParseAtomicLevel(level)
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
 	}
+	config.Level = atomicLevel
 
-	config.Encoding = "console"
+	config.Encoding = format
 	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 
-	logger, _ = config.Build()
+	logger, err = config.Build()
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
 	defer func() {
 		if syncErr := logger.Sync(); syncErr != nil {
 			log.Printf("Failed to sync logger: %v", syncErr)
@@ -1,10 +1,52 @@
 package utils
 
 import (
+	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"image-resizing-service/internal/domain"
 )
 
 func InitMigrations(db *gorm.DB) {
-	db.AutoMigrate(&domain.Image{}, &domain.Thumbnail{})
+	db.AutoMigrate(&domain.Tenant{}, &domain.APIKey{}, &domain.Image{}, &domain.Thumbnail{}, &domain.ReprocessJob{}, &domain.ThumbnailPreset{}, &domain.TenantUsage{})
+
+	seedThumbnailPresets(db)
+	seedDefaultTenant(db)
+}
+
+// seedDefaultTenant creates the default tenant and assigns it the images stored before tenants existed.
+func seedDefaultTenant(db *gorm.DB) {
+	tenant := &domain.Tenant{ID: domain.DefaultTenantID, Name: domain.DefaultTenantName}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(tenant).Error; err != nil {
+		GetLogger().Error("failed to seed default tenant", zap.Error(err))
+		return
+	}
+
+	result := db.Model(&domain.Image{}).Unscoped().Where("tenant_id IS NULL").Update("tenant_id", domain.DefaultTenantID)
+	if result.Error != nil {
+		GetLogger().Error("failed to assign images to the default tenant", zap.Error(result.Error))
+		return
+	}
+	if result.RowsAffected > 0 {
+		GetLogger().Info("assigned images to the default tenant", zap.Int64("images", result.RowsAffected))
+	}
+}
+
+// seedThumbnailPresets inserts the built-in presets that are not in the table yet. Existing rows,
+// including disabled ones, are left untouched.
+func seedThumbnailPresets(db *gorm.DB) {
+	for _, size := range domain.ThumbnailSizes {
+		preset := &domain.ThumbnailPreset{
+			Label:    size.Label,
+			Width:    size.Width,
+			Height:   size.Height,
+			Type:     size.Type,
+			Encoding: size.Encoding,
+			Enabled:  true,
+		}
+
+		if err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "label"}}, DoNothing: true}).Create(preset).Error; err != nil {
+			GetLogger().Error("failed to seed thumbnail preset", zap.String("preset", size.Label), zap.Error(err))
+		}
+	}
 }
@@ -3,9 +3,13 @@ package utils
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"go.opentelemetry.io/otel/attribute"
+	"image-resizing-service/pkg/metrics"
+	"image-resizing-service/pkg/tracing"
 	"io"
 	"log"
 	"net/http"
@@ -14,19 +18,35 @@ import (
 	"time"
 )
 
-const BucketName = "images"
+// ErrObjectNotFound is returned by OpenObject for keys that are not in the bucket.
+var ErrObjectNotFound = errors.New("object not found")
 
 type MinioClient struct {
 	client *minio.Client
-	bucket string
+	// presigner signs download URLs. It is client unless the URLs are handed out for a public URL.
+	presigner *minio.Client
+	bucket    string
 }
 
+// customHostTransport sends the requests of a client addressed to the public URL of the storage to
+// its internal endpoint instead. The Host header keeps the public host the requests were signed for.
 type customHostTransport struct {
-	Transport  http.RoundTripper
-	PublicHost string
+	Transport http.RoundTripper
+	Scheme    string
+	Endpoint  string
 }
 
-func NewMinioClient(endpoint, accessKey, secretKey, bucketName string, useSSL bool) *MinioClient {
+func (t *customHostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Host = req.URL.Host
+	req.URL.Scheme = t.Scheme
+	req.URL.Host = t.Endpoint
+	return t.Transport.RoundTrip(req)
+}
+
+// NewMinioClient connects to the storage at endpoint. When publicURL is set, e.g.
+// https://files.example.com, presigned URLs are issued for it rather than for endpoint.
+func NewMinioClient(endpoint, accessKey, secretKey, bucketName string, useSSL bool, publicURL string) *MinioClient {
 	client, err := minio.New(endpoint, &minio.Options{
 		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
 		Secure: useSSL,
@@ -46,15 +66,69 @@ func NewMinioClient(endpoint, accessKey, secretKey, bucketName string, useSSL bo
 		}
 	}
 
-	return &MinioClient{client: client, bucket: bucketName}
+	presigner := client
+	if publicURL != "" {
+		presigner, err = newPresigner(endpoint, accessKey, secretKey, useSSL, publicURL)
+		if err != nil {
+			log.Fatalf("Failed to initialize MinIO presigner: %v", err)
+		}
+	}
+
+	return &MinioClient{client: client, presigner: presigner, bucket: bucketName}
 }
 
-func (m *MinioClient) UploadFile(ctx context.Context, objectName, filePath, contentType string) error {
-	_, err := m.client.FPutObject(ctx, m.bucket, objectName, filePath, minio.PutObjectOptions{ContentType: contentType})
+// newPresigner returns a client for publicURL. Signing needs the region of the bucket, which the
+// client looks up once; that request reaches the storage through its internal endpoint.
+func newPresigner(endpoint, accessKey, secretKey string, useSSL bool, publicURL string) (*minio.Client, error) {
+	public, err := url.Parse(publicURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public URL: %w", err)
+	}
+
+	transport, err := minio.DefaultTransport(useSSL)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := "http"
+	if useSSL {
+		scheme = "https"
+	}
+
+	return minio.New(public.Host, &minio.Options{
+		Creds:     credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure:    public.Scheme == "https",
+		Transport: &customHostTransport{Transport: transport, Scheme: scheme, Endpoint: endpoint},
+	})
+}
+
+// instrument starts a span for a storage operation on key. The returned function, deferred with a
+// pointer to the operation's named error, ends the span and records the operation's latency.
+func (m *MinioClient) instrument(ctx context.Context, operation, key string) (context.Context, func(*error)) {
+	startedAt := time.Now()
+	ctx, span := tracing.StartChild(ctx, "minio."+operation,
+		attribute.String("storage.bucket", m.bucket),
+		attribute.String("storage.key", key),
+	)
+
+	return ctx, func(err *error) {
+		metrics.ObserveStorage(operation, startedAt, err)
+		tracing.End(span, *err)
+	}
+}
+
+func (m *MinioClient) UploadFile(ctx context.Context, objectName, filePath, contentType string) (err error) {
+	ctx, done := m.instrument(ctx, "put", objectName)
+	defer done(&err)
+
+	_, err = m.client.FPutObject(ctx, m.bucket, objectName, filePath, minio.PutObjectOptions{ContentType: contentType})
 	return err
 }
 
-func (m *MinioClient) GetFile(ctx context.Context, objectName, destinationPath string) error {
+func (m *MinioClient) GetFile(ctx context.Context, objectName, destinationPath string) (err error) {
+	ctx, done := m.instrument(ctx, "get", objectName)
+	defer done(&err)
+
 	object, err := m.client.GetObject(ctx, m.bucket, objectName, minio.GetObjectOptions{})
 	if err != nil {
 		return err
@@ -75,7 +149,10 @@ func (m *MinioClient) GetFile(ctx context.Context, objectName, destinationPath s
 	return nil
 }
 
-func (m *MinioClient) GetFileAsStream(ctx context.Context, objectName string) (io.Reader, error) {
+func (m *MinioClient) GetFileAsStream(ctx context.Context, objectName string) (_ io.Reader, err error) {
+	ctx, done := m.instrument(ctx, "open", objectName)
+	defer done(&err)
+
 	object, err := m.client.GetObject(ctx, m.bucket, objectName, minio.GetObjectOptions{})
 	if err != nil {
 		return nil, err
@@ -84,7 +161,49 @@ func (m *MinioClient) GetFileAsStream(ctx context.Context, objectName string) (i
 	return object, nil
 }
 
-func (m *MinioClient) GetFileAsBytes(ctx context.Context, objectName string) ([]byte, error) {
+// ObjectReader streams an object opened by OpenObject. Seeking is cheap: reads after a seek fetch
+// the rest of the object from the new offset.
+type ObjectReader struct {
+	io.ReadSeekCloser
+	Size        int64
+	ContentType string
+	// ETag is the quoted entity tag of the object, as sent in HTTP headers.
+	ETag         string
+	LastModified time.Time
+}
+
+// OpenObject looks the object up and opens it for reading. The caller closes the reader.
+func (m *MinioClient) OpenObject(ctx context.Context, objectName string) (_ *ObjectReader, err error) {
+	ctx, done := m.instrument(ctx, "open", objectName)
+	defer done(&err)
+
+	object, err := m.client.GetObject(ctx, m.bucket, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := object.Stat()
+	if err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+
+	return &ObjectReader{
+		ReadSeekCloser: object,
+		Size:           info.Size,
+		ContentType:    info.ContentType,
+		ETag:           `"` + info.ETag + `"`,
+		LastModified:   info.LastModified,
+	}, nil
+}
+
+func (m *MinioClient) GetFileAsBytes(ctx context.Context, objectName string) (_ []byte, err error) {
+	ctx, done := m.instrument(ctx, "get", objectName)
+	defer done(&err)
+
 	object, err := m.client.GetObject(ctx, m.bucket, objectName, minio.GetObjectOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get object: %v", err)
@@ -105,24 +224,101 @@ func (m *MinioClient) GetFileAsBytes(ctx context.Context, objectName string) ([]
 	return buf.Bytes(), nil
 }
 
-func (m *MinioClient) UploadBytes(ctx context.Context, key string, data []byte, contentType string) error {
+func (m *MinioClient) UploadBytes(ctx context.Context, key string, data []byte, contentType string) (err error) {
+	ctx, done := m.instrument(ctx, "put", key)
+	defer done(&err)
+
 	reader := bytes.NewReader(data)
 
-	_, err := m.client.PutObject(ctx, m.bucket, key, reader, int64(len(data)), minio.PutObjectOptions{
+	_, err = m.client.PutObject(ctx, m.bucket, key, reader, int64(len(data)), minio.PutObjectOptions{
 		ContentType: contentType,
 	})
 	return err
 }
 
-func (m *MinioClient) GetFileURL(ctx context.Context, objectName string) (string, error) {
+// ObjectExists reports whether the object is present in the bucket.
+func (m *MinioClient) ObjectExists(ctx context.Context, objectName string) (_ bool, err error) {
+	ctx, done := m.instrument(ctx, "stat", objectName)
+	defer done(&err)
+
+	_, err = m.client.StatObject(ctx, m.bucket, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// CheckBucket verifies that the storage is reachable with the configured credentials and the bucket exists.
+func (m *MinioClient) CheckBucket(ctx context.Context) (err error) {
+	ctx, done := m.instrument(ctx, "bucket_exists", "")
+	defer done(&err)
+
+	exists, err := m.client.BucketExists(ctx, m.bucket)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("bucket %s does not exist", m.bucket)
+	}
+
+	return nil
+}
+
+func (m *MinioClient) RemoveObject(ctx context.Context, objectName string) (err error) {
+	ctx, done := m.instrument(ctx, "remove", objectName)
+	defer done(&err)
+
+	return m.client.RemoveObject(ctx, m.bucket, objectName, minio.RemoveObjectOptions{})
+}
+
+// StoredObject describes an object returned by ListObjects.
+type StoredObject struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// ListObjects calls fn for every object under prefix, stopping at the first error.
+func (m *MinioClient) ListObjects(ctx context.Context, prefix string, fn func(StoredObject) error) (err error) {
+	ctx, done := m.instrument(ctx, "list", prefix)
+	defer done(&err)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for object := range m.client.ListObjects(ctx, m.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return object.Err
+		}
+
+		if err := fn(StoredObject{Key: object.Key, Size: object.Size, LastModified: object.LastModified}); err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
+
+// GetFileURL presigns a download URL of the object that stays valid for expiry. A non-empty
+// disposition is returned as the Content-Disposition of the download.
+func (m *MinioClient) GetFileURL(ctx context.Context, objectName string, expiry time.Duration, disposition string) (_ string, err error) {
+	ctx, done := m.instrument(ctx, "presign", objectName)
+	defer done(&err)
+
 	reqParams := make(url.Values)
-	reqParams.Set("response-content-disposition", fmt.Sprintf("attachment; filename=\"%s\"", objectName))
+	if disposition != "" {
+		reqParams.Set("response-content-disposition", disposition)
+	}
 
-	presignedURL, err := m.client.PresignedGetObject(
+	presignedURL, err := m.presigner.PresignedGetObject(
 		ctx,
 		m.bucket,
 		objectName,
-		time.Hour*24,
+		expiry,
 		reqParams,
 	)
 
@@ -14,7 +14,11 @@ var Rdb *redis.Client
 type RedisClient interface {
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	// SetNX sets key only if it does not exist yet, and reports whether it did.
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
 	Del(ctx context.Context, key string) error
+	// RunScript evaluates a Lua script atomically, loading it into the script cache on first use.
+	RunScript(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) (interface{}, error)
 }
 
 type RedisAdapter struct {
@@ -33,13 +37,21 @@ func (r *RedisAdapter) Set(ctx context.Context, key string, value interface{}, e
 	return r.client.Set(ctx, key, value, expiration).Err()
 }
 
+func (r *RedisAdapter) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, value, expiration).Result()
+}
+
 func (r *RedisAdapter) Del(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
 
-func CreateRedisConn(redisHost string, redisPort string) *redis.Client {
+func (r *RedisAdapter) RunScript(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	return script.Run(ctx, r.client, keys, args...).Result()
+}
+
+func CreateRedisConn(redisHost string, redisPort int) *redis.Client {
 	Rdb = redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", redisHost, redisPort),
+		Addr:     fmt.Sprintf("%s:%d", redisHost, redisPort),
 		Password: "",
 		DB:       0,
 	})
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"image-resizing-service/pkg/logging"
+	"time"
+)
+
+// RunEvery calls fn every interval until ctx is done. A panic in fn is logged and does not stop the schedule.
+func RunEvery(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runScheduled(ctx, name, fn)
+		}
+	}
+}
+
+func runScheduled(ctx context.Context, name string, fn func(ctx context.Context)) {
+	defer func() {
+		if r := recover(); r != nil {
+			GetLogger().Error("panic in scheduled task", zap.String("task", name), zap.Any("recover", r))
+		}
+	}()
+
+	fn(logging.With(ctx, zap.String("task", name)))
+}
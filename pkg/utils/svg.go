@@ -0,0 +1,252 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"strings"
+)
+
+// SVGMimeType is the content type of SVG documents, which browsers may run scripts in.
+const SVGMimeType = "image/svg+xml"
+
+// svgRasterSize is the length of the longest side SVG inputs are rasterised to.
+// Vectors scale without loss, so small icons are rendered large enough for every preset.
+const svgRasterSize = 2048
+
+// svgDroppedElements are removed from the document together with their children.
+var svgDroppedElements = map[string]bool{
+	"script":        true,
+	"foreignObject": true,
+	"iframe":        true,
+	"embed":         true,
+	"object":        true,
+	"image":         true,
+}
+
+var errInvalidSVG = errors.New("invalid svg document")
+
+// isSVG reports whether h starts an XML document whose root is <svg>. The XML declaration, comments,
+// processing instructions and an SVG doctype may precede the root; a prolog running past the end of
+// h still matches, and SanitizeSVG rejects the document later if its root turns out not to be <svg>.
+func isSVG(h []byte) bool {
+	h = bytes.TrimPrefix(h, []byte("\xEF\xBB\xBF"))
+	h = bytes.TrimLeft(h, " \t\r\n")
+
+	if !bytes.HasPrefix(h, []byte("<")) {
+		return false
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(h))
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			var syntaxErr *xml.SyntaxError
+			return errors.As(err, &syntaxErr) && syntaxErr.Msg == "unexpected EOF"
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			return t.Name.Local == "svg"
+		case xml.Directive:
+			if bytes.HasPrefix(t, []byte("DOCTYPE")) && !bytes.HasPrefix(bytes.TrimSpace(t[len("DOCTYPE"):]), []byte("svg")) {
+				return false
+			}
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) > 0 {
+				return false
+			}
+		}
+	}
+}
+
+// SanitizeSVG rewrites an SVG document without scripts, embedded foreign content,
+// event handler attributes, external references, DTDs and processing instructions.
+// Style sheets that import or reference anything outside the document are emptied.
+func SanitizeSVG(r io.Reader) ([]byte, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = true
+
+	var out bytes.Buffer
+	skipDepth := 0
+	sawRoot := false
+	// style collects the text of the <style> element being read, which is only written once it is complete.
+	var style *bytes.Buffer
+
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidSVG, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if skipDepth > 0 || svgDroppedElements[t.Name.Local] {
+				skipDepth++
+				continue
+			}
+			if !sawRoot {
+				if t.Name.Local != "svg" {
+					return nil, fmt.Errorf("%w: root element is <%s>", errInvalidSVG, t.Name.Local)
+				}
+				sawRoot = true
+			}
+
+			out.WriteString("<" + rawName(t.Name))
+			for _, attr := range t.Attr {
+				if !isSafeSVGAttr(attr) {
+					continue
+				}
+				out.WriteString(" " + rawName(attr.Name) + `="`)
+				_ = xml.EscapeText(&out, []byte(attr.Value))
+				out.WriteString(`"`)
+			}
+			out.WriteString(">")
+			if t.Name.Local == "style" {
+				style = &bytes.Buffer{}
+			}
+		case xml.EndElement:
+			if skipDepth > 0 {
+				skipDepth--
+				continue
+			}
+			if style != nil && t.Name.Local == "style" {
+				if isSafeCSS(style.String()) {
+					_ = xml.EscapeText(&out, style.Bytes())
+				}
+				style = nil
+			}
+			out.WriteString("</" + rawName(t.Name) + ">")
+		case xml.CharData:
+			if skipDepth > 0 {
+				continue
+			}
+			if style != nil {
+				style.Write(t)
+				continue
+			}
+			_ = xml.EscapeText(&out, t)
+		}
+	}
+
+	if !sawRoot {
+		return nil, fmt.Errorf("%w: missing <svg> root", errInvalidSVG)
+	}
+
+	return out.Bytes(), nil
+}
+
+// DecodeSVG sanitises and rasterises an SVG document so its longest side is svgRasterSize pixels.
+func DecodeSVG(r io.Reader) (image.Image, error) {
+	icon, width, height, err := readSVG(r)
+	if err != nil {
+		return nil, err
+	}
+
+	icon.SetTarget(0, 0, float64(width), float64(height))
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	scanner := rasterx.NewScannerGV(width, height, img, img.Bounds())
+	icon.Draw(rasterx.NewDasher(width, height, scanner), 1.0)
+
+	return img, nil
+}
+
+// DecodeSVGConfig returns the size the document will be rasterised to.
+func DecodeSVGConfig(r io.Reader) (image.Config, error) {
+	_, width, height, err := readSVG(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{ColorModel: color.RGBAModel, Width: width, Height: height}, nil
+}
+
+func readSVG(r io.Reader) (*oksvg.SvgIcon, int, int, error) {
+	sanitized, err := SanitizeSVG(r)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(sanitized), oksvg.IgnoreErrorMode)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("%w: %v", errInvalidSVG, err)
+	}
+
+	w, h := icon.ViewBox.W, icon.ViewBox.H
+	if w <= 0 || h <= 0 || math.IsNaN(w) || math.IsNaN(h) || math.IsInf(w, 0) || math.IsInf(h, 0) {
+		return nil, 0, 0, fmt.Errorf("%w: missing or empty viewBox", errInvalidSVG)
+	}
+
+	scale := svgRasterSize / math.Max(w, h)
+	width := int(math.Max(1, math.Round(w*scale)))
+	height := int(math.Max(1, math.Round(h*scale)))
+
+	return icon, width, height, nil
+}
+
+func isSafeSVGAttr(attr xml.Attr) bool {
+	name := strings.ToLower(attr.Name.Local)
+	if strings.HasPrefix(name, "on") {
+		return false
+	}
+
+	if name == "href" || name == "src" {
+		return strings.HasPrefix(strings.TrimSpace(attr.Value), "#")
+	}
+
+	return isSafeCSS(attr.Value)
+}
+
+// isSafeCSS reports whether an attribute value or style sheet only references the document itself.
+// Backslashes are refused outright, since CSS escapes could spell out url( or @import.
+func isSafeCSS(value string) bool {
+	value = strings.ToLower(value)
+	if strings.Contains(value, "javascript:") || strings.Contains(value, "@import") || strings.Contains(value, "\\") {
+		return false
+	}
+
+	for {
+		_, rest, found := strings.Cut(value, "url(")
+		if !found {
+			return true
+		}
+
+		target, remainder := cssURLTarget(rest)
+		if !strings.HasPrefix(target, "#") {
+			return false
+		}
+		value = remainder
+	}
+}
+
+// cssURLTarget returns the target of a url() whose argument starts args, without whitespace and
+// quotes, and the text after it.
+func cssURLTarget(args string) (string, string) {
+	args = strings.TrimLeft(args, " \t\r\n\f")
+	if args != "" && (args[0] == '"' || args[0] == '\'') {
+		target, rest, _ := strings.Cut(args[1:], args[:1])
+		return strings.TrimSpace(target), rest
+	}
+
+	target, rest, _ := strings.Cut(args, ")")
+	return strings.TrimSpace(target), rest
+}
+
+func rawName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeSVG(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain document", input: `<svg viewBox="0 0 1 1"><rect fill="red"></rect></svg>`, want: `<svg viewBox="0 0 1 1"><rect fill="red"></rect></svg>`},
+		{name: "script", input: `<svg><script>alert(1)</script><rect></rect></svg>`, want: `<svg><rect></rect></svg>`},
+		{name: "foreign object", input: `<svg><foreignObject><div>x</div></foreignObject></svg>`, want: `<svg></svg>`},
+		{name: "event handlers", input: `<svg onload="alert(1)"><rect OnClick="alert(1)" fill="red"></rect></svg>`, want: `<svg><rect fill="red"></rect></svg>`},
+		{name: "local url", input: `<svg><rect fill="url(#grad)"></rect></svg>`, want: `<svg><rect fill="url(#grad)"></rect></svg>`},
+		{name: "quoted local url", input: `<svg><rect fill="url( '#grad' )"></rect></svg>`, want: `<svg><rect fill="url( &#39;#grad&#39; )"></rect></svg>`},
+		{name: "unquoted external url", input: `<svg><rect fill="url(https://evil/x)"></rect></svg>`, want: `<svg><rect></rect></svg>`},
+		{name: "single-quoted external url", input: `<svg><rect fill="url('https://evil/x')"></rect></svg>`, want: `<svg><rect></rect></svg>`},
+		{name: "double-quoted protocol-relative url", input: `<svg><rect fill='url("//evil")'></rect></svg>`, want: `<svg><rect></rect></svg>`},
+		{name: "url with leading whitespace", input: `<svg><rect fill="url( https://evil/x)"></rect></svg>`, want: `<svg><rect></rect></svg>`},
+		{name: "uppercase url", input: `<svg><rect fill="URL(https://evil/x)"></rect></svg>`, want: `<svg><rect></rect></svg>`},
+		{name: "external url after a local one", input: `<svg><rect style="fill:url(#a);stroke:url(https://evil/x)"></rect></svg>`, want: `<svg><rect></rect></svg>`},
+		{name: "data url", input: `<svg><rect fill="url(data:image/png;base64,AAAA)"></rect></svg>`, want: `<svg><rect></rect></svg>`},
+		{name: "escaped url in style", input: `<svg><rect style="fill:\75 rl(https://evil/x)"></rect></svg>`, want: `<svg><rect></rect></svg>`},
+		{name: "local xlink:href", input: `<svg><use xlink:href="#icon"></use></svg>`, want: `<svg><use xlink:href="#icon"></use></svg>`},
+		{name: "external xlink:href", input: `<svg><use xlink:href="https://evil/x.svg#icon"></use></svg>`, want: `<svg><use></use></svg>`},
+		{name: "javascript href", input: `<svg><a href="javascript:alert(1)"></a></svg>`, want: `<svg><a></a></svg>`},
+		{name: "safe style sheet", input: `<svg><style>rect { fill: url(#grad) }</style></svg>`, want: `<svg><style>rect { fill: url(#grad) }</style></svg>`},
+		{name: "style sheet with import", input: `<svg><style>@import "https://evil/x.css";</style></svg>`, want: `<svg><style></style></svg>`},
+		{name: "style sheet with external url", input: `<svg><style>rect { fill: url("https://evil/x") }</style></svg>`, want: `<svg><style></style></svg>`},
+		{name: "style sheet in cdata", input: `<svg><style><![CDATA[rect { background: url(//evil) }]]></style></svg>`, want: `<svg><style></style></svg>`},
+		{name: "processing instructions and doctype", input: `<?xml version="1.0"?><!DOCTYPE svg><svg></svg>`, want: `<svg></svg>`},
+		{name: "root is not svg", input: `<html><svg></svg></html>`, wantErr: true},
+		{name: "malformed", input: `<svg><rect fill="red></svg>`, wantErr: true},
+		{name: "empty", input: ``, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SanitizeSVG(strings.NewReader(tt.input))
+			if tt.wantErr {
+				if !errors.Is(err, errInvalidSVG) {
+					t.Fatalf("SanitizeSVG error = %v, want errInvalidSVG", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SanitizeSVG: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("SanitizeSVG =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSVG(t *testing.T) {
+	longComment := "<?xml version=\"1.0\"?>\n<!-- " + strings.Repeat("generated by a very chatty editor ", 20) + "-->\n<svg>"
+
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{name: "svg root", header: `<svg xmlns="http://www.w3.org/2000/svg">`, want: true},
+		{name: "byte order mark and declaration", header: "\xEF\xBB\xBF<?xml version=\"1.0\"?>\n<svg>", want: true},
+		{name: "svg doctype", header: `<!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN" "http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd"><svg>`, want: true},
+		{name: "prolog longer than the sniffed header", header: longComment[:512], want: true},
+		{name: "root tag cut off by the sniffed header", header: `<svg xmlns="http://www.w3.org/2000/svg" width="`, want: true},
+		{name: "html doctype", header: `<!DOCTYPE html><html><svg></svg></html>`, want: false},
+		{name: "other root", header: `<?xml version="1.0"?><feed><svg>`, want: false},
+		{name: "text before the root", header: `hello <svg>`, want: false},
+		{name: "text after the declaration", header: `<?xml version="1.0"?>hello<svg>`, want: false},
+		{name: "not xml", header: `<<<<`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSVG([]byte(tt.header)); got != tt.want {
+				t.Errorf("isSVG(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
@@ -7,35 +7,52 @@ import (
 	"github.com/disintegration/imaging"
 	"github.com/rwcarlsen/goexif/exif"
 	"image"
-	_ "image/gif"
-	_ "image/jpeg"
-	_ "image/png"
-	"os"
 )
 
-func ConvertToWebp(filePath string) (string, error) {
-	originalFile, err := os.Open(filePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to open original file: %w", err)
-	}
-	defer originalFile.Close()
+type WebpMode string
 
-	fileBytes, err := os.ReadFile(filePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to read original file: %w", err)
-	}
+const (
+	WebpLossy        WebpMode = "lossy"
+	WebpLossless     WebpMode = "lossless"
+	WebpNearLossless WebpMode = "near_lossless"
+	WebpAuto         WebpMode = "auto"
+)
+
+const defaultWebpQuality = 80
+
+// WebpOptions controls how an image is encoded to WebP.
+type WebpOptions struct {
+	Mode WebpMode
+	// Quality is the lossy quality factor, 0-100.
+	Quality float32
+	// NearLossless is the near-lossless preprocessing level, 0-100. Lower values discard more precision.
+	NearLossless int
+	// Exact preserves RGB values under fully transparent pixels in lossless output.
+	Exact bool
+	// TryLossless makes the auto mode also encode a lossless candidate for images it classifies as photos
+	// and keep it when it is not larger. This doubles the encoding work for photos, so it is off by default.
+	TryLossless bool
+	// KeepWideGamut skips the sRGB conversion and embeds the source ICC profile in the output instead.
+	KeepWideGamut bool
+}
 
-	return ConvertBytesToWebp(fileBytes)
+// SourceImage is a decoded, orientation-corrected original together with its embedded colour profile.
+// It is decoded once per processing run and every rendition is derived from it.
+type SourceImage struct {
+	Image      image.Image
+	Format     *ImageFormat
+	ICCProfile []byte
 }
 
-func ConvertBytesToWebp(file []byte) (string, error) {
-	img, format, err := image.Decode(bytes.NewReader(file))
+// DecodeSource checks data against the limits, decodes it, applies the EXIF orientation and extracts the ICC profile.
+func DecodeSource(data []byte, limits ImageLimits) (*SourceImage, error) {
+	img, format, err := DecodeImageWithLimits(data, limits)
 	if err != nil {
-		return "", fmt.Errorf("failed to decode image: %w", err)
+		return nil, fmt.Errorf("failed to decode image: %w", err)
 	}
 
-	if format == "jpeg" {
-		exifData, err := exif.Decode(bytes.NewReader(file))
+	if format.Name == "jpeg" || format.Name == "tiff" {
+		exifData, err := exif.Decode(bytes.NewReader(data))
 		if err == nil {
 			orientationTag, err := exifData.Get(exif.Orientation)
 			if err == nil {
@@ -45,30 +62,65 @@ func ConvertBytesToWebp(file []byte) (string, error) {
 		}
 	}
 
-	webpTempFile, err := os.CreateTemp("", "converted-*.webp")
+	return &SourceImage{
+		Image:      img,
+		Format:     format,
+		ICCProfile: ExtractICCProfile(data, format),
+	}, nil
+}
+
+// EncodeWebp encodes img to WebP. Pixels described by a wide-gamut iccProfile are converted to sRGB,
+// unless options.KeepWideGamut is set, in which case the profile is embedded in the output.
+// RGB profiles that cannot be converted (LUT-based ones, for example) are embedded so colour-managed viewers
+// still render them correctly. Profiles for any other colour space do not describe the RGB output and are dropped.
+func EncodeWebp(img image.Image, iccProfile []byte, options WebpOptions) ([]byte, error) {
+	var embedProfile []byte
+	if len(iccProfile) > 0 {
+		profile, err := ParseICCProfile(iccProfile)
+		switch {
+		case err != nil:
+			if iccColourSpace(iccProfile) == "RGB " {
+				embedProfile = iccProfile
+			}
+		case profile.IsSRGB():
+		case options.KeepWideGamut:
+			embedProfile = iccProfile
+		default:
+			img = profile.ConvertToSRGB(img)
+		}
+	}
+
+	encoded, err := encodeWebpPixels(straightRGBA(img), options)
 	if err != nil {
-		return "", fmt.Errorf("failed to create temp webp file: %w", err)
+		return nil, fmt.Errorf("failed to encode image to webp: %w", err)
 	}
-	defer webpTempFile.Close()
 
-	options := &webp.Options{
-		Lossless: false,
-		Quality:  80,
+	if embedProfile == nil {
+		return encoded, nil
 	}
 
-	if err := webp.Encode(webpTempFile, img, options); err != nil {
-		return "", fmt.Errorf("failed to encode image to webp: %w", err)
+	encoded, err = webp.SetMetadata(encoded, embedProfile, "ICCP")
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed icc profile: %w", err)
 	}
 
-	return webpTempFile.Name(), nil
+	return encoded, nil
 }
 
 func autoRotate(img image.Image, orientation int) image.Image {
 	switch orientation {
+	case 2:
+		return imaging.FlipH(img)
 	case 3:
 		return imaging.Rotate180(img)
+	case 4:
+		return imaging.FlipV(img)
+	case 5:
+		return imaging.Transpose(img)
 	case 6:
 		return imaging.Rotate270(img)
+	case 7:
+		return imaging.Transverse(img)
 	case 8:
 		return imaging.Rotate90(img)
 	default:
@@ -77,9 +129,9 @@ func autoRotate(img image.Image, orientation int) image.Image {
 }
 
 func IsValidImage(data []byte) (bool, string) {
-	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	_, format, err := DecodeImageConfig(data)
 	if err != nil {
 		return false, ""
 	}
-	return true, format
+	return true, format.Name
 }
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"github.com/chai2010/webp"
+	"github.com/disintegration/imaging"
+	"image"
+	"math"
+)
+
+// autoModeMaxColors is the number of distinct colours below which an image is treated as flat artwork.
+const autoModeMaxColors = 256
+
+func encodeWebpPixels(pixels *image.RGBA, options WebpOptions) ([]byte, error) {
+	switch options.Mode {
+	case WebpLossless:
+		return encodeWebpLossless(pixels, options.Exact)
+	case WebpNearLossless:
+		return encodeWebpLossless(nearLosslessQuantize(pixels, options.NearLossless), options.Exact)
+	case WebpAuto:
+		options.Mode = ChooseWebpMode(pixels)
+
+		encoded, err := encodeWebpPixels(pixels, options)
+		if err != nil || options.Mode == WebpLossless || !options.TryLossless {
+			return encoded, err
+		}
+
+		lossless, err := encodeWebpLossless(pixels, options.Exact)
+		if err == nil && len(lossless) <= len(encoded) {
+			return lossless, nil
+		}
+		return encoded, nil
+	default:
+		quality := options.Quality
+		if quality <= 0 {
+			quality = defaultWebpQuality
+		}
+		return webp.EncodeRGBA(pixels, float32(math.Min(float64(quality), 100)))
+	}
+}
+
+func encodeWebpLossless(pixels *image.RGBA, exact bool) ([]byte, error) {
+	if exact {
+		return webp.EncodeExactLosslessRGBA(pixels)
+	}
+
+	return webp.EncodeLosslessRGBA(pixels)
+}
+
+// ChooseWebpMode picks lossless for images with transparency or flat colour (logos, screenshots,
+// diagrams) and lossy for photographic content. It samples at most ~64k pixels for the colour analysis.
+func ChooseWebpMode(pixels *image.RGBA) WebpMode {
+	for i := 3; i < len(pixels.Pix); i += 4 {
+		if pixels.Pix[i] != 0xFF {
+			return WebpLossless
+		}
+	}
+
+	bounds := pixels.Bounds()
+	step := max(1, int(math.Sqrt(float64(bounds.Dx()*bounds.Dy())/65536)))
+
+	colors := make(map[uint32]struct{}, autoModeMaxColors+1)
+	samples, flat := 0, 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			i := pixels.PixOffset(x, y)
+			color := uint32(pixels.Pix[i])<<16 | uint32(pixels.Pix[i+1])<<8 | uint32(pixels.Pix[i+2])
+			if len(colors) <= autoModeMaxColors {
+				colors[color] = struct{}{}
+			}
+
+			if x+1 < bounds.Max.X {
+				j := i + 4
+				if pixels.Pix[i] == pixels.Pix[j] && pixels.Pix[i+1] == pixels.Pix[j+1] && pixels.Pix[i+2] == pixels.Pix[j+2] {
+					flat++
+				}
+			}
+			samples++
+		}
+	}
+
+	if len(colors) <= autoModeMaxColors || (samples > 0 && float64(flat)/float64(samples) > 0.5) {
+		return WebpLossless
+	}
+
+	return WebpLossy
+}
+
+// nearLosslessQuantize mirrors libwebp's near-lossless preprocessing: pixels in smooth areas have the
+// low bits of their colour channels rounded away so the lossless encoder compresses them better, while edges are left untouched.
+func nearLosslessQuantize(src *image.RGBA, level int) *image.RGBA {
+	if level >= 100 {
+		return src
+	}
+
+	bits := 5 - max(level, 0)/20
+	limit := 1 << bits
+
+	out := &image.RGBA{Pix: append([]uint8(nil), src.Pix...), Stride: src.Stride, Rect: src.Rect}
+	bounds := src.Bounds()
+	for y := bounds.Min.Y + 1; y < bounds.Max.Y-1; y++ {
+		for x := bounds.Min.X + 1; x < bounds.Max.X-1; x++ {
+			i := src.PixOffset(x, y)
+			if !isSmoothPixel(src, i, limit) {
+				continue
+			}
+			for c := 0; c < 3; c++ {
+				out.Pix[i+c] = discretize(src.Pix[i+c], bits)
+			}
+		}
+	}
+
+	return out
+}
+
+func isSmoothPixel(img *image.RGBA, i, limit int) bool {
+	for _, neighbour := range []int{i - 4, i + 4, i - img.Stride, i + img.Stride} {
+		for c := 0; c < 4; c++ {
+			diff := int(img.Pix[i+c]) - int(img.Pix[neighbour+c])
+			if diff >= limit || diff <= -limit {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func discretize(value uint8, bits int) uint8 {
+	mask := (1 << bits) - 1
+	biased := int(value) + (mask >> 1) + ((int(value) >> bits) & 1)
+	if biased > 0xFF {
+		return 0xFF
+	}
+
+	return uint8(biased &^ mask)
+}
+
+// straightRGBA returns the pixels of img as non-premultiplied RGBA. libwebp expects straight alpha,
+// so the buffer is handed over as *image.RGBA to stop the encoder from premultiplying it again.
+func straightRGBA(img image.Image) *image.RGBA {
+	nrgba := imaging.Clone(img)
+
+	return &image.RGBA{Pix: nrgba.Pix, Stride: nrgba.Stride, Rect: nrgba.Rect}
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"bytes"
+	"github.com/chai2010/webp"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestEncodeWebpUnparsedProfiles(t *testing.T) {
+	tests := []struct {
+		name       string
+		profile    []byte
+		wantEmbeds bool
+	}{
+		{name: "rgb profile without colorant tags", profile: iccHeader("RGB "), wantEmbeds: true},
+		{name: "cmyk profile", profile: iccHeader("CMYK"), wantEmbeds: false},
+		{name: "gray profile", profile: iccHeader("GRAY"), wantEmbeds: false},
+		{name: "truncated header", profile: []byte("short"), wantEmbeds: false},
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+3] = 0x80, 0xFF
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := EncodeWebp(img, tt.profile, WebpOptions{Mode: WebpLossless})
+			if err != nil {
+				t.Fatalf("EncodeWebp: %v", err)
+			}
+
+			embedded, _ := webp.GetMetadata(encoded, "ICCP")
+			if got := bytes.Equal(embedded, tt.profile); got != tt.wantEmbeds {
+				t.Errorf("profile embedded = %v, want %v", got, tt.wantEmbeds)
+			}
+			if !tt.wantEmbeds && len(embedded) > 0 {
+				t.Errorf("unexpected ICCP chunk of %d bytes", len(embedded))
+			}
+
+			decoded, err := webp.DecodeRGBA(encoded)
+			if err != nil {
+				t.Fatalf("DecodeRGBA: %v", err)
+			}
+			if got := decoded.RGBAAt(1, 1); got != (color.RGBA{R: 0x80, A: 0xFF}) {
+				t.Errorf("pixel = %v, want unchanged", got)
+			}
+		})
+	}
+}
+
+// iccHeader returns a profile with a valid header for the given colour space and an empty tag table.
+func iccHeader(space string) []byte {
+	data := make([]byte, 132)
+	copy(data[12:16], "mntr")
+	copy(data[16:20], space)
+	copy(data[20:24], "XYZ ")
+	copy(data[36:40], "acsp")
+
+	return data
+}